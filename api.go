@@ -0,0 +1,90 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsapigatewayv2integrations"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/scud"
+)
+
+// MountAPI mounts the pipeline 𝑚: A ⟼ B as the Express state machine behind
+// the route `POST path` of scud API Gateway, so that HTTP and event entry
+// points share one typed definition. The request body is decoded as A, it is
+// the detail of event consumed by the pipeline. The response is the reply
+// of synchronous execution, its `output` is JSON of B.
+//
+//	typestep.MountAPI(gw, "/recommend", m)
+func MountAPI[A, B any](gw *scud.Gateway, path string, m duct.Morphism[A, B]) awsstepfunctions.IStateMachine {
+	ts := NewTypeStep(gw.Construct, jsii.String("API"+routeOf(path)), &TypeStepProps{}).(*typeStep)
+	ts.api = &route{gw: gw, path: path}
+	StateMachine(ts, m)
+
+	return ts.pipelines[len(ts.pipelines)-1]
+}
+
+type route struct {
+	gw   *scud.Gateway
+	path string
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// routeOf derives the construct id from the path (e.g. /user/{id} ⟼ UserId)
+func routeOf(path string) string {
+	id := ""
+	for _, x := range nonAlphanumeric.Split(path, -1) {
+		if x != "" {
+			id += strings.ToUpper(x[:1]) + x[1:]
+		}
+	}
+	return id
+}
+
+// request wraps the body of HTTP request as the detail of event, the reply
+// is the payload of the last step.
+func (ts *typeStep) request(chain awsstepfunctions.IChainable) awsstepfunctions.IChainable {
+	request := awsstepfunctions.NewPass(ts.scope, jsii.String("Request"),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{"detail.$": "$"},
+		},
+	)
+	reply := awsstepfunctions.NewPass(ts.scope, jsii.String("Reply"),
+		&awsstepfunctions.PassProps{
+			OutputPath: jsii.String(ts.args),
+		},
+	)
+
+	return request.Next(chain).Next(reply)
+}
+
+// mount the state machine behind the route, the state machine is started
+// synchronously by API Gateway.
+func (ts *typeStep) mount(states awsstepfunctions.StateMachine) {
+	integration := awsapigatewayv2integrations.NewHttpStepFunctionsIntegration(jsii.String(*ts.Node().Id()),
+		&awsapigatewayv2integrations.HttpStepFunctionsIntegrationProps{
+			StateMachine: states,
+			Subtype:      awsapigatewayv2.HttpIntegrationSubtype_STEPFUNCTIONS_START_SYNC_EXECUTION,
+		},
+	)
+
+	ts.api.gw.RestAPI.AddRoutes(
+		&awsapigatewayv2.AddRoutesOptions{
+			Path:        jsii.String(ts.api.path),
+			Methods:     &[]awsapigatewayv2.HttpMethod{awsapigatewayv2.HttpMethod_POST},
+			Integration: integration,
+		},
+	)
+}
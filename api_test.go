@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/scud"
+	"github.com/fogfish/typestep"
+)
+
+func TestMountAPI(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	gw := scud.NewGateway(stack, jsii.String("Gateway"), &scud.GatewayProps{})
+
+	a := typestep.Function_FromFunctionArn[User, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[User](event)
+	p2 := typestep.Join(a, p1)
+
+	typestep.MountAPI(gw, "/recommend", p2)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::StepFunctions::StateMachine"),
+		map[string]any{
+			"StateMachineType": "EXPRESS",
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::ApiGatewayV2::Integration"),
+		map[string]any{
+			"IntegrationSubtype": "StepFunctions-StartSyncExecution",
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::ApiGatewayV2::Route"),
+		map[string]any{
+			"RouteKey": "POST /recommend",
+		},
+	)
+	template.ResourceCountIs(jsii.String("AWS::Events::Rule"), jsii.Number(0))
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Request":{"Type":"Pass","Parameters":{"detail.$":"$"},"Next":"MapA"}`,
+		`"InputPath":"$.detail"`,
+		`"Reply":{"Type":"Pass","OutputPath":"$.Payload","End":true}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// Package asl is the lightweight backend of typestep pipelines, it emits
+// the Amazon States Language definition and the minimal CloudFormation
+// template directly from the morphism. The package does not depend on
+// AWS CDK, it does not require jsii runtime (Node) at build time, which
+// makes it usable by CI images without the CDK toolchain.
+//
+// Resources are referenced by ARN, the morphism is composed using the same
+// vocabulary as typestep:
+//
+//	f := asl.Function[User, Account]("arn:aws:lambda:eu-west-1:000000000000:function:account")
+//
+//	p1 := asl.From[User]("my-event-bus")
+//	p2 := asl.Join(f, p1)
+//	p3 := asl.ToQueue("arn:aws:sqs:eu-west-1:000000000000:accounts", p2)
+//
+//	template, err := asl.Template("Pipe", p3)
+//
+// The backend covers the core of composition: events source, functions,
+// sequences (Lift, Wrap, Unit) and sinks to queue or event bus. Pipelines
+// using other features of typestep require AWS CDK.
+package asl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fogfish/golem/duct"
+)
+
+// Lambda is the typed function 𝑓: A ⟼ B deployed outside of the template,
+// it is referenced by ARN.
+type Lambda[A, B any] struct {
+	arn string
+}
+
+// HKT1 is a phantom method that represents the type-level information of
+// a function A → B. It is not meant to be called.
+func (Lambda[A, B]) HKT1(func(A) B) {}
+
+// Function references the typed function by ARN
+func Function[A, B any](arn string) Lambda[A, B] {
+	return Lambda[A, B]{arn: arn}
+}
+
+type source struct {
+	bus  string
+	cat  []string
+	kind reflect.Type
+}
+
+type lambda struct {
+	arn        string
+	concurency int
+}
+
+type queue struct {
+	arn string
+}
+
+type eventbus struct {
+	arn    string
+	source string
+	cat    []string
+	kind   reflect.Type
+}
+
+// From creates new morphism 𝑚, binding it with EventBridge bus (name or
+// ARN) for reading category `A` events.
+func From[A any](bus string, cat ...string) duct.Morphism[A, A] {
+	return duct.From(duct.L1[A](source{bus: bus, cat: cat, kind: reflect.TypeOf(new(A)).Elem()}))
+}
+
+// Join composes lambda function 𝑓: B ⟼ C with morphism 𝑚: A ⟼ B.
+func Join[A, B, C any](f Lambda[B, C], m duct.Morphism[A, B]) duct.Morphism[A, C] {
+	return duct.Join(duct.L2[B, C](lambda{arn: f.arn, concurency: 1}), m)
+}
+
+// Lift composes lambda function 𝑓: B ⟼ C with morphism 𝑚: A ⟼ []B, the
+// computation is nested within the slice context (see typestep.Lift).
+func Lift[A, B, C any](f Lambda[B, C], m duct.Morphism[A, []B]) duct.Morphism[A, C] {
+	return duct.LiftF(duct.L2[B, C](lambda{arn: f.arn, concurency: 1}), m)
+}
+
+// LiftP is equivalent to Lift but allows to specify the maximum number of
+// concurrent invocations of the lambda function.
+func LiftP[A, B, C any](n int, f Lambda[B, C], m duct.Morphism[A, []B]) duct.Morphism[A, C] {
+	return duct.LiftF(duct.L2[B, C](lambda{arn: f.arn, concurency: n}), m)
+}
+
+// Wrap operates on the inner structure of the morphism 𝑚: A ⟼ []B
+// (see typestep.Wrap).
+func Wrap[A, B any](m duct.Morphism[A, []B]) duct.Morphism[A, B] {
+	return duct.WrapF(m)
+}
+
+// Unit collapses the nested morphism (see typestep.Unit).
+func Unit[A, B any](m duct.Morphism[A, B]) duct.Morphism[A, []B] {
+	return duct.Unit(m)
+}
+
+// ToQueue yields results of 𝑚: A ⟼ B binding it with AWS SQS queue (ARN).
+func ToQueue[A, B any](arn string, m duct.Morphism[A, B]) duct.Morphism[A, duct.Void] {
+	return duct.Yield(duct.L1[B](queue{arn: arn}), m)
+}
+
+// ToEventBus yields results of 𝑚: A ⟼ B binding it with AWS EventBridge
+// bus (ARN).
+func ToEventBus[A, B any](source string, arn string, m duct.Morphism[A, B], cat ...string) duct.Morphism[A, duct.Void] {
+	return duct.Yield(duct.L1[B](eventbus{arn: arn, source: source, cat: cat, kind: reflect.TypeOf(new(B)).Elem()}), m)
+}
+
+//------------------------------------------------------------------------------
+
+// Definition renders the Amazon States Language definition of the pipeline
+func Definition[A, B any](m duct.Morphism[A, B]) ([]byte, error) {
+	p, err := compile(m)
+	if err != nil {
+		return nil, err
+	}
+	return encode(p.definition())
+}
+
+// Template renders CloudFormation template of the pipeline: the state
+// machine, the rule of event bus and IAM roles. Logical ids of resources are
+// prefixed with the name.
+func Template[A, B any](name string, m duct.Morphism[A, B]) ([]byte, error) {
+	p, err := compile(m)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := encode(p.definition())
+	if err != nil {
+		return nil, err
+	}
+
+	return encode(p.template(name, string(def)))
+}
+
+func compile[A, B any](m duct.Morphism[A, B]) (*pipeline, error) {
+	p := &pipeline{}
+	if err := m.Apply(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func encode(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+//------------------------------------------------------------------------------
+
+// chain of states at the nesting level
+type chain struct {
+	names  []string
+	states map[string]map[string]any
+	args   string
+}
+
+func (c *chain) append(name string, state map[string]any) {
+	if c.states == nil {
+		c.states = map[string]map[string]any{}
+	}
+	c.names = append(c.names, name)
+	c.states[name] = state
+}
+
+func (c *chain) definition() map[string]any {
+	for i, name := range c.names {
+		if i == len(c.names)-1 {
+			c.states[name]["End"] = true
+		} else {
+			c.states[name]["Next"] = c.names[i+1]
+		}
+	}
+	return map[string]any{"StartAt": c.names[0], "States": c.states}
+}
+
+type pipeline struct {
+	duct.AstVisitor
+	source  *source
+	stack   []*chain
+	ids     map[string]int
+	lambdas []string
+	queues  []string
+	buses   []string
+}
+
+// unique id of the state
+func (p *pipeline) unique(id string) string {
+	if p.ids == nil {
+		p.ids = map[string]int{}
+	}
+	p.ids[id]++
+	if n := p.ids[id]; n > 1 {
+		return fmt.Sprintf("%s%d", id, n)
+	}
+	return id
+}
+
+func (p *pipeline) top() *chain { return p.stack[len(p.stack)-1] }
+
+func (p *pipeline) definition() map[string]any {
+	return p.stack[0].definition()
+}
+
+func (p *pipeline) OnEnterMorphism(depth int, node duct.AstSeq) error {
+	p.stack = []*chain{{args: "$"}}
+	return nil
+}
+
+func (p *pipeline) OnLeaveMorphism(depth int, node duct.AstSeq) error {
+	if len(p.stack) != 1 {
+		return fmt.Errorf("bad definition of compute pipeline")
+	}
+	if p.source == nil {
+		return fmt.Errorf("undefined event source for compute pipeline")
+	}
+	if len(p.stack[0].names) == 0 {
+		return fmt.Errorf("compute pipeline has no steps")
+	}
+	return nil
+}
+
+func (p *pipeline) OnEnterFrom(depth int, node duct.AstFrom) error {
+	f, ok := node.Source.(source)
+	if !ok {
+		return fmt.Errorf("source %T is not supported by asl backend", node.Source)
+	}
+	p.source = &f
+	p.top().args = "$.detail"
+	return nil
+}
+
+func (p *pipeline) OnEnterSeq(depth int, node duct.AstSeq) error {
+	p.stack = append(p.stack, &chain{args: "$"})
+	return nil
+}
+
+func (p *pipeline) OnLeaveSeq(depth int, node duct.AstSeq) error {
+	inner := p.top()
+	p.stack = p.stack[:len(p.stack)-1]
+	outer := p.top()
+
+	// Note: the iteration yields the element, not the response of the step
+	if inner.args != "$" {
+		inner.append(p.unique("Item"), map[string]any{"Type": "Pass", "InputPath": inner.args})
+	}
+
+	concurency := 1
+	if f, ok := node.Seq[0].(*duct.AstMap); ok {
+		if f, ok := f.F.(lambda); ok {
+			concurency = f.concurency
+		}
+	}
+
+	processor := inner.definition()
+	processor["ProcessorConfig"] = map[string]any{"Mode": "INLINE"}
+
+	outer.append(p.unique("Seq"), map[string]any{
+		"Type":           "Map",
+		"ItemsPath":      outer.args,
+		"MaxConcurrency": concurency,
+		"ItemProcessor":  processor,
+	})
+	outer.args = "$"
+	return nil
+}
+
+func (p *pipeline) OnEnterMap(depth int, node duct.AstMap) error {
+	f, ok := node.F.(lambda)
+	if !ok {
+		return fmt.Errorf("step %T is not supported by asl backend", node.F)
+	}
+
+	c := p.top()
+	c.append(p.unique("Map"+nameOf(f.arn)), map[string]any{
+		"Type":      "Task",
+		"Resource":  "arn:aws:states:::lambda:invoke",
+		"InputPath": c.args,
+		"Parameters": map[string]any{
+			"FunctionName": f.arn,
+			"Payload.$":    "$",
+		},
+		"Retry": []any{
+			map[string]any{
+				"ErrorEquals":     []string{"Lambda.ClientExecutionTimeoutException", "Lambda.ServiceException", "Lambda.AWSLambdaException", "Lambda.SdkClientException"},
+				"IntervalSeconds": 2,
+				"MaxAttempts":     6,
+				"BackoffRate":     2,
+			},
+		},
+	})
+	c.args = "$.Payload"
+	p.lambdas = append(p.lambdas, f.arn)
+	return nil
+}
+
+func (p *pipeline) OnEnterYield(depth int, node duct.AstYield) error {
+	c := p.top()
+
+	switch f := node.Target.(type) {
+	case queue:
+		url, err := urlOf(f.arn)
+		if err != nil {
+			return err
+		}
+		c.append(p.unique("Sink"), map[string]any{
+			"Type":     "Task",
+			"Resource": "arn:aws:states:::sqs:sendMessage",
+			"Parameters": map[string]any{
+				"QueueUrl":      url,
+				"MessageBody.$": c.args,
+			},
+		})
+		p.queues = append(p.queues, f.arn)
+		return nil
+
+	case eventbus:
+		if f.kind.Kind() == reflect.Slice {
+			return fmt.Errorf("sequence %s is not supported by asl sink, use Wrap", f.kind)
+		}
+		kind := detailTypeOf(f.kind)
+		if len(f.cat) != 0 {
+			kind = f.cat[0]
+		}
+		c.append(p.unique("Sink"), map[string]any{
+			"Type":     "Task",
+			"Resource": "arn:aws:states:::events:putEvents",
+			"Parameters": map[string]any{
+				"Entries": []any{
+					map[string]any{
+						"Source":       f.source,
+						"DetailType":   kind,
+						"EventBusName": f.arn,
+						"Detail.$":     c.args,
+					},
+				},
+			},
+		})
+		p.buses = append(p.buses, f.arn)
+		return nil
+
+	default:
+		return fmt.Errorf("sink %T is not supported by asl backend", f)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (p *pipeline) template(name, definition string) map[string]any {
+	cat := p.source.cat
+	if len(cat) == 0 {
+		cat = []string{detailTypeOf(p.source.kind)}
+	}
+
+	statements := []any{}
+	if len(p.lambdas) != 0 {
+		resources := []string{}
+		for _, arn := range p.lambdas {
+			resources = append(resources, arn, arn+":*")
+		}
+		statements = append(statements, statement("lambda:InvokeFunction", resources))
+	}
+	if len(p.queues) != 0 {
+		statements = append(statements, statement("sqs:SendMessage", p.queues))
+	}
+	if len(p.buses) != 0 {
+		statements = append(statements, statement("events:PutEvents", p.buses))
+	}
+
+	machine := name + "StateMachine"
+	resources := map[string]any{
+		machine + "Role": role("states.amazonaws.com", machine+"Policy", statements),
+		machine: map[string]any{
+			"Type": "AWS::StepFunctions::StateMachine",
+			"Properties": map[string]any{
+				"DefinitionString": definition,
+				"RoleArn":          map[string]any{"Fn::GetAtt": []string{machine + "Role", "Arn"}},
+			},
+		},
+		name + "RuleRole": role("events.amazonaws.com", name+"RulePolicy",
+			[]any{
+				map[string]any{
+					"Effect":   "Allow",
+					"Action":   "states:StartExecution",
+					"Resource": map[string]any{"Ref": machine},
+				},
+			},
+		),
+		name + "Rule": map[string]any{
+			"Type": "AWS::Events::Rule",
+			"Properties": map[string]any{
+				"EventBusName": p.source.bus,
+				"EventPattern": map[string]any{"detail-type": cat},
+				"State":        "ENABLED",
+				"Targets": []any{
+					map[string]any{
+						"Id":      "Target0",
+						"Arn":     map[string]any{"Ref": machine},
+						"RoleArn": map[string]any{"Fn::GetAtt": []string{name + "RuleRole", "Arn"}},
+					},
+				},
+			},
+		},
+	}
+
+	return map[string]any{
+		"AWSTemplateFormatVersion": "2010-09-09",
+		"Resources":                resources,
+		"Outputs": map[string]any{
+			machine: map[string]any{"Value": map[string]any{"Ref": machine}},
+		},
+	}
+}
+
+func statement(action string, resources []string) map[string]any {
+	return map[string]any{
+		"Effect":   "Allow",
+		"Action":   action,
+		"Resource": resources,
+	}
+}
+
+func role(principal, policy string, statements []any) map[string]any {
+	props := map[string]any{
+		"AssumeRolePolicyDocument": map[string]any{
+			"Version": "2012-10-17",
+			"Statement": []any{
+				map[string]any{
+					"Effect":    "Allow",
+					"Action":    "sts:AssumeRole",
+					"Principal": map[string]any{"Service": principal},
+				},
+			},
+		},
+	}
+	if len(statements) != 0 {
+		props["Policies"] = []any{
+			map[string]any{
+				"PolicyName":     policy,
+				"PolicyDocument": map[string]any{"Version": "2012-10-17", "Statement": statements},
+			},
+		}
+	}
+	return map[string]any{"Type": "AWS::IAM::Role", "Properties": props}
+}
+
+//------------------------------------------------------------------------------
+
+// named types define detail-type of events (see typestep.Named)
+type named interface{ DetailType() string }
+
+var typeNamed = reflect.TypeOf((*named)(nil)).Elem()
+
+func detailTypeOf(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + detailTypeOf(t.Elem())
+	case reflect.Slice:
+		return "[]" + detailTypeOf(t.Elem())
+	}
+
+	if t.Implements(typeNamed) {
+		return reflect.Zero(t).Interface().(named).DetailType()
+	}
+	return t.Name()
+}
+
+// nameOf the function from its ARN, the state is named after it
+func nameOf(arn string) string {
+	_, name, has := strings.Cut(arn, ":function:")
+	if !has {
+		return "Lambda"
+	}
+	name, _, _ = strings.Cut(name, ":")
+
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '-' || r == '_' || r == '.':
+			upper = true
+		case upper:
+			sb.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// urlOf the queue from its ARN
+func urlOf(arn string) (string, error) {
+	seq := strings.Split(arn, ":")
+	if len(seq) != 6 || seq[2] != "sqs" {
+		return "", fmt.Errorf("invalid ARN of queue %s", arn)
+	}
+
+	domain := "amazonaws.com"
+	if seq[1] == "aws-cn" {
+		domain = "amazonaws.com.cn"
+	}
+	return fmt.Sprintf("https://sqs.%s.%s/%s/%s", seq[3], domain, seq[4], seq[5]), nil
+}
@@ -0,0 +1,133 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package asl_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fogfish/typestep/asl"
+)
+
+type User struct {
+	ID string `json:"id"`
+}
+
+type Account struct {
+	ID string `json:"id"`
+}
+
+const (
+	fnA   = "arn:aws:lambda:eu-west-1:000000000000:function:user-accounts"
+	fnB   = "arn:aws:lambda:eu-west-1:000000000000:function:account"
+	queue = "arn:aws:sqs:eu-west-1:000000000000:my-queue"
+	bus   = "arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"
+)
+
+func TestDefinition(t *testing.T) {
+	a := asl.Function[User, []Account](fnA)
+	b := asl.Function[Account, Account](fnB)
+
+	p1 := asl.From[User]("my-event-bus")
+	p2 := asl.Join(a, p1)
+	p3 := asl.Lift(b, p2)
+	p4 := asl.Unit(p3)
+	p5 := asl.ToQueue(queue, p4)
+
+	raw, err := asl.Definition(p5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	def := string(raw)
+	for _, expect := range []string{
+		`"StartAt":"MapUserAccounts"`,
+		`"MapUserAccounts":{"InputPath":"$.detail","Next":"Seq","Parameters":{"FunctionName":"` + fnA + `","Payload.$":"$"}`,
+		`"ItemsPath":"$.Payload"`,
+		`"ItemProcessor":{"ProcessorConfig":{"Mode":"INLINE"},"StartAt":"MapAccount"`,
+		`"Item":{"End":true,"InputPath":"$.Payload","Type":"Pass"}`,
+		`"Sink":{"End":true,"Parameters":{"MessageBody.$":"$","QueueUrl":"https://sqs.eu-west-1.amazonaws.com/000000000000/my-queue"}`,
+	} {
+		if !strings.Contains(def, expect) {
+			t.Errorf("definition does not contain %s\n%s", expect, def)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	a := asl.Function[User, Account](fnB)
+
+	p1 := asl.From[User]("my-event-bus")
+	p2 := asl.Join(a, p1)
+	p3 := asl.ToEventBus("test", bus, p2)
+
+	raw, err := asl.Template("Pipe", p3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfn struct {
+		Resources map[string]struct {
+			Type       string         `json:"Type"`
+			Properties map[string]any `json:"Properties"`
+		} `json:"Resources"`
+	}
+	if err := json.Unmarshal(raw, &cfn); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, kind := range map[string]string{
+		"PipeStateMachine":     "AWS::StepFunctions::StateMachine",
+		"PipeStateMachineRole": "AWS::IAM::Role",
+		"PipeRule":             "AWS::Events::Rule",
+		"PipeRuleRole":         "AWS::IAM::Role",
+	} {
+		if cfn.Resources[id].Type != kind {
+			t.Errorf("resource %s is not %s", id, kind)
+		}
+	}
+
+	rule, _ := json.Marshal(cfn.Resources["PipeRule"].Properties)
+	if !strings.Contains(string(rule), `"EventPattern":{"detail-type":["User"]}`) {
+		t.Errorf("unexpected rule %s", rule)
+	}
+
+	def, _ := cfn.Resources["PipeStateMachine"].Properties["DefinitionString"].(string)
+	if !strings.Contains(def, `"Entries":[{"Detail.$":"$.Payload","DetailType":"Account","EventBusName":"`+bus+`","Source":"test"}]`) {
+		t.Errorf("unexpected definition %s", def)
+	}
+
+	role, _ := json.Marshal(cfn.Resources["PipeStateMachineRole"].Properties)
+	for _, expect := range []string{
+		`"Action":"lambda:InvokeFunction","Effect":"Allow","Resource":["` + fnB + `","` + fnB + `:*"]`,
+		`"Action":"events:PutEvents","Effect":"Allow","Resource":["` + bus + `"]`,
+	} {
+		if !strings.Contains(string(role), expect) {
+			t.Errorf("role does not contain %s", expect)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	a := asl.Function[User, []Account](fnA)
+
+	p1 := asl.From[User]("my-event-bus")
+	p2 := asl.Join(a, p1)
+	p3 := asl.ToQueue("arn:aws:sns:eu-west-1:000000000000:my-topic", p2)
+
+	if _, err := asl.Definition(p3); err == nil {
+		t.Errorf("invalid queue is accepted")
+	}
+
+	p4 := asl.ToEventBus("test", bus, p2)
+	if _, err := asl.Definition(p4); err == nil {
+		t.Errorf("sequence is accepted by event bus")
+	}
+}
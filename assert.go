@@ -0,0 +1,89 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// ErrAssertion is the error of execution, which payload violates
+// the invariant declared with [Assert].
+const ErrAssertion = "typestep.AssertionFailed"
+
+// Assert the invariant over the payload of morphism 𝑚: A ⟼ B. The execution
+// fails immediately with the message, which is routed to the dead-letter
+// queue, if the payload violates the predicate (see [Eq], [Prefix], [Exists]
+// and [Numeric]). It is a lightweight data-quality gate evaluated by the state
+// machine, no dedicated function is required.
+//
+//	typestep.Assert(typestep.Numeric(func(o *Order) *int { return &o.Qty }, ">", 0), "quantity is not positive", m)
+func Assert[A, B any](pred Predicate[B], message string, m duct.Morphism[A, B]) duct.Morphism[A, B] {
+	return duct.Join(duct.L2[B, B](assertion{path: pred.path, pattern: pred.pattern, message: message}), m)
+}
+
+// invariant over the payload, the payload is passed as-is
+type assertion struct {
+	path    fieldPath
+	pattern any
+	message string
+}
+
+// condition of the invariant as JSONata expression over the payload at path
+func (a assertion) condition(n runtime.FieldNamer, args string) string {
+	field := "$states.input" + strings.TrimPrefix(args, "$")
+	for _, key := range a.path.names(n) {
+		field += ".`" + key + "`"
+	}
+
+	if spec, ok := a.pattern.(map[string]any); ok {
+		switch {
+		case spec["prefix"] != nil:
+			prefix := spec["prefix"].(string)
+			return fmt.Sprintf("$substring(%s, 0, %d) = %s", field, utf8.RuneCountInString(prefix), quote(prefix))
+		case spec["exists"] != nil:
+			return fmt.Sprintf("$exists(%s)", field)
+		case spec["numeric"] != nil:
+			op := spec["numeric"].([]any)
+			return fmt.Sprintf("%s %s %v", field, op[0], op[1])
+		}
+	}
+
+	value, _ := json.Marshal(a.pattern)
+	return fmt.Sprintf("%s = %s", field, value)
+}
+
+// assert the invariant before the next step
+//
+//	Choice ⟼ (violated) Diagnostic ⟼ DLQ ⟼ Fail
+//	       ⟼ ...
+func (ts *typeStep) assert(f assertion) {
+	id := ts.idOf("Assert")
+
+	check := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $not("+f.condition(ts.namer, ts.args)+") %}")),
+		ts.reject(id, "Violated", ErrAssertion, f.message),
+		nil,
+	)
+
+	ts.appendChain(
+		check.Afterwards(&awsstepfunctions.AfterwardsOptions{IncludeOtherwise: jsii.Bool(true)}),
+		id,
+		1,
+	)
+}
@@ -0,0 +1,63 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestAssert(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[Order, Order](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[Order](event)
+	p2 := typestep.Assert(typestep.Prefix(func(o *Order) *string { return &o.Customer }, "c-"), "unknown customer", p1)
+	p3 := typestep.Join(a, p2)
+	p4 := typestep.Assert(typestep.Numeric(func(o *Order) *int { return &o.Seq }, ">", 0), "sequence is not positive", p3)
+	p5 := typestep.ToQueue(queue, p4)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: queue,
+		},
+	)
+	typestep.StateMachine(ts, p5)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"Assert0":{"Type":"Choice","QueryLanguage":"JSONata","Choices":[{"Condition":"{% $not($substring($states.input.detail.` + "`customer`" + `, 0, 2) = \"c-\") %}","Next":"Assert0Violated"}],"Default":"MapA"}`,
+		`"Condition":"{% $not($states.input.Payload.` + "`seq`" + ` > 0) %}"`,
+		`"Cause":"unknown customer"`,
+		`"Error":"typestep.AssertionFailed"`,
+		`"Next":"Assert1Try"`,
+		`"InputPath":"$.detail"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// audit archives the current value of the pipeline into the bucket, the state
+// is not modified. Objects are partitioned by pipeline, execution and step:
+//
+//	typestep/{pipeline}/{execution}/{step}/{uuid}.json
+//
+// The uuid distinguishes values of the same step within nested computations.
+func (ts *typeStep) audit(step string) {
+	key := "typestep/" + ts.name() + "/{}/" + step + "/{}.json"
+
+	archive := awsstepfunctionstasks.NewCallAwsService(ts.scope, jsii.String("Audit"+step),
+		&awsstepfunctionstasks.CallAwsServiceProps{
+			Service: jsii.String("s3"),
+			Action:  jsii.String("putObject"),
+			Parameters: &map[string]any{
+				"Bucket":  ts.auditing.BucketName(),
+				"Key.$":   "States.Format('" + key + "', $$.Execution.Name, States.UUID())",
+				"Body.$":  ts.args,
+				"Tagging": "typestep=audit",
+			},
+			IamResources: jsii.Strings(*ts.auditing.ArnForObjects(jsii.String("typestep/*"))),
+			IamAction:    jsii.String("s3:PutObject"),
+			ResultPath:   awsstepfunctions.JsonPath_DISCARD(),
+		},
+	)
+	ts.append(archive)
+
+	// Note: the replay resumes from the archived value of top level steps
+	if len(ts.stack) == 1 {
+		ts.audits = append(ts.audits, audited{step: step, state: archive})
+	}
+}
+
+type audited struct {
+	step  string
+	state awsstepfunctions.IChainable
+}
+
+// replay is the entry of the state machine, which resumes the execution from
+// the archived value of the step (see package replay). The input is
+//
+//	{"replay": {"step": "...", "execution": "..."}, "Payload": ...}
+//
+// The correlation id of resumed execution is the name of original one.
+func (ts *typeStep) replay(chain awsstepfunctions.IChainable) awsstepfunctions.IChainable {
+	choice := awsstepfunctions.NewChoice(ts.scope, jsii.String("Replay"),
+		&awsstepfunctions.ChoiceProps{},
+	)
+
+	for _, x := range ts.audits {
+		assign := map[string]any{}
+		if ts.correlation {
+			assign["correlation"] = "{% $states.input.replay.execution %}"
+		}
+		if ts.tracing {
+			assign["traceparent"] = traceparent
+		}
+
+		props := &awsstepfunctions.PassJsonataProps{
+			Outputs: "{% $sift($states.input, function($v, $k) { $k != 'replay' }) %}",
+		}
+		if len(assign) != 0 {
+			props.Assign = &assign
+		}
+
+		resume := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("Replay"+x.step), props)
+		choice.When(
+			awsstepfunctions.Condition_And(
+				awsstepfunctions.Condition_IsPresent(jsii.String("$.replay.step")),
+				awsstepfunctions.Condition_StringEquals(jsii.String("$.replay.step"), jsii.String(x.step)),
+			),
+			resume.Next(x.state),
+			nil,
+		)
+	}
+
+	return choice.Otherwise(chain)
+}
@@ -0,0 +1,62 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestAudit(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	bucket := awss3.Bucket_FromBucketName(stack, jsii.String("Bucket"), jsii.String("my-bucket"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Audit: bucket,
+		},
+	)
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[string](event))))
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"AuditSource":{"Next":"MapA","Type":"Task","ResultPath":null`,
+		`"Key.$":"States.Format('typestep/Pipe/{}/Source/{}.json', $$.Execution.Name, States.UUID())"`,
+		`"Body.$":"$.detail"`,
+		`"AuditA":{"Next":"Sink"`,
+		`"Key.$":"States.Format('typestep/Pipe/{}/A/{}.json', $$.Execution.Name, States.UUID())"`,
+		`"Body.$":"$.Payload"`,
+		`"StartAt":"Replay"`,
+		`{"And":[{"Variable":"$.replay.step","IsPresent":true},{"Variable":"$.replay.step","StringEquals":"A"}],"Next":"ReplayA"}`,
+		`"ReplayA":{"Type":"Pass","QueryLanguage":"JSONata","Output":"{% $sift($states.input, function($v, $k) { $k != 'replay' }) %}","Next":"AuditA"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// ErrAwaitTimeout is the error of execution, which does not receive
+// the external event declared by [AwaitEvent] within the timeout.
+const ErrAwaitTimeout = "typestep.AwaitTimeout"
+
+// detail-type of events registering executions awaiting the external event
+const awaitDetailType = "typestep.await"
+
+// Correlation of the payload B with the external event C, the value of fields
+// selected from both types is the same.
+type Correlation[B, C any] struct {
+	b Selector[B]
+	c Selector[C]
+}
+
+// Correlate payload B with the external event C by fields
+//
+//	typestep.Correlate(
+//	  typestep.Field(func(o *Order) *string { return &o.ID }),
+//	  typestep.Field(func(p *Payment) *string { return &p.OrderID }),
+//	)
+func Correlate[B, C any](b Selector[B], c Selector[C]) Correlation[B, C] {
+	return Correlation[B, C]{b: b, c: c}
+}
+
+// AwaitEvent suspends the execution after the morphism 𝑚: A ⟼ B until
+// the external event C correlated with the payload arrives on the bus,
+// the event is the output of the step. The execution fails with
+// [ErrAwaitTimeout] unless the event arrives within the timeout.
+//
+// The task token of the execution is stored in DynamoDB table keyed by
+// the correlation value and the execution, the router state machine resumes
+// all executions awaiting the matching event. Events arriving before
+// the execution is suspended are not matched.
+//
+//	typestep.AwaitEvent(bus,
+//	  typestep.Correlate(
+//	    typestep.Field(func(o *Order) *string { return &o.ID }),
+//	    typestep.Field(func(p *Payment) *string { return &p.OrderID }),
+//	  ),
+//	  24*time.Hour,
+//	  m,
+//	)
+func AwaitEvent[A, B, C any](bus awsevents.IEventBus, correlation Correlation[B, C], timeout time.Duration, m duct.Morphism[A, B]) duct.Morphism[A, C] {
+	f := awaitEvent{
+		bus:     bus,
+		kind:    reflect.TypeOf(new(C)).Elem(),
+		b:       correlation.b.path,
+		c:       correlation.c.path,
+		timeout: timeout,
+	}
+	return duct.Join(duct.L2[B, C](f), m)
+}
+
+type awaitEvent struct {
+	bus     awsevents.IEventBus
+	kind    reflect.Type
+	b       fieldPath
+	c       fieldPath
+	timeout time.Duration
+}
+
+// await registers the task token and suspends the execution
+//
+//	PutEvents.waitForTaskToken ⟼ (timeout) Diagnostic ⟼ DLQ ⟼ Fail
+//	                           ⟼ ...
+func (ts *typeStep) await(f awaitEvent) {
+	id := ts.idOf("Await")
+	route := ts.name() + id
+	input := "$states.input" + strings.TrimPrefix(ts.args, "$")
+
+	register := awsstepfunctionstasks.EventBridgePutEvents_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.EventBridgePutEventsJsonataProps{
+			IntegrationPattern: awsstepfunctions.IntegrationPattern_WAIT_FOR_TASK_TOKEN,
+			Entries: &[]*awsstepfunctionstasks.EventBridgePutEventsEntry{
+				{
+					EventBus:   f.bus,
+					Source:     jsii.String("typestep"),
+					DetailType: jsii.String(awaitDetailType),
+					Detail: awsstepfunctions.TaskInput_FromObject(&map[string]any{
+						"await":     route,
+						"key":       "{% $string(" + selectorOf(input, f.b.names(ts.namer)) + ") %}",
+						"execution": "{% $states.context.Execution.Id %}",
+						"token":     "{% $states.context.Task.Token %}",
+					}),
+				},
+			},
+			TaskTimeout: awsstepfunctions.Timeout_Duration(awscdk.Duration_Seconds(jsii.Number(f.timeout.Seconds()))),
+		},
+	)
+
+	register.AddCatch(
+		ts.reject(id, "Timeout", ErrAwaitTimeout, fmt.Sprintf("%s is not received within %s", ts.detailTypeOf(f.kind), f.timeout)),
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("States.Timeout"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+	ts.append(register)
+
+	ts.awaitRouter(id, route, f)
+}
+
+// awaitRouter is the express state machine that resumes executions
+//
+//	Choice ⟼ (registration) PutItem
+//	       ⟼ Query ⟼ Map: SendTaskSuccess ⟼ DeleteItem
+func (ts *typeStep) awaitRouter(id, route string, f awaitEvent) {
+	table := awsdynamodb.NewTable(ts.scope, jsii.String(id+"Table"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{
+				Name: jsii.String("key"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			SortKey: &awsdynamodb.Attribute{
+				Name: jsii.String("execution"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+			TimeToLiveAttribute: jsii.String("ttl"),
+		},
+	)
+
+	put := awsstepfunctionstasks.DynamoPutItem_Jsonata(ts.scope, jsii.String(id+"Register"),
+		&awsstepfunctionstasks.DynamoPutItemJsonataProps{
+			Table: table,
+			Item: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key":       awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.detail.key %}")),
+				"execution": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.detail.execution %}")),
+				"token":     awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.detail.token %}")),
+				"ttl": awsstepfunctionstasks.DynamoAttributeValue_NumberFromString(
+					jsii.String(fmt.Sprintf("{%% $string($floor($millis() / 1000) + %d) %%}", int(f.timeout.Seconds()))),
+				),
+			},
+		},
+	)
+
+	// Note: waiters of the correlation value are expected to fit the single
+	// page of the query (1 MB)
+	get := awsstepfunctionstasks.CallAwsService_Jsonata(ts.scope, jsii.String(id+"Lookup"),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Service: jsii.String("dynamodb"),
+			Action:  jsii.String("query"),
+			Parameters: &map[string]any{
+				"TableName":                table.TableName(),
+				"KeyConditionExpression":   "#key = :key",
+				"ExpressionAttributeNames": map[string]any{"#key": "key"},
+				"ExpressionAttributeValues": map[string]any{
+					":key": map[string]any{"S": "{% $string(" + selectorOf("$states.input.detail", f.c.names(ts.namer)) + ") %}"},
+				},
+				"ConsistentRead": true,
+			},
+			IamResources: &[]*string{table.TableArn()},
+			IamAction:    jsii.String("dynamodb:Query"),
+			Outputs: map[string]any{
+				"items":  "{% $states.result.Items %}",
+				"detail": "{% $states.input.detail %}",
+			},
+		},
+	)
+
+	resume := awsstepfunctionstasks.CallAwsService_Jsonata(ts.scope, jsii.String(id+"Resume"),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Service: jsii.String("sfn"),
+			Action:  jsii.String("sendTaskSuccess"),
+			Parameters: &map[string]any{
+				"TaskToken": "{% $states.input.item.token.S %}",
+				"Output":    "{% $string({'Payload': $states.input.detail}) %}",
+			},
+			IamResources: jsii.Strings("*"),
+			IamAction:    jsii.String("states:SendTaskSuccess"),
+			Outputs:      "{% $states.input %}",
+		},
+	)
+
+	release := awsstepfunctionstasks.DynamoDeleteItem_Jsonata(ts.scope, jsii.String(id+"Release"),
+		&awsstepfunctionstasks.DynamoDeleteItemJsonataProps{
+			Table: table,
+			Key: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key":       awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.item.key.S %}")),
+				"execution": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.item.execution.S %}")),
+			},
+		},
+	)
+
+	// Note: the token of timed out or aborted execution is released as well
+	resume.AddCatch(release,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("States.ALL"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	match := awsstepfunctions.Map_Jsonata(ts.scope, jsii.String(id+"Match"),
+		&awsstepfunctions.MapJsonataProps{
+			Items: awsstepfunctions.ProvideItems_Jsonata(jsii.String("{% $states.input.items %}")),
+			ItemSelector: &map[string]any{
+				"item":   "{% $states.context.Map.Item.Value %}",
+				"detail": "{% $states.input.detail %}",
+			},
+		},
+	).ItemProcessor(resume.Next(release), nil)
+
+	dispatch := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(id+"Route"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $states.input.`detail-type` = '"+awaitDetailType+"' %}")),
+		put,
+		nil,
+	).Otherwise(
+		get.Next(match),
+	)
+
+	router := awsstepfunctions.NewStateMachine(ts.scope, jsii.String(id+"Router"),
+		&awsstepfunctions.StateMachineProps{
+			StateMachineType: awsstepfunctions.StateMachineType_EXPRESS,
+			DefinitionBody:   awsstepfunctions.ChainDefinitionBody_FromChainable(dispatch),
+		},
+	)
+
+	awsevents.NewRule(ts.scope, jsii.String(id+"Registration"),
+		&awsevents.RuleProps{
+			EventBus: f.bus,
+			EventPattern: &awsevents.EventPattern{
+				Source:     jsii.Strings("typestep"),
+				DetailType: jsii.Strings(awaitDetailType),
+				Detail:     &map[string]any{"await": []string{route}},
+			},
+			Targets: &[]awsevents.IRuleTarget{
+				awseventstargets.NewSfnStateMachine(router, &awseventstargets.SfnStateMachineProps{}),
+			},
+		},
+	)
+
+	awsevents.NewRule(ts.scope, jsii.String(id+"Event"),
+		&awsevents.RuleProps{
+			EventBus: f.bus,
+			EventPattern: &awsevents.EventPattern{
+				DetailType: jsii.Strings(ts.detailTypeOf(f.kind)),
+			},
+			Targets: &[]awsevents.IRuleTarget{
+				awseventstargets.NewSfnStateMachine(router, &awseventstargets.SfnStateMachineProps{}),
+			},
+		},
+	)
+}
@@ -0,0 +1,97 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type Payment struct {
+	UserID string `json:"user"`
+	Amount int    `json:"amount"`
+}
+
+func TestAwaitEvent(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.AwaitEvent(event,
+		typestep.Correlate(
+			typestep.Field(func(u *User) *string { return &u.ID }),
+			typestep.Field(func(p *Payment) *string { return &p.UserID }),
+		),
+		time.Hour,
+		p1,
+	)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+
+	// WHEN
+	typestep.StateMachine(ts, p3)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(2))
+	template.ResourceCountIs(jsii.String("AWS::DynamoDB::Table"), jsii.Number(1))
+	template.HasResourceProperties(jsii.String("AWS::DynamoDB::Table"),
+		map[string]any{
+			"KeySchema": []any{
+				map[string]any{"AttributeName": "key", "KeyType": "HASH"},
+				map[string]any{"AttributeName": "execution", "KeyType": "RANGE"},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"detail-type": []string{"typestep.await"},
+				"detail":      map[string]any{"await": []string{"PipeAwait0"}},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{"detail-type": []string{"Payment"}},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Resource":"arn::states:::events:putEvents.waitForTaskToken"`,
+		`"key":"{% $string($states.input.detail.` + "`id`" + `) %}"`,
+		`"token":"{% $states.context.Task.Token %}"`,
+		`"TimeoutSeconds":3600`,
+		`"Error":"typestep.AwaitTimeout"`,
+		`"S":"{% $string($states.input.detail.` + "`user`" + `) %}"`,
+		`"Output":"{% $string({'Payload': $states.input.detail}) %}"`,
+		`"Resource":"arn::states:::aws-sdk:sfn:sendTaskSuccess"`,
+		`"execution":"{% $states.context.Execution.Id %}"`,
+		`"Resource":"arn::states:::aws-sdk:dynamodb:query"`,
+		`"Items":"{% $states.input.items %}"`,
+		`"ErrorEquals":["States.ALL"],"Next":"Await0Release"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
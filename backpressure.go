@@ -0,0 +1,196 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudwatch"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Backpressure of the pipeline disables the rule consuming events of
+// the source when the dead-letter queue backs up or executions fail, so
+// that the broken downstream does not burn through the whole backlog of
+// events. The rule is enabled again when the alarm recovers. Events
+// published while the rule is disabled are not delivered to the pipeline,
+// use archive and replay of the event bus to recover them.
+//
+// The failure rate is not observed while the rule is disabled, the alarm
+// recovers after the evaluation period and the rule is enabled, which
+// probes the downstream with new executions. The depth of dead-letter queue
+// recovers when messages are purged. It is not compatible with [NewRedrive],
+// which drains the dead-letter queue.
+type Backpressure struct {
+	// DeadLetterDepth is the number of messages visible in the dead-letter
+	// queue, which disables the rule. It requires TypeStepProps.DeadLetterQueue.
+	DeadLetterDepth int
+
+	// FailureRate is the percentage (0 - 100) of failed executions within
+	// the evaluation period, which disables the rule.
+	FailureRate float64
+
+	// Period of evaluation, default is 5 minutes.
+	Period time.Duration
+}
+
+const defaultBackpressurePeriod = 5 * time.Minute
+
+// throttle the rule by the alarm on dead-letter queue and failed executions
+//
+//	Alarm ⟼ Rule ⟼ StateMachine: Choice ⟼ (ALARM) DisableRule
+//	                                     ⟼ EnableRule
+func (ts *typeStep) throttle(states awsstepfunctions.StateMachine, intake awsevents.Rule) error {
+	bp := ts.backpressure
+	if bp.DeadLetterDepth == 0 && bp.FailureRate == 0 {
+		return fmt.Errorf("backpressure requires dead-letter depth or failure rate")
+	}
+	if bp.DeadLetterDepth > 0 && ts.DeadLetterQueue == nil {
+		return fmt.Errorf("backpressure on dead-letter depth requires dead-letter queue")
+	}
+
+	period := bp.Period
+	if period == 0 {
+		period = defaultBackpressurePeriod
+	}
+	window := awscdk.Duration_Seconds(jsii.Number(period.Seconds()))
+
+	alarms := []awscloudwatch.IAlarm{}
+	if bp.DeadLetterDepth > 0 {
+		alarms = append(alarms,
+			awscloudwatch.NewAlarm(ts.scope, jsii.String("BackpressureDeadLetter"),
+				&awscloudwatch.AlarmProps{
+					Metric: ts.DeadLetterQueue.MetricApproximateNumberOfMessagesVisible(
+						&awscloudwatch.MetricOptions{
+							Period:    window,
+							Statistic: jsii.String("Maximum"),
+						},
+					),
+					Threshold:          jsii.Number(float64(bp.DeadLetterDepth)),
+					ComparisonOperator: awscloudwatch.ComparisonOperator_GREATER_THAN_OR_EQUAL_TO_THRESHOLD,
+					EvaluationPeriods:  jsii.Number(1),
+					TreatMissingData:   awscloudwatch.TreatMissingData_NOT_BREACHING,
+				},
+			),
+		)
+	}
+
+	if bp.FailureRate > 0 {
+		rate := awscloudwatch.NewMathExpression(
+			&awscloudwatch.MathExpressionProps{
+				Expression: jsii.String("IF(started > 0, 100 * failed / started, 0)"),
+				UsingMetrics: &map[string]awscloudwatch.IMetric{
+					"started": states.MetricStarted(&awscloudwatch.MetricOptions{Period: window, Statistic: jsii.String("Sum")}),
+					"failed":  states.MetricFailed(&awscloudwatch.MetricOptions{Period: window, Statistic: jsii.String("Sum")}),
+				},
+				Period: window,
+				Label:  jsii.String("failure rate"),
+			},
+		)
+
+		alarms = append(alarms,
+			awscloudwatch.NewAlarm(ts.scope, jsii.String("BackpressureFailureRate"),
+				&awscloudwatch.AlarmProps{
+					Metric:             rate,
+					Threshold:          jsii.Number(bp.FailureRate),
+					ComparisonOperator: awscloudwatch.ComparisonOperator_GREATER_THAN_OR_EQUAL_TO_THRESHOLD,
+					EvaluationPeriods:  jsii.Number(1),
+					TreatMissingData:   awscloudwatch.TreatMissingData_NOT_BREACHING,
+				},
+			),
+		)
+	}
+
+	alarm := awscloudwatch.NewCompositeAlarm(ts.scope, jsii.String("Backpressure"),
+		&awscloudwatch.CompositeAlarmProps{
+			AlarmRule: awscloudwatch.AlarmRule_AnyOf(alarmRulesOf(alarms)...),
+		},
+	)
+
+	ts.control("Backpressure", alarm,
+		ts.toggle("BackpressureDisable", intake, false),
+		ts.toggle("BackpressureEnable", intake, true),
+	)
+
+	return nil
+}
+
+// control state machine, it degrades the pipeline when the alarm is raised
+// and recovers it when the alarm is cleared
+//
+//	Alarm ⟼ Rule ⟼ StateMachine: Choice ⟼ (ALARM) degrade
+//	                                     ⟼ recover
+func (ts *typeStep) control(id string, alarm awscloudwatch.IAlarm, degrade, recover awsstepfunctions.IChainable) {
+	choice := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(id+"State"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $states.input.detail.state.value = 'ALARM' %}")),
+		degrade,
+		nil,
+	).Otherwise(recover)
+
+	machine := awsstepfunctions.NewStateMachine(ts.scope, jsii.String(id+"StateMachine"),
+		&awsstepfunctions.StateMachineProps{
+			StateMachineType: awsstepfunctions.StateMachineType_EXPRESS,
+			DefinitionBody:   awsstepfunctions.ChainDefinitionBody_FromChainable(choice),
+		},
+	)
+
+	// Note: the state change events of alarms are published to the default bus
+	awsevents.NewRule(ts.scope, jsii.String(id+"Rule"),
+		&awsevents.RuleProps{
+			EventPattern: &awsevents.EventPattern{
+				Source:     jsii.Strings("aws.cloudwatch"),
+				DetailType: jsii.Strings("CloudWatch Alarm State Change"),
+				Resources:  &[]*string{alarm.AlarmArn()},
+				Detail: &map[string]any{
+					"state": map[string]any{"value": []any{"ALARM", "OK"}},
+				},
+			},
+		},
+	).AddTarget(
+		awseventstargets.NewSfnStateMachine(machine, &awseventstargets.SfnStateMachineProps{}),
+	)
+}
+
+// toggle enables or disables the rule consuming events of the source
+func (ts *typeStep) toggle(id string, rule awsevents.IRule, enable bool) awsstepfunctionstasks.CallAwsService {
+	action, iam := "disableRule", "events:DisableRule"
+	if enable {
+		action, iam = "enableRule", "events:EnableRule"
+	}
+
+	return awsstepfunctionstasks.CallAwsService_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Service: jsii.String("eventbridge"),
+			Action:  jsii.String(action),
+			Parameters: &map[string]any{
+				"Name":         rule.RuleName(),
+				"EventBusName": ts.bus.EventBusName(),
+			},
+			IamResources: jsii.Strings(*rule.RuleArn()),
+			IamAction:    jsii.String(iam),
+			Outputs:      "{% $states.input %}",
+		},
+	)
+}
+
+func alarmRulesOf(alarms []awscloudwatch.IAlarm) []awscloudwatch.IAlarmRule {
+	seq := make([]awscloudwatch.IAlarmRule, len(alarms))
+	for i, alarm := range alarms {
+		seq[i] = awscloudwatch.AlarmRule_FromAlarm(alarm, awscloudwatch.AlarmState_ALARM)
+	}
+	return seq
+}
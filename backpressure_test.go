@@ -0,0 +1,120 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestBackpressure(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	dlq := awssqs.Queue_FromQueueArn(stack, jsii.String("DLQ"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-dlq"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: dlq,
+			Backpressure: &typestep.Backpressure{
+				DeadLetterDepth: 10,
+				FailureRate:     20,
+			},
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::CloudWatch::Alarm"), jsii.Number(2))
+	template.ResourceCountIs(jsii.String("AWS::CloudWatch::CompositeAlarm"), jsii.Number(1))
+	template.HasResourceProperties(jsii.String("AWS::CloudWatch::Alarm"),
+		map[string]any{
+			"MetricName": "ApproximateNumberOfMessagesVisible",
+			"Threshold":  10,
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::CloudWatch::Alarm"),
+		map[string]any{
+			"Threshold": 20,
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"source":      []string{"aws.cloudwatch"},
+				"detail-type": []string{"CloudWatch Alarm State Change"},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::IAM::Policy"),
+		map[string]any{
+			"PolicyDocument": map[string]any{
+				"Statement": assertions.Match_ArrayWith(&[]any{
+					map[string]any{
+						"Action":   "events:DisableRule",
+						"Effect":   "Allow",
+						"Resource": assertions.Match_AnyValue(),
+					},
+				}),
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"BackpressureState":{"Type":"Choice"`,
+		`:states:::aws-sdk:eventbridge:disableRule"`,
+		`:states:::aws-sdk:eventbridge:enableRule"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestBackpressureDeadLetter(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("backpressure without dead-letter queue is defined")
+		}
+	}()
+
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.ToQueue(queue, p1)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Backpressure: &typestep.Backpressure{DeadLetterDepth: 10},
+		},
+	)
+	typestep.StateMachine(ts, p2)
+}
@@ -0,0 +1,232 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// Package bench measures latency of typestep pipelines per step. It runs
+// executions of the deployed pipeline (AWS account or LocalStack) with
+// generated inputs, collects durations of states from the execution
+// history and reports percentiles keyed by names of typed steps, which
+// guides tuning of memory and concurrency of functions.
+//
+//	b := bench.New(cfg, "arn:aws:states:...")
+//	r, err := bench.Run(ctx, b, 100, func(i int) User { return User{ID: strconv.Itoa(i)} })
+//	fmt.Println(r)
+//
+// Only standard workflows are measurable, express workflows do not keep
+// the execution history.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sfn/types"
+)
+
+type api interface {
+	StartExecution(context.Context, *sfn.StartExecutionInput, ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error)
+	DescribeExecution(context.Context, *sfn.DescribeExecutionInput, ...func(*sfn.Options)) (*sfn.DescribeExecutionOutput, error)
+	GetExecutionHistory(context.Context, *sfn.GetExecutionHistoryInput, ...func(*sfn.Options)) (*sfn.GetExecutionHistoryOutput, error)
+}
+
+// Bench of the deployed pipeline
+type Bench struct {
+	machine string
+	poll    time.Duration
+	api     api
+}
+
+// New creates benchmark of the pipeline, the state machine is ARN of
+// the pipeline deployed to the test account.
+func New(cfg aws.Config, stateMachine string) *Bench {
+	return &Bench{
+		machine: stateMachine,
+		poll:    time.Second,
+		api:     sfn.NewFromConfig(cfg),
+	}
+}
+
+// Step is the latency of the typed step
+type Step struct {
+	Name  string
+	Count int
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// Report of the benchmark
+type Report struct {
+	Executions int
+	Failed     int
+	Steps      []Step
+}
+
+func (r Report) String() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("executions %d, failed %d\n", r.Executions, r.Failed))
+	sb.WriteString(fmt.Sprintf("%-32s %8s %10s %10s %10s %10s\n", "step", "count", "p50", "p90", "p99", "max"))
+	for _, s := range r.Steps {
+		sb.WriteString(fmt.Sprintf("%-32s %8d %10s %10s %10s %10s\n", s.Name, s.Count, s.P50, s.P90, s.P99, s.Max))
+	}
+	return sb.String()
+}
+
+// Run n executions of the pipeline, the input of i-th execution is
+// generated by the function. It waits for completion of all executions.
+func Run[A any](ctx context.Context, b *Bench, n int, gen func(int) A) (Report, error) {
+	seq := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		arn, err := b.start(ctx, gen(i))
+		if err != nil {
+			return Report{}, err
+		}
+		seq = append(seq, arn)
+	}
+
+	report := Report{Executions: n}
+	durations := map[string][]time.Duration{}
+	for _, arn := range seq {
+		status, err := b.await(ctx, arn)
+		if err != nil {
+			return Report{}, err
+		}
+		if status != types.ExecutionStatusSucceeded {
+			report.Failed++
+		}
+
+		events, err := b.history(ctx, arn)
+		if err != nil {
+			return Report{}, err
+		}
+
+		for name, ds := range stepsOf(events) {
+			durations[name] = append(durations[name], ds...)
+		}
+	}
+
+	for name, ds := range durations {
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		report.Steps = append(report.Steps,
+			Step{
+				Name:  name,
+				Count: len(ds),
+				P50:   percentile(ds, 0.50),
+				P90:   percentile(ds, 0.90),
+				P99:   percentile(ds, 0.99),
+				Max:   ds[len(ds)-1],
+			},
+		)
+	}
+	sort.Slice(report.Steps, func(i, j int) bool { return report.Steps[i].Name < report.Steps[j].Name })
+
+	return report, nil
+}
+
+func (b *Bench) start(ctx context.Context, a any) (string, error) {
+	input, err := json.Marshal(map[string]any{"detail": a})
+	if err != nil {
+		return "", err
+	}
+
+	out, err := b.api.StartExecution(ctx,
+		&sfn.StartExecutionInput{
+			StateMachineArn: aws.String(b.machine),
+			Input:           aws.String(string(input)),
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("typestep failed to start execution: %w", err)
+	}
+
+	return aws.ToString(out.ExecutionArn), nil
+}
+
+func (b *Bench) await(ctx context.Context, arn string) (types.ExecutionStatus, error) {
+	for {
+		out, err := b.api.DescribeExecution(ctx, &sfn.DescribeExecutionInput{ExecutionArn: aws.String(arn)})
+		if err != nil {
+			return "", fmt.Errorf("typestep failed to describe execution %s: %w", arn, err)
+		}
+
+		if out.Status != types.ExecutionStatusRunning && out.Status != types.ExecutionStatusPendingRedrive {
+			return out.Status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("typestep execution %s is not completed: %w", arn, ctx.Err())
+		case <-time.After(b.poll):
+		}
+	}
+}
+
+func (b *Bench) history(ctx context.Context, arn string) ([]types.HistoryEvent, error) {
+	seq := []types.HistoryEvent{}
+
+	var token *string
+	for {
+		out, err := b.api.GetExecutionHistory(ctx,
+			&sfn.GetExecutionHistoryInput{
+				ExecutionArn: aws.String(arn),
+				NextToken:    token,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("typestep failed to read history of %s: %w", arn, err)
+		}
+
+		seq = append(seq, out.Events...)
+
+		if out.NextToken == nil {
+			return seq, nil
+		}
+		token = out.NextToken
+	}
+}
+
+// stepsOf pairs entered and exited events of states, states within Map are
+// entered once per item.
+func stepsOf(events []types.HistoryEvent) map[string][]time.Duration {
+	entered := map[string][]time.Time{}
+	steps := map[string][]time.Duration{}
+
+	for _, evt := range events {
+		switch {
+		case evt.StateEnteredEventDetails != nil:
+			name := aws.ToString(evt.StateEnteredEventDetails.Name)
+			entered[name] = append(entered[name], aws.ToTime(evt.Timestamp))
+		case evt.StateExitedEventDetails != nil:
+			name := aws.ToString(evt.StateExitedEventDetails.Name)
+			if len(entered[name]) == 0 {
+				continue
+			}
+			t := entered[name][0]
+			entered[name] = entered[name][1:]
+			steps[name] = append(steps[name], aws.ToTime(evt.Timestamp).Sub(t))
+		}
+	}
+
+	return steps
+}
+
+// percentile of sorted durations (nearest rank)
+func percentile(seq []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(seq)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return seq[rank]
+}
@@ -0,0 +1,142 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sfn/types"
+)
+
+type User struct {
+	ID string `json:"id"`
+}
+
+type mockAPI struct {
+	inputs  []string
+	polls   int
+	running bool
+}
+
+func (m *mockAPI) StartExecution(ctx context.Context, in *sfn.StartExecutionInput, opts ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error) {
+	m.inputs = append(m.inputs, aws.ToString(in.Input))
+	return &sfn.StartExecutionOutput{ExecutionArn: aws.String(fmt.Sprintf("arn:%d", len(m.inputs)))}, nil
+}
+
+func (m *mockAPI) DescribeExecution(ctx context.Context, in *sfn.DescribeExecutionInput, opts ...func(*sfn.Options)) (*sfn.DescribeExecutionOutput, error) {
+	m.polls++
+	switch {
+	case m.polls == 1 || m.running:
+		return &sfn.DescribeExecutionOutput{Status: types.ExecutionStatusRunning}, nil
+	case aws.ToString(in.ExecutionArn) == "arn:4":
+		return &sfn.DescribeExecutionOutput{Status: types.ExecutionStatusFailed}, nil
+	default:
+		return &sfn.DescribeExecutionOutput{Status: types.ExecutionStatusSucceeded}, nil
+	}
+}
+
+// history of the execution: UserAccounts takes n×100ms, Account (within Map)
+// takes 10ms and 20ms
+func (m *mockAPI) GetExecutionHistory(ctx context.Context, in *sfn.GetExecutionHistoryInput, opts ...func(*sfn.Options)) (*sfn.GetExecutionHistoryOutput, error) {
+	var n int
+	fmt.Sscanf(aws.ToString(in.ExecutionArn), "arn:%d", &n)
+	t := time.Unix(0, 0)
+
+	if in.NextToken == nil {
+		return &sfn.GetExecutionHistoryOutput{
+			Events: []types.HistoryEvent{
+				entered("UserAccounts", t),
+				exited("UserAccounts", t.Add(time.Duration(n)*100*time.Millisecond)),
+			},
+			NextToken: aws.String("next"),
+		}, nil
+	}
+
+	return &sfn.GetExecutionHistoryOutput{
+		Events: []types.HistoryEvent{
+			entered("Account", t),
+			entered("Account", t),
+			exited("Account", t.Add(10*time.Millisecond)),
+			exited("Account", t.Add(20*time.Millisecond)),
+		},
+	}, nil
+}
+
+func entered(name string, t time.Time) types.HistoryEvent {
+	return types.HistoryEvent{
+		Type:                     types.HistoryEventTypeTaskStateEntered,
+		Timestamp:                aws.Time(t),
+		StateEnteredEventDetails: &types.StateEnteredEventDetails{Name: aws.String(name)},
+	}
+}
+
+func exited(name string, t time.Time) types.HistoryEvent {
+	return types.HistoryEvent{
+		Type:                    types.HistoryEventTypeTaskStateExited,
+		Timestamp:               aws.Time(t),
+		StateExitedEventDetails: &types.StateExitedEventDetails{Name: aws.String(name)},
+	}
+}
+
+func TestRun(t *testing.T) {
+	// GIVEN
+	api := &mockAPI{}
+	b := &Bench{machine: "arn:pipe", poll: time.Millisecond, api: api}
+
+	// WHEN
+	r, err := Run(context.Background(), b, 10, func(i int) User { return User{ID: fmt.Sprintf("u%d", i)} })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	if len(api.inputs) != 10 || api.inputs[0] != `{"detail":{"id":"u0"}}` {
+		t.Errorf("unexpected inputs %v", api.inputs)
+	}
+
+	if r.Executions != 10 || r.Failed != 1 {
+		t.Errorf("unexpected executions %d %d", r.Executions, r.Failed)
+	}
+
+	if len(r.Steps) != 2 {
+		t.Fatalf("unexpected steps %v", r.Steps)
+	}
+
+	account := r.Steps[0]
+	if account.Name != "Account" || account.Count != 20 || account.P50 != 10*time.Millisecond || account.Max != 20*time.Millisecond {
+		t.Errorf("unexpected step %+v", account)
+	}
+
+	ua := r.Steps[1]
+	if ua.Name != "UserAccounts" || ua.Count != 10 || ua.P50 != 500*time.Millisecond || ua.P90 != 900*time.Millisecond || ua.P99 != time.Second {
+		t.Errorf("unexpected step %+v", ua)
+	}
+
+	if !strings.Contains(r.String(), "UserAccounts") {
+		t.Errorf("unexpected report %s", r)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	api := &mockAPI{running: true}
+	b := &Bench{machine: "arn:pipe", poll: time.Millisecond, api: api}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := Run(ctx, b, 1, func(i int) User { return User{} }); err == nil {
+		t.Errorf("running execution is completed")
+	}
+}
@@ -0,0 +1,88 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsssm"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// BlueGreen deployment of pipelines. Each definition of the pipeline is
+// published as the immutable version of state machine, which is deployed
+// side-by-side with the live one. Events are routed to the alias `live`,
+// the alias is switched to the new version atomically once it is promoted.
+// In-flight executions complete on the version they were started with.
+//
+// The live version is recorded into SSM parameter `/typestep/<stack>/<pipeline>/live`,
+// the first deployment of the pipeline has to be promoted.
+type BlueGreen struct {
+	// Promote switches events to the new version of the pipeline, otherwise
+	// the new version is deployed side-by-side with the live one.
+	Promote bool
+
+	// Mirror duplicates events to the new version until it is promoted, it is
+	// not supported by windowed sources. Mirrored executions are not subject
+	// of idempotency (see TypeStepProps.IdempotencyKey).
+	Mirror bool
+}
+
+// blueGreen publishes the version of state machine, it returns the alias
+// receiving events and the version receiving mirrored events if any.
+func (ts *typeStep) blueGreen(states awsstepfunctions.StateMachine) (awsstepfunctions.IStateMachine, awsstepfunctions.IStateMachine) {
+	cfn := states.Node().DefaultChild().(awsstepfunctions.CfnStateMachine)
+
+	// Note: versions are retained, the alias might still refer them
+	version := awsstepfunctions.NewCfnStateMachineVersion(ts.scope, jsii.String("Version"+ts.version),
+		&awsstepfunctions.CfnStateMachineVersionProps{
+			StateMachineArn:        states.StateMachineArn(),
+			StateMachineRevisionId: cfn.AttrStateMachineRevisionId(),
+			Description:            jsii.String("typestep " + ts.version),
+		},
+	)
+	version.ApplyRemovalPolicy(awscdk.RemovalPolicy_RETAIN, nil)
+
+	stack := awscdk.Stack_Of(ts.scope)
+	name := "/typestep/" + *stack.StackName() + "/" + ts.name() + "/live"
+
+	// Note: the parameter is resolved before the update, it refers the version
+	// of previous deployment.
+	live := version.AttrArn()
+	if !ts.blueGreenMode.Promote {
+		live = awsssm.StringParameter_ValueForStringParameter(ts.scope, jsii.String(name), nil)
+	}
+
+	alias := awsstepfunctions.NewCfnStateMachineAlias(ts.scope, jsii.String("Live"),
+		&awsstepfunctions.CfnStateMachineAliasProps{
+			Name: jsii.String("live"),
+			RoutingConfiguration: []any{
+				&awsstepfunctions.CfnStateMachineAlias_RoutingConfigurationVersionProperty{
+					StateMachineVersionArn: live,
+					Weight:                 jsii.Number(100),
+				},
+			},
+		},
+	)
+
+	awsssm.NewStringParameter(ts.scope, jsii.String("LiveVersion"),
+		&awsssm.StringParameterProps{
+			ParameterName: jsii.String(name),
+			StringValue:   live,
+		},
+	)
+
+	target := awsstepfunctions.StateMachine_FromStateMachineArn(ts.scope, jsii.String("LiveAlias"), alias.AttrArn())
+	if ts.blueGreenMode.Promote || !ts.blueGreenMode.Mirror {
+		return target, nil
+	}
+
+	mirror := awsstepfunctions.StateMachine_FromStateMachineArn(ts.scope, jsii.String("NextVersion"), version.AttrArn())
+	return target, mirror
+}
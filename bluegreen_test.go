@@ -0,0 +1,86 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestBlueGreen(t *testing.T) {
+	for name, spec := range map[string]struct {
+		deployment *typestep.BlueGreen
+		targets    int
+		parameters int
+	}{
+		"promote": {&typestep.BlueGreen{Promote: true}, 1, 0},
+		"deploy":  {&typestep.BlueGreen{}, 1, 1},
+		"mirror":  {&typestep.BlueGreen{Mirror: true}, 2, 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			app := awscdk.NewApp(nil)
+			stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+			event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+			queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+			a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+				jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+			// THEN
+			ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+				&typestep.TypeStepProps{
+					Deployment: spec.deployment,
+				},
+			)
+			typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[string](event))))
+
+			// WHEN
+			template := assertions.Template_FromStack(stack, nil)
+			template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachineVersion"), jsii.Number(1))
+			template.HasResource(jsii.String("AWS::StepFunctions::StateMachineVersion"),
+				map[string]any{
+					"DeletionPolicy": "Retain",
+				},
+			)
+			template.HasResourceProperties(jsii.String("AWS::StepFunctions::StateMachineAlias"),
+				map[string]any{
+					"Name": "live",
+				},
+			)
+			template.HasResourceProperties(jsii.String("AWS::SSM::Parameter"),
+				map[string]any{
+					"Name": "/typestep/Test/Pipe/live",
+				},
+			)
+
+			rules := template.FindResources(jsii.String("AWS::Events::Rule"), nil)
+			for _, rule := range *rules {
+				targets := (*rule)["Properties"].(map[string]any)["Targets"].([]any)
+				if len(targets) != spec.targets {
+					t.Errorf("unexpected number of targets %d", len(targets))
+				}
+			}
+
+			params := template.FindParameters(jsii.String("*"),
+				map[string]any{"Type": "AWS::SSM::Parameter::Value<String>"},
+			)
+			delete(*params, "BootstrapVersion")
+			if n := len(*params); n != spec.parameters {
+				t.Errorf("unexpected number of parameters %d", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudwatch"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Budget is the cost-safety net of the pipeline against runaway fan-outs.
+// The pipeline is switched into degraded mode when the alarm is raised, and
+// recovered when the alarm is cleared. The degraded pipeline either pauses
+// the intake of events or samples them.
+//
+// The alarm is either the billing alarm (see [BillingAlarm]) or any alarm on
+// the spending of the pipeline (e.g. cost metric of AWS Budgets reported by
+// custom metric). Only alarms of the pipeline's region are observable.
+type Budget struct {
+	// Alarm on the spending, which degrades the pipeline.
+	Alarm awscloudwatch.IAlarm
+
+	// Sample is the fraction (0 - 1) of events consumed by the degraded
+	// pipeline, the intake is paused if it is not defined. Events are sampled
+	// by their id with the precision of 1/16.
+	Sample float64
+}
+
+// BillingAlarm on estimated charges (USD) of the account, the alarm is raised
+// when charges of the month exceed the limit. Billing metrics are only
+// published to us-east-1, the alarm is usable by pipelines of the region.
+func BillingAlarm(scope constructs.Construct, id *string, limit float64) awscloudwatch.Alarm {
+	return awscloudwatch.NewAlarm(scope, id,
+		&awscloudwatch.AlarmProps{
+			Metric: awscloudwatch.NewMetric(
+				&awscloudwatch.MetricProps{
+					Namespace:  jsii.String("AWS/Billing"),
+					MetricName: jsii.String("EstimatedCharges"),
+					DimensionsMap: &map[string]*string{
+						"Currency": jsii.String("USD"),
+					},
+					Statistic: jsii.String("Maximum"),
+					Period:    awscdk.Duration_Hours(jsii.Number(6)),
+				},
+			),
+			Threshold:          jsii.Number(limit),
+			ComparisonOperator: awscloudwatch.ComparisonOperator_GREATER_THAN_THRESHOLD,
+			EvaluationPeriods:  jsii.Number(1),
+			TreatMissingData:   awscloudwatch.TreatMissingData_NOT_BREACHING,
+		},
+	)
+}
+
+// event ids are UUIDs, prefixes of ids sample events uniformly
+const sampleOfIds = "0123456789abcdef"
+
+// degrade the intake of the pipeline when the budget alarm is raised. The
+// sampled intake is the disabled rule, enabled in degraded mode only.
+//
+//	Alarm ⟼ Rule ⟼ StateMachine: Choice ⟼ (ALARM) DisableRule ⟼ EnableRule(sampled)
+//	                                     ⟼ EnableRule ⟼ DisableRule(sampled)
+func (ts *typeStep) budget(intake awsevents.Rule, subscribe func(id string, pattern *awsevents.EventPattern, enabled bool) awsevents.Rule) error {
+	if ts.budgeted.Alarm == nil {
+		return fmt.Errorf("budget requires the alarm")
+	}
+	if ts.budgeted.Sample < 0 || ts.budgeted.Sample >= 1 {
+		return fmt.Errorf("budget sample %v is out of range [0, 1)", ts.budgeted.Sample)
+	}
+
+	disable := ts.toggle("BudgetDisable", intake, false)
+	enable := ts.toggle("BudgetEnable", intake, true)
+
+	var degrade, recover awsstepfunctions.IChainable = disable, enable
+
+	if ts.budgeted.Sample > 0 {
+		n := int(math.Max(1, math.Round(ts.budgeted.Sample*float64(len(sampleOfIds)))))
+		seq := make([]any, n)
+		for i := range n {
+			seq[i] = awsevents.Match_Prefix(jsii.String(sampleOfIds[i : i+1]))
+		}
+
+		pattern := *ts.eventPattern
+		pattern.Id = awsevents.Match_AnyOf(seq...)
+		sampled := subscribe("BudgetSampledRule", &pattern, false)
+
+		degrade = disable.Next(ts.toggle("BudgetSample", sampled, true))
+		recover = enable.Next(ts.toggle("BudgetUnsample", sampled, false))
+	}
+
+	ts.control("Budget", ts.budgeted.Alarm, degrade, recover)
+	return nil
+}
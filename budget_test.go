@@ -0,0 +1,90 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestBudget(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Budget: &typestep.Budget{
+				Alarm:  typestep.BillingAlarm(stack, jsii.String("Billing"), 100),
+				Sample: 0.25,
+			},
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::CloudWatch::Alarm"),
+		map[string]any{
+			"MetricName": "EstimatedCharges",
+			"Namespace":  "AWS/Billing",
+			"Threshold":  100,
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"State": "DISABLED",
+			"EventPattern": map[string]any{
+				"detail-type": []string{"string"},
+				"id": []any{
+					map[string]any{"prefix": "0"},
+					map[string]any{"prefix": "1"},
+					map[string]any{"prefix": "2"},
+					map[string]any{"prefix": "3"},
+				},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"source":      []string{"aws.cloudwatch"},
+				"detail-type": []string{"CloudWatch Alarm State Change"},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"BudgetState":{"Type":"Choice"`,
+		`"BudgetDisable":{"QueryLanguage":"JSONata","Next":"BudgetSample"`,
+		`"BudgetEnable":{"QueryLanguage":"JSONata","Next":"BudgetUnsample"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
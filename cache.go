@@ -0,0 +1,148 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Cached memoizes results of the function 𝑓: A ⟼ B in AWS DynamoDB table.
+// The state machine looks up the cache (native GetItem) before invoking the
+// lambda and writes the result after, the lambda is not invoked if the result
+// is known. The key is JSONPath within A (e.g. `$.id`), use [Path] to derive
+// it from the field.
+//
+// The table must have a string partition key `key`, the attribute `ttl` is
+// the expiration time of the cached result (enable TTL on the table).
+//
+//	typestep.Join(typestep.Cached(f, table, 1*time.Hour, "$.id"), m)
+func Cached[A, B any](f F[A, B], table awsdynamodb.ITable, ttl time.Duration, key string) F[A, B] {
+	return cached[A, B]{
+		f:     f,
+		cache: &cache{table: table, ttl: ttl, key: key},
+	}
+}
+
+type cached[A, B any] struct {
+	f     F[A, B]
+	cache *cache
+}
+
+func (c cached[A, B]) HKT1(func(A) B)         {}
+func (c cached[A, B]) F() awslambda.IFunction { return c.f.F() }
+
+func (c cached[A, B]) decorate(fn *lambda) {
+	fn.cache = c.cache
+	decorate(c.f, fn)
+}
+
+type cache struct {
+	table awsdynamodb.ITable
+	ttl   time.Duration
+	key   string
+}
+
+// cached step of the state machine is
+//
+//	Payload ⟼ GetItem ⟼ Hit? ⟼ Pass
+//	                     ⟼ LambdaInvoke ⟼ PutItem ⟼ Pass
+//
+// The payload is packed into object, so that cached result is stored aside.
+func (ts *typeStep) cached(f lambda) error {
+	uuid := ts.uuid
+	path, err := ts.path(f.cache.key)
+	if err != nil {
+		return err
+	}
+	key := strings.TrimPrefix(path, "$")
+	keyval := fmt.Sprintf("%s/{}", uuid)
+
+	pack := awsstepfunctions.NewPass(ts.scope, jsii.String("Cache"+uuid),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{"Payload.$": ts.args},
+		},
+	)
+
+	lookup := awsstepfunctionstasks.NewDynamoGetItem(ts.scope, jsii.String("CacheGet"+uuid),
+		&awsstepfunctionstasks.DynamoGetItemProps{
+			Table: f.cache.table,
+			Key: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(
+					awsstepfunctions.JsonPath_Format(jsii.String(keyval), awsstepfunctions.JsonPath_StringAt(jsii.String("$.Payload"+key))),
+				),
+			},
+			ResultPath: jsii.String("$.Cache"),
+		},
+	)
+
+	hit := awsstepfunctions.NewPass(ts.scope, jsii.String("CacheHit"+uuid),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{"Payload.$": "States.ArrayGetItem(States.StringToJson($.Cache.Item.value.S), 0)"},
+		},
+	)
+
+	compute := ts.invoke(f,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath:      jsii.String("$.Payload"),
+			ResultSelector: &map[string]any{"Payload.$": "$.Payload"},
+			ResultPath:     jsii.String("$.Reply"),
+		},
+	)
+
+	// Note: JSONata is used to compute expiration time. The value is wrapped
+	//       into array, so that any JSON value is stored as a valid JSON.
+	store := awsstepfunctionstasks.DynamoPutItem_Jsonata(ts.scope, jsii.String("CachePut"+uuid),
+		&awsstepfunctionstasks.DynamoPutItemJsonataProps{
+			Table: f.cache.table,
+			Item: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(
+					jsii.String(fmt.Sprintf("{%% '%s/' & $string($states.input.Payload%s) %%}", uuid, key)),
+				),
+				"value": awsstepfunctionstasks.DynamoAttributeValue_FromString(
+					jsii.String("{% $string([$states.input.Reply.Payload]) %}"),
+				),
+				"ttl": awsstepfunctionstasks.DynamoAttributeValue_NumberFromString(
+					jsii.String(fmt.Sprintf("{%% $string($floor($millis() / 1000) + %d) %%}", int(f.cache.ttl.Seconds()))),
+				),
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	miss := awsstepfunctions.NewPass(ts.scope, jsii.String("CacheMiss"+uuid),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{"Payload.$": "$.Reply.Payload"},
+		},
+	)
+
+	check := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String("CacheCheck"+uuid),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $exists($states.input.Cache.Item) and $number($states.input.Cache.Item.ttl.N) > $millis() / 1000 %}")),
+		hit,
+		nil,
+	).Otherwise(
+		compute.Next(store).Next(miss),
+	)
+
+	ts.appendChain(
+		awsstepfunctions.Chain_Start(pack).Next(lookup).Next(check.Afterwards(nil)),
+		"Cache"+uuid,
+		7,
+	)
+	return nil
+}
@@ -0,0 +1,61 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestCached(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("my-table"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(typestep.Cached(a, table, 1*time.Hour, "$.id"), p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"CacheA":{"Type":"Pass","Parameters":{"Payload.$":"$.detail"},"Next":"CacheGetA"}`,
+		`"key":{"S.$":"States.Format('A/{}', $.Payload.id)"}`,
+		`"CacheCheckA":{"Type":"Choice","QueryLanguage":"JSONata"`,
+		`"MapA":{"Next":"CachePutA"`,
+		`"ttl":{"N":"{% $string($floor($millis() / 1000) + 3600) %}"}`,
+		`"CacheMissA":{"Type":"Pass","Parameters":{"Payload.$":"$.Reply.Payload"},"Next":"Sink"}`,
+		`"CacheHitA":{"Type":"Pass","Parameters":{"Payload.$":"States.ArrayGetItem(States.StringToJson($.Cache.Item.value.S), 0)"},"Next":"Sink"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
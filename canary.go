@@ -0,0 +1,109 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudwatch"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// CanarySource is the source of synthetic events started by the canary,
+// typed functions might use it to skip side effects.
+const CanarySource = "typestep.canary"
+
+// default objective of the canary execution
+const defaultCanarySLO = 5 * time.Minute
+
+// CanaryProps configures the canary
+type CanaryProps struct {
+	// SLO is the maximum duration of the canary execution, default 5 minutes.
+	SLO time.Duration
+}
+
+// NewCanary periodically starts a real execution of the pipeline, defined
+// by the last call of [StateMachine], with the synthetic input. The input is
+// wrapped into the event of source [CanarySource], the execution is named
+// `canary-{id}`. The alarm is raised if the execution fails or does not
+// complete within the objective, it detects broken permissions or schema
+// drift before customers do.
+//
+//	typestep.StateMachine(ts, m)
+//	typestep.NewCanary(ts, User{ID: "canary"}, awsevents.Schedule_Rate(awscdk.Duration_Minutes(jsii.Number(15))))
+func NewCanary[A any](ts TypeStep, input A, schedule awsevents.Schedule, opts ...*CanaryProps) awscloudwatch.Alarm {
+	b := ts.(*typeStep)
+	if len(b.pipelines) == 0 {
+		panic(fmt.Errorf("canary requires the pipeline"))
+	}
+	states := b.pipelines[len(b.pipelines)-1]
+
+	slo := defaultCanarySLO
+	for _, opt := range opts {
+		if opt != nil && opt.SLO != 0 {
+			slo = opt.SLO
+		}
+	}
+
+	raw, err := json.Marshal(input)
+	if err != nil {
+		panic(err)
+	}
+	var detail any
+	if err := json.Unmarshal(raw, &detail); err != nil {
+		panic(err)
+	}
+
+	exec := awsstepfunctionstasks.NewStepFunctionsStartExecution(b.scope, jsii.String("CanaryRun"),
+		&awsstepfunctionstasks.StepFunctionsStartExecutionProps{
+			StateMachine:       states,
+			IntegrationPattern: awsstepfunctions.IntegrationPattern_RUN_JOB,
+			Name:               awsstepfunctions.JsonPath_Format(jsii.String("canary-{}"), awsstepfunctions.JsonPath_ExecutionName()),
+			Input: awsstepfunctions.TaskInput_FromObject(&map[string]any{
+				"id.$":        "$$.Execution.Name",
+				"source":      CanarySource,
+				"detail-type": b.detailTypeOf(reflect.TypeFor[A]()),
+				"detail":      detail,
+			}),
+			TaskTimeout: awsstepfunctions.Timeout_Duration(awscdk.Duration_Seconds(jsii.Number(slo.Seconds()))),
+		},
+	)
+
+	canary := awsstepfunctions.NewStateMachine(b.scope, jsii.String("Canary"),
+		&awsstepfunctions.StateMachineProps{
+			DefinitionBody: awsstepfunctions.ChainDefinitionBody_FromChainable(exec),
+		},
+	)
+
+	awsevents.NewRule(b.scope, jsii.String("CanarySchedule"),
+		&awsevents.RuleProps{
+			Schedule: schedule,
+			Targets: &[]awsevents.IRuleTarget{
+				awseventstargets.NewSfnStateMachine(canary, &awseventstargets.SfnStateMachineProps{}),
+			},
+		},
+	)
+
+	return awscloudwatch.NewAlarm(b.scope, jsii.String("CanaryFailed"),
+		&awscloudwatch.AlarmProps{
+			Metric:            canary.MetricFailed(nil),
+			Threshold:         jsii.Number(1),
+			EvaluationPeriods: jsii.Number(1),
+			TreatMissingData:  awscloudwatch.TreatMissingData_NOT_BREACHING,
+		},
+	)
+}
@@ -0,0 +1,74 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestCanary(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[User, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	typestep.NewCanary(ts, User{ID: "canary"},
+		awsevents.Schedule_Rate(awscdk.Duration_Minutes(jsii.Number(15))),
+		&typestep.CanaryProps{SLO: 2 * time.Minute},
+	)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(2))
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"ScheduleExpression": "rate(15 minutes)",
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::CloudWatch::Alarm"),
+		map[string]any{
+			"MetricName": "ExecutionsFailed",
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Resource":"arn:`,
+		`:states:startExecution.sync:2"`,
+		`"TimeoutSeconds":120`,
+		`"Name.$":"States.Format('canary-{}', $$.Execution.Name)"`,
+		`"source":"typestep.canary"`,
+		`"detail-type":"User"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// MetadataPipeline is the type of metadata entry of the cloud assembly, which
+// describes the pipeline (see [Catalog]). The entry is attached to the scope
+// of the pipeline, it is listed by `manifest.json` of `cdk.out`.
+const MetadataPipeline = "typestep:pipeline"
+
+// Catalog is the machine-readable architecture metadata of the pipeline,
+// org-wide tooling builds the catalog of pipelines across repositories
+// from the cloud assembly.
+type Catalog struct {
+	Version string        `json:"version"`
+	Owner   string        `json:"owner,omitempty"`
+	Sources []CatalogNode `json:"sources"`
+	Steps   []CatalogStep `json:"steps"`
+	Sinks   []CatalogNode `json:"sinks"`
+}
+
+// CatalogNode is the source or sink of the pipeline
+type CatalogNode struct {
+	Kind     string `json:"kind"`
+	Type     string `json:"type"`
+	Resource string `json:"resource,omitempty"`
+}
+
+// CatalogStep is the step of the pipeline
+type CatalogStep struct {
+	Name   string `json:"name"`
+	Input  string `json:"input"`
+	Output string `json:"output"`
+	Lambda string `json:"lambda,omitempty"`
+}
+
+// catalogOf the pipeline 𝑚: A ⟼ B
+func catalogOf(m interface{ Apply(duct.Visitor) error }) (*Catalog, error) {
+	c := &cataloger{catalog: &Catalog{
+		Sources: []CatalogNode{},
+		Steps:   []CatalogStep{},
+		Sinks:   []CatalogNode{},
+	}}
+	if err := m.Apply(c); err != nil {
+		return nil, err
+	}
+	return c.catalog, nil
+}
+
+type cataloger struct {
+	duct.AstVisitor
+	catalog *Catalog
+}
+
+func (c *cataloger) OnEnterFrom(depth int, node duct.AstFrom) error {
+	n := CatalogNode{Kind: nameOf(node.Source), Type: node.Type}
+	switch f := node.Source.(type) {
+	case source:
+		n.Kind, n.Resource = "eventbridge", *f.bus.Node().Path()
+	case queued:
+		n.Kind, n.Resource = "sqs", *f.q.Node().Path()
+	}
+	c.catalog.Sources = append(c.catalog.Sources, n)
+	return nil
+}
+
+func (c *cataloger) OnEnterMap(depth int, node duct.AstMap) error {
+	if _, ok := node.F.(describe); ok {
+		return nil
+	}
+
+	step := CatalogStep{Name: nameOf(node.F), Input: node.TypeA, Output: node.TypeB}
+	if f, ok := lambdaOf(node.F); ok {
+		step.Name, step.Lambda = *f.f.Node().Id(), *f.f.Node().Path()
+	}
+	c.catalog.Steps = append(c.catalog.Steps, step)
+	return nil
+}
+
+func (c *cataloger) OnEnterYield(depth int, node duct.AstYield) error {
+	n := CatalogNode{Kind: nameOf(node.Target), Type: node.Type}
+	switch f := node.Target.(type) {
+	case queue:
+		n.Kind, n.Resource = "sqs", *f.q.Node().Path()
+	case eventbus:
+		n.Kind, n.Resource = "eventbridge", *f.bus.Node().Path()
+	case function:
+		n.Kind, n.Resource = "lambda", *f.f.Node().Path()
+	case timestream:
+		n.Kind, n.Resource = "timestream", *f.table.Node().Path()
+	case redshift:
+		n.Kind, n.Resource = "redshift", f.table.Table
+	case outbox:
+		n.Kind, n.Resource = "outbox", *f.table.Node().Path()
+	}
+	c.catalog.Sinks = append(c.catalog.Sinks, n)
+	return nil
+}
+
+// annotate the scope of the pipeline with the catalog entry, the metadata is
+// the plain JSON object
+func (ts *typeStep) annotate(m interface{ Apply(duct.Visitor) error }) (*Catalog, error) {
+	catalog, err := catalogOf(m)
+	if err != nil {
+		return nil, err
+	}
+	catalog.Version = ts.version
+	catalog.Owner = ts.owner
+
+	raw, err := json.Marshal(catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+
+	ts.scope.Node().AddMetadata(jsii.String(MetadataPipeline), data, nil)
+	return catalog, nil
+}
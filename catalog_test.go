@@ -0,0 +1,78 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestCatalog(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[User, Contact](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{Owner: "billing"},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(a,
+				typestep.From[User](event),
+			),
+		),
+	)
+
+	// WHEN
+	var catalog *typestep.Catalog
+	for _, entry := range *ts.Node().Metadata() {
+		if *entry.Type != typestep.MetadataPipeline {
+			continue
+		}
+		raw, err := json.Marshal(entry.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(raw, &catalog); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if catalog == nil {
+		t.Fatalf("pipeline metadata is not defined")
+	}
+
+	if catalog.Owner != "billing" || catalog.Version == "" {
+		t.Errorf("unexpected pipeline metadata %+v", catalog)
+	}
+
+	if len(catalog.Sources) != 1 || catalog.Sources[0].Kind != "eventbridge" || catalog.Sources[0].Resource != "Test/Events" {
+		t.Errorf("unexpected sources %+v", catalog.Sources)
+	}
+
+	if len(catalog.Steps) != 1 || catalog.Steps[0].Name != "A" || catalog.Steps[0].Lambda != "Test/A" {
+		t.Errorf("unexpected steps %+v", catalog.Steps)
+	}
+
+	if len(catalog.Sinks) != 1 || catalog.Sinks[0].Kind != "sqs" || catalog.Sinks[0].Resource != "Test/Queue" {
+		t.Errorf("unexpected sinks %+v", catalog.Sinks)
+	}
+}
@@ -0,0 +1,60 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// WithChaos injects the failure into the step 𝑓: A ⟼ B for the percentage of
+// executions, so that retry, dead-letter and alarm behavior of the composed
+// pipeline is verified under controlled failure. The runtime wrapper injects
+// the latency and/or fails the function with `ChaosError` (see [runtime.Chaos]).
+// Enable TypeStepProps.Metadata to sample executions consistently across steps.
+//
+//	chaos := runtime.Chaos{Percent: 10, Latency: 5 * time.Second, Error: "injected"}
+//	if os.Getenv("CHAOS") != "" {
+//		f = typestep.WithChaos(f, chaos)
+//	}
+//
+// Only functions deployed by the stack are affected. Never deploy it to
+// production environments.
+func WithChaos[A, B any](f F[A, B], chaos runtime.Chaos) F[A, B] {
+	return withChaos[A, B]{f: f, chaos: chaos}
+}
+
+type withChaos[A, B any] struct {
+	f     F[A, B]
+	chaos runtime.Chaos
+}
+
+func (c withChaos[A, B]) HKT1(func(A) B)         {}
+func (c withChaos[A, B]) F() awslambda.IFunction { return c.f.F() }
+
+func (c withChaos[A, B]) decorate(fn *lambda) {
+	fn.chaos = &c.chaos
+	decorate(c.f, fn)
+}
+
+// chaos configures the runtime wrapper to inject failures
+func (ts *typeStep) chaos(f lambda) {
+	if f.chaos == nil {
+		return
+	}
+
+	spec, err := json.Marshal(f.chaos)
+	if err != nil {
+		panic(err)
+	}
+
+	ts.setenv(f.f, runtime.EnvChaos, string(spec))
+}
@@ -0,0 +1,62 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/scud"
+	"github.com/fogfish/typestep"
+	"github.com/fogfish/typestep/internal/test"
+	"github.com/fogfish/typestep/runtime"
+)
+
+func TestWithChaos(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// THEN
+	chaos := runtime.Chaos{Percent: 10, Latency: time.Second, Error: "injected"}
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(typestep.WithChaos(f, chaos), p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"Environment": map[string]any{
+				"Variables": map[string]any{
+					runtime.EnvChaos: `{"percent":10,"latency":1000000000,"error":"injected"}`,
+				},
+			},
+		},
+	)
+}
@@ -0,0 +1,172 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// The command line utility of typestep pipelines.
+//
+//	typestep diff -arn arn:aws:states:... -template cdk.out/Stack.template.json [-id PipeStateMachine]
+//	typestep terraform -template cdk.out/Stack.template.json > pipeline.tf.json
+//	typestep bench -arn arn:aws:states:... -fixture testdata/Pipe/v1.json [-n 100] [-localstack]
+//
+// The diff command compares the deployed state machine with the definition
+// synthesized locally, it exits with status 1 if definitions differ.
+// The terraform command renders the synthesized template as Terraform JSON
+// configuration. The bench command runs executions of the deployed pipeline
+// with events of the fixture, it reports latency percentiles per step.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/fogfish/typestep/bench"
+	"github.com/fogfish/typestep/diff"
+	"github.com/fogfish/typestep/fixture"
+	"github.com/fogfish/typestep/localstack"
+	"github.com/fogfish/typestep/terraform"
+)
+
+const usage = `usage:
+  typestep diff -arn ARN -template FILE [-id LOGICAL_ID]
+  typestep terraform -template FILE
+  typestep bench -arn ARN -fixture FILE [-n N] [-localstack]`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "diff":
+		changes, err := run(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		if len(changes) != 0 {
+			os.Exit(1)
+		}
+	case "terraform":
+		tf, err := export(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		os.Stdout.Write(tf)
+	case "bench":
+		report, err := benchmark(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		fmt.Print(report)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func run(args []string) ([]diff.Change, error) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	arn := fs.String("arn", "", "ARN of the deployed state machine")
+	template := fs.String("template", "", "CloudFormation template synthesized by cdk")
+	id := fs.String("id", "", "prefix of logical id of the state machine within the template")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if *arn == "" || *template == "" {
+		return nil, fmt.Errorf("diff requires -arn and -template")
+	}
+
+	raw, err := os.ReadFile(*template)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := diff.Template(raw, *id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deployed, err := diff.Deployed(ctx, cfg, *arn)
+	if err != nil {
+		return nil, err
+	}
+
+	return diff.Compare(deployed, local)
+}
+
+func export(args []string) ([]byte, error) {
+	fs := flag.NewFlagSet("terraform", flag.ExitOnError)
+	template := fs.String("template", "", "CloudFormation template synthesized by cdk")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if *template == "" {
+		return nil, fmt.Errorf("terraform requires -template")
+	}
+
+	raw, err := os.ReadFile(*template)
+	if err != nil {
+		return nil, err
+	}
+
+	return terraform.Export(raw)
+}
+
+func benchmark(args []string) (bench.Report, error) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	arn := fs.String("arn", "", "ARN of the deployed state machine")
+	file := fs.String("fixture", "", "fixture file with input events")
+	n := fs.Int("n", 100, "number of executions")
+	local := fs.Bool("localstack", false, "run against LocalStack")
+	if err := fs.Parse(args); err != nil {
+		return bench.Report{}, err
+	}
+	if *arn == "" || *file == "" {
+		return bench.Report{}, fmt.Errorf("bench requires -arn and -fixture")
+	}
+
+	f, err := fixture.ReadFile(*file)
+	if err != nil {
+		return bench.Report{}, err
+	}
+	if len(f.Events) == 0 {
+		return bench.Report{}, fmt.Errorf("fixture %s has no events", *file)
+	}
+
+	ctx := context.Background()
+	var cfg aws.Config
+	if *local {
+		cfg, err = localstack.Config(ctx)
+	} else {
+		cfg, err = config.LoadDefaultConfig(ctx)
+	}
+	if err != nil {
+		return bench.Report{}, err
+	}
+
+	return bench.Run(ctx, bench.New(cfg, *arn), *n,
+		func(i int) json.RawMessage { return f.Events[i%len(f.Events)] },
+	)
+}
@@ -0,0 +1,104 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fogfish/typestep/runtime"
+)
+
+// TagAlias is the prefix of struct tag `typestep:"alias=name"`, which
+// annotates the renamed field with its previous name. The pipeline maps
+// the previous name into the field before the function consuming the type,
+// so that older producers keep working during the transition window.
+//
+//	type Order struct {
+//	  CustomerID string `json:"customerId" typestep:"alias=userId"`
+//	}
+const TagAlias = "alias="
+
+// renamed field of the type
+type alias struct {
+	field
+	was string
+}
+
+// aliasesOf returns fields annotated with the previous name, recursive types
+// are walked once along the path.
+func aliasesOf(n runtime.FieldNamer, path string, t reflect.Type, visited map[reflect.Type]bool) []alias {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	seq := []alias{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := runtime.FieldName(n, f)
+		if name == "-" {
+			continue
+		}
+
+		at := path
+		if !(f.Anonymous && name == "") {
+			if name == "" {
+				name = f.Name
+			}
+			at = path + "." + name
+		}
+
+		if was, ok := aliasOf(f); ok {
+			seq = append(seq, alias{field: field{name: name, path: at, kind: f.Type.Kind()}, was: was})
+			continue
+		}
+		seq = append(seq, aliasesOf(n, at, f.Type, visited)...)
+	}
+
+	return seq
+}
+
+func aliasOf(f reflect.StructField) (string, bool) {
+	return runtime.TagValue(f, TagAlias)
+}
+
+// shim maps previous names of fields into the input of function, the state
+// is generated only if the type declares renamed fields.
+func (ts *typeStep) shim(t reflect.Type) {
+	aliases := aliasesOf(ts.namer, "", t, map[reflect.Type]bool{})
+	if len(aliases) == 0 {
+		return
+	}
+
+	expr := "$states.input" + strings.TrimPrefix(ts.args, "$")
+	for _, x := range aliases {
+		now, was := "`"+x.name+"`", "`"+x.was+"`"
+		location := "$"
+		if seq := strings.Split(strings.TrimPrefix(x.path, "."), "."); len(seq) > 1 {
+			location = strings.TrimPrefix(selectorOf("", seq[:len(seq)-1]), ".")
+		}
+		expr += fmt.Sprintf(" ~> |%s|{'%s': $exists(%s) ? %s : %s}, ['%s']|", location, x.name, now, now, was, x.was)
+	}
+
+	ts.shape("Shim", "{% "+expr+" %}")
+	ts.args = "$.Payload"
+}
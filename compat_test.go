@@ -0,0 +1,130 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type Member struct {
+	ID      string `json:"id" typestep:"alias=customerId"`
+	Address struct {
+		Street string `json:"street" typestep:"required,alias=line1"`
+	} `json:"address"`
+}
+
+func TestShim(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[Member, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[Member](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"Shim0":{"Type":"Pass","QueryLanguage":"JSONata","Output":{"Payload":"{% $states.input.detail ~> |$|{'id': $exists(` + "`id`) ? `id` : `customerId`" + `}, ['customerId']| ~> |` + "`address`|{'street': $exists(`street`) ? `street` : `line1`}" + `, ['line1']| %}"},"Next":"MapA"}`,
+		`"InputPath":"$.Payload"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
+
+type Branch struct {
+	ID     string  `json:"id" typestep:"alias=key"`
+	Parent *Branch `json:"parent,omitempty"`
+}
+
+func TestShimRecursive(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[Branch, Branch](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[Branch](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"Payload":"{% $states.input.detail ~> |$|{'id': $exists(` + "`id`) ? `id` : `key`" + `}, ['key']| %}"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
+
+func TestShimSchemaCompatibility(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(&awscdk.AppProps{
+		Context: &map[string]any{
+			"ssm:account=000000000000:parameterName=/typestep/Test/Pipe:region=eu-west-1": `{"typestep_test.Member":{"type":"object","properties":{"customerId":{"type":"string"},"address":{"type":"object","properties":{"line1":{"type":"string"}},"required":["line1"]}},"required":["address","customerId"]}}`,
+		},
+	})
+	stack := awscdk.NewStack(app, jsii.String("Test"),
+		&awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("000000000000"),
+				Region:  jsii.String("eu-west-1"),
+			},
+		},
+	)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[Member, Member](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			SchemaCompatibility: true,
+		},
+	)
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[Member](event))))
+
+	// WHEN
+	annotations := assertions.Annotations_FromStack(stack)
+	annotations.HasNoError(jsii.String("*"), assertions.Match_AnyValue())
+}
@@ -0,0 +1,105 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// PipelineProps of the reusable pipeline 𝑚: A ⟼ B, the bus is the input
+// and the queue is the output of the pipeline.
+type PipelineProps[A, B any] struct {
+	TypeStepProps
+
+	// EventBus is the source of events `A` consumed by the pipeline.
+	EventBus awsevents.IEventBus
+
+	// Category filters events of the bus (see [From]).
+	Category []string
+
+	// Queue receives results `B` of the pipeline. The queue is defined by
+	// the construct unless it is given.
+	Queue awssqs.IQueue
+
+	// Pipeline composes the computation from the source, functions are
+	// defined within the scope of the construct.
+	Pipeline func(scope constructs.Construct, m duct.Morphism[A, A]) duct.Morphism[A, B]
+}
+
+// Pipeline is AWS CDK L3 that bundles the pipeline 𝑚: A ⟼ B with its
+// lambdas, rule, dead-letter queue and alarms into the single construct.
+// Platform teams ship the construct as versioned building block, other
+// stacks consume it through typed props and outputs.
+type Pipeline[A, B any] struct {
+	constructs.Construct
+	TypeStep        TypeStep
+	EventBus        awsevents.IEventBus
+	Queue           awssqs.IQueue
+	DeadLetterQueue awssqs.IQueue
+}
+
+// NewPipelineConstruct defines the pipeline consuming events of the bus and
+// yielding results into the queue. The dead-letter queue and the output
+// queue are defined by the construct unless they are given, alarms on failed
+// executions are enabled unless the profile is given.
+//
+//	typestep.NewPipelineConstruct(stack, jsii.String("Billing"),
+//	  &typestep.PipelineProps[Order, Invoice]{
+//	    EventBus: bus,
+//	    Pipeline: func(scope constructs.Construct, m duct.Morphism[Order, Order]) duct.Morphism[Order, Invoice] {
+//	      return typestep.Join(NewBilling(scope), m)
+//	    },
+//	  },
+//	)
+func NewPipelineConstruct[A, B any](scope constructs.Construct, id *string, props *PipelineProps[A, B]) *Pipeline[A, B] {
+	if props.EventBus == nil || props.Pipeline == nil {
+		panic(fmt.Errorf("pipeline construct %s requires event bus and pipeline", *id))
+	}
+
+	c := &Pipeline[A, B]{
+		Construct:       constructs.NewConstruct(scope, id),
+		EventBus:        props.EventBus,
+		Queue:           props.Queue,
+		DeadLetterQueue: props.DeadLetterQueue,
+	}
+
+	if c.DeadLetterQueue == nil {
+		c.DeadLetterQueue = awssqs.NewQueue(c.Construct, jsii.String("DeadLetterQueue"),
+			&awssqs.QueueProps{
+				RetentionPeriod: awscdk.Duration_Days(jsii.Number(14)),
+			},
+		)
+	}
+
+	if c.Queue == nil {
+		c.Queue = awssqs.NewQueue(c.Construct, jsii.String("Queue"), &awssqs.QueueProps{})
+	}
+
+	spec := props.TypeStepProps
+	spec.DeadLetterQueue = c.DeadLetterQueue
+	if spec.Profile == nil {
+		spec.Profile = &Profile{Alarms: true}
+	}
+
+	c.TypeStep = NewTypeStep(c.Construct, jsii.String("TypeStep"), &spec)
+	StateMachine(c.TypeStep,
+		ToQueue(c.Queue,
+			props.Pipeline(c.Construct, From[A](c.EventBus, props.Category...)),
+		),
+	)
+
+	return c
+}
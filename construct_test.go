@@ -0,0 +1,51 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep"
+)
+
+func TestPipelineConstruct(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+
+	// THEN
+	c := typestep.NewPipelineConstruct(stack, jsii.String("Billing"),
+		&typestep.PipelineProps[User, Contact]{
+			EventBus: event,
+			Pipeline: func(scope constructs.Construct, m duct.Morphism[User, User]) duct.Morphism[User, Contact] {
+				f := typestep.Function_FromFunctionArn[User, Contact](scope, jsii.String("F"),
+					jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+				return typestep.Join(f, m)
+			},
+		},
+	)
+
+	if c.Queue == nil || c.DeadLetterQueue == nil {
+		t.Errorf("pipeline construct does not define queues")
+	}
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::SQS::Queue"), jsii.Number(2))
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(1))
+	template.ResourceCountIs(jsii.String("AWS::Events::Rule"), jsii.Number(1))
+	template.ResourceCountIs(jsii.String("AWS::CloudWatch::Alarm"), jsii.Number(1))
+}
@@ -0,0 +1,127 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Continue is the result of the chunk of unbounded workload, the remaining
+// work is the input `A` of the pipeline, nil if the work is completed.
+type Continue[A, B any] struct {
+	Value B  `json:"value"`
+	Next  *A `json:"next,omitempty"`
+}
+
+// ContinueAsNew composes lambda function transformer 𝑓: B ⟼ Continue[A, C]
+// with morphism 𝑚: A ⟼ B, producing a new morphism 𝑚: A ⟼ C. When the
+// function indicates more work remains, the fresh execution of the pipeline
+// is started with the remaining work, the current execution continues with
+// the value and succeeds. It keeps executions of crawling and backfill
+// pipelines under limits of the history and the payload size.
+//
+//	typestep.ContinueAsNew(Crawl, m) // Crawl: Site ⟼ Continue[Site, []Page]
+//
+// The fresh execution receives the input of the current one with the new
+// detail, it is named by random UUID. It is supported by pipelines started
+// by EventBridge rule only, neither by queues, windows and API nor by nested
+// computations.
+func ContinueAsNew[A, B, C any](f F[B, Continue[A, C]], m duct.Morphism[A, B]) duct.Morphism[A, C] {
+	fn := newLambda(1, f)
+	return duct.Join(duct.L2[B, C](continueAsNew{lambda: fn}), m)
+}
+
+type continueAsNew struct{ lambda }
+
+// continueAsNew starts the fresh execution if the function has remaining work
+//
+//	f ⟼ Choice ⟼ (next) StartExecution ⟼ Pass
+//	           ⟼ Pass
+func (ts *typeStep) continueAsNew(f continueAsNew) error {
+	if len(ts.stack) != 1 {
+		return fmt.Errorf("continue-as-new of %s is not supported by nested computations", *f.f.Node().Id())
+	}
+
+	// Note: the fresh execution is the event of the rule with the new detail
+	if ts.queued != nil || ts.windowed != nil || ts.api != nil {
+		return fmt.Errorf("continue-as-new of %s is supported by pipelines started by rules only", *f.f.Node().Id())
+	}
+	ts.restarts = true
+
+	uuid := ts.uuid
+	compute := ts.invoke(f.lambda,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath: jsii.String(ts.args),
+		},
+	)
+
+	// Note: the task is the custom state, the policy of SDK integration would
+	//       refer the arn of own state machine, which is circular dependency.
+	//       The permission is granted once the state machine is defined.
+	restart := awsstepfunctions.NewCustomState(ts.scope, jsii.String("Continue"+uuid),
+		&awsstepfunctions.CustomStateProps{
+			StateJson: &map[string]any{
+				"Type":          "Task",
+				"QueryLanguage": "JSONata",
+				"Resource":      "arn:" + *awscdk.Aws_PARTITION() + ":states:::aws-sdk:sfn:startExecution",
+				"Arguments": map[string]any{
+					"StateMachineArn": "{% $states.context.StateMachine.Id %}",
+					"Input":           "{% $string($merge([$states.context.Execution.Input, {'detail': $states.input.Payload.next}])) %}",
+				},
+				"Output": "{% $states.input %}",
+			},
+		},
+	)
+
+	value := awsstepfunctions.NewPass(ts.scope, jsii.String("ContinueValue"+uuid),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{"Payload.$": "$.Payload.value"},
+		},
+	)
+	restart.Next(value)
+
+	next := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String("ContinueNext"+uuid),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $exists($states.input.Payload.next) %}")),
+		restart,
+		nil,
+	).Otherwise(value)
+	compute.Next(next)
+
+	ts.appendChain(
+		awsstepfunctions.Chain_Custom(compute, &[]awsstepfunctions.INextable{value}, value),
+		*compute.Node().Id(),
+		4,
+	)
+	return nil
+}
+
+// restart grants the state machine to start own executions, the separate
+// policy is not the dependency of the state machine.
+func (ts *typeStep) restart(states awsstepfunctions.StateMachine) {
+	awsiam.NewPolicy(ts.scope, jsii.String("ContinueAsNew"),
+		&awsiam.PolicyProps{
+			Roles: &[]awsiam.IRole{states.Role()},
+			Statements: &[]awsiam.PolicyStatement{
+				awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+					Actions:   jsii.Strings("states:StartExecution"),
+					Resources: jsii.Strings(*states.StateMachineArn()),
+				}),
+			},
+		},
+	)
+}
@@ -0,0 +1,93 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestContinueAsNew(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[typestep.Cursor, typestep.Continue[typestep.Cursor, []Order]](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[typestep.Cursor](event)
+	p2 := typestep.ContinueAsNew(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::IAM::Policy"),
+		map[string]any{
+			"PolicyDocument": map[string]any{
+				"Statement": assertions.Match_ArrayWith(&[]any{
+					map[string]any{
+						"Action":   "states:StartExecution",
+						"Effect":   "Allow",
+						"Resource": map[string]any{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PipeStateMachine"))},
+					},
+				}),
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"MapA":{"Next":"ContinueNextA"`,
+		`"Choices":[{"Condition":"{% $exists($states.input.Payload.next) %}","Next":"ContinueA"}],"Default":"ContinueValueA"`,
+		`"Input":"{% $string($merge([$states.context.Execution.Input, {'detail': $states.input.Payload.next}])) %}"`,
+		`"ContinueValueA":{"Type":"Pass","Parameters":{"Payload.$":"$.Payload.value"},"Next":"Sink"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
+
+func TestContinueAsNewQueued(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	source := awssqs.Queue_FromQueueArn(stack, jsii.String("Source"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-source"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[typestep.Cursor, typestep.Continue[typestep.Cursor, []Order]](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("continue-as-new shall not be supported by queued pipelines")
+		}
+	}()
+
+	p1 := typestep.FromQueue[typestep.Cursor](source)
+	p2 := typestep.ContinueAsNew(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// retention of dedup keys covers the redrive window of executions (14 days)
+const dedupRetention = 15 * 24 * 60 * 60
+
+// Yield results of 𝑚: A ⟼ B binding it with AWS EventBridge, the event is
+// emitted once per input of the execution and the dedup key. The key is
+// derived from the selected field of the result and the hash of execution
+// input, it is recorded into DynamoDB table before the event is emitted.
+// Retries of the execution and its redrives, which re-start the execution
+// with the original input (see [NewRedrive]), do not publish the event twice.
+//
+// The table must have a string partition key `key`, the attribute `ttl` is
+// the expiration time of the key (enable TTL on the table).
+//
+//	typestep.ToEventBusOnce("order", bus, table, typestep.Field(func(o *Order) *string { return &o.ID }), m)
+//
+// The key is recorded before the event, the event is lost if the execution is
+// not able to emit it (exactly-once-ish).
+func ToEventBusOnce[A, B any](source string, bus awsevents.IEventBus, table awsdynamodb.ITable, key Selector[B], m duct.Morphism[A, B], cat ...string) duct.Morphism[A, duct.Void] {
+	sink := eventbus{
+		bus:    bus,
+		source: source,
+		cat:    cat,
+		kind:   reflect.TypeOf(new(B)).Elem(),
+		dedup:  &dedup{table: table, key: key.path},
+	}
+	return duct.Yield(duct.L1[B](sink), m)
+}
+
+type dedup struct {
+	table awsdynamodb.ITable
+	key   fieldPath
+}
+
+// publishOnce records the dedup key before emitting the event, duplicates
+// are skipped
+//
+//	PutItem ⟼ PutEvents ⟼ Pass
+//	        ⟼ (duplicate) Pass
+func (ts *typeStep) publishOnce(f eventbus, detailType string) error {
+	if f.kind.Kind() == reflect.Slice {
+		return fmt.Errorf("dedup of events does not support sequence %s, use Lift", f.kind)
+	}
+	if len(f.dedup.key) == 0 {
+		return fmt.Errorf("undefined dedup key of event %s", f.kind)
+	}
+
+	input := "$states.input" + strings.TrimPrefix(ts.args, "$")
+	id := ts.unique("Sink")
+
+	record := awsstepfunctionstasks.DynamoPutItem_Jsonata(ts.scope, jsii.String(id+"Dedup"),
+		&awsstepfunctionstasks.DynamoPutItemJsonataProps{
+			Table: f.dedup.table,
+			Item: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(
+					jsii.String("{% $hash($string($states.context.Execution.Input), 'SHA-256') & '/' & $string(" + selectorOf(input, f.dedup.key.names(ts.namer)) + ") %}"),
+				),
+				"ttl": awsstepfunctionstasks.DynamoAttributeValue_NumberFromString(
+					jsii.String(fmt.Sprintf("{%% $string($floor($millis() / 1000) + %d) %%}", dedupRetention)),
+				),
+			},
+			ConditionExpression: jsii.String("attribute_not_exists(#key)"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#key": jsii.String("key"),
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	sink := ts.putEvents(id, f.bus, "["+ts.entryOf(f, input, f.kind, detailType)+"]")
+
+	done := awsstepfunctions.NewPass(ts.scope, jsii.String(id+"Done"), &awsstepfunctions.PassProps{})
+	sink.Next(done)
+
+	record.AddCatch(done,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+	record.Next(sink)
+
+	ts.appendChain(
+		awsstepfunctions.Chain_Custom(record, &[]awsstepfunctions.INextable{done}, done),
+		*record.Node().Id(),
+		7,
+	)
+	return nil
+}
@@ -0,0 +1,42 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Describe annotates the step following the morphism 𝑚: A ⟼ B with the human
+// description, which becomes the `Comment` of the generated state. Workflow
+// Studio graph documents the pipeline for operators who do not read the code.
+// Descriptions are applied to functions (`Join`, `Lift`, etc.) and sinks,
+// they do not change the version of the pipeline.
+//
+//	typestep.Join(Enrich, typestep.Describe("enrich the account with profile", m))
+//	typestep.ToQueue(q, typestep.Describe("notify the billing", m))
+func Describe[A, B any](comment string, m duct.Morphism[A, B]) duct.Morphism[A, B] {
+	return duct.Join(duct.L2[B, B](describe{comment: comment}), m)
+}
+
+type describe struct {
+	comment string
+}
+
+// comment of the state, the pending description is consumed by the first
+// function or sink generated after it.
+func (ts *typeStep) comment() *string {
+	if ts.describe == "" {
+		return nil
+	}
+
+	comment := ts.describe
+	ts.describe = ""
+	return jsii.String(comment)
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Yield results of 𝑚: A ⟼ B into the terminal function 𝑓: B ⟼ C, which is
+// invoked asynchronously (fire-and-forget). The pipeline completes once the
+// event is accepted by AWS Lambda. Use options to configure destinations
+// (on-success, on-failure targets) of the asynchronous invocation, the reply
+// C of the function is delivered to on-success destination.
+func ToFunction[A, B, C any](f F[B, C], m duct.Morphism[A, B], opts ...*awslambda.EventInvokeConfigOptions) duct.Morphism[A, duct.Void] {
+	fn := newLambda(1, f)
+	if len(opts) != 0 {
+		target := fn.f
+		if fn.alias != nil {
+			target = fn.alias
+		}
+		target.ConfigureAsyncInvoke(opts[0])
+	}
+	return duct.Yield(duct.L1[B](function{fn}), m)
+}
+
+// sink of category B into AWS Lambda
+type function struct{ lambda }
+
+// async invokes the terminal function with the event
+func (ts *typeStep) async(f function) awsstepfunctionstasks.LambdaInvoke {
+	return ts.invoke(f.lambda,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath:      jsii.String(ts.args),
+			InvocationType: awsstepfunctionstasks.LambdaInvocationType_EVENT,
+			ResultPath:     awsstepfunctions.JsonPath_DISCARD(),
+		},
+	)
+}
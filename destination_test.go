@@ -0,0 +1,82 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambdadestinations"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/scud"
+	"github.com/fogfish/typestep"
+	"github.com/fogfish/typestep/internal/test"
+)
+
+func TestToFunction(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.NewFunctionTyped(stack, jsii.String("B"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToFunction(b,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+			&awslambda.EventInvokeConfigOptions{
+				OnFailure:     awslambdadestinations.NewSqsDestination(queue),
+				RetryAttempts: jsii.Number(1),
+			},
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::EventInvokeConfig"),
+		map[string]any{
+			"MaximumRetryAttempts": 1,
+			"DestinationConfig": map[string]any{
+				"OnFailure": map[string]any{
+					"Destination": "arn:aws:sqs:eu-west-1:000000000000:my-queue",
+				},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"MapA":{"Next":"MapB"`,
+		`"InvocationType":"Event"`,
+		`"ResultPath":null`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// Package diff produces the semantic diff of typestep pipelines, the deployed
+// state machine is compared with the definition synthesized locally (e.g. by
+// `cdk synth`). Changes are reported per state: states added or removed,
+// types of states, transitions, resources and retries, so that change-review
+// gates see the effect of the composition rather than the diff of JSON.
+//
+//	deployed, err := diff.Deployed(ctx, cfg, "arn:aws:states:...")
+//	local, err := diff.Template(template, "PipeStateMachine")
+//	changes, err := diff.Compare(deployed, local)
+//
+// Types of payloads are not part of the definition, use the schema gate
+// (TypeStepProps.SchemaCompatibility) to review them.
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+)
+
+// Kinds of changes
+const (
+	StateAdded      = "added"
+	StateRemoved    = "removed"
+	TypeChanged     = "type"
+	NextChanged     = "next"
+	ResourceChanged = "resource"
+	RetryChanged    = "retry"
+)
+
+// Change of the state, the state is the path of nested states (e.g. Seq/MapA)
+type Change struct {
+	State string `json:"state"`
+	Kind  string `json:"kind"`
+	Was   string `json:"was,omitempty"`
+	Now   string `json:"now,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case StateAdded, StateRemoved:
+		return fmt.Sprintf("%s %s", c.State, c.Kind)
+	default:
+		return fmt.Sprintf("%s %s changed: %s ⟼ %s", c.State, c.Kind, c.Was, c.Now)
+	}
+}
+
+type describer interface {
+	DescribeStateMachine(context.Context, *sfn.DescribeStateMachineInput, ...func(*sfn.Options)) (*sfn.DescribeStateMachineOutput, error)
+}
+
+// Deployed fetches the definition of the deployed state machine
+func Deployed(ctx context.Context, cfg aws.Config, stateMachine string) ([]byte, error) {
+	return deployed(ctx, sfn.NewFromConfig(cfg), stateMachine)
+}
+
+func deployed(ctx context.Context, api describer, stateMachine string) ([]byte, error) {
+	out, err := api.DescribeStateMachine(ctx,
+		&sfn.DescribeStateMachineInput{
+			StateMachineArn: aws.String(stateMachine),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("typestep failed to describe state machine: %w", err)
+	}
+	return []byte(aws.ToString(out.Definition)), nil
+}
+
+// Template extracts the definition of the state machine from CloudFormation
+// template, the state machine is identified by the prefix of its logical id.
+// The prefix is optional if the template defines the single state machine.
+// References to resources are replaced with placeholders `${...}`, which
+// match any value of the deployed definition.
+func Template(template []byte, logicalID string) ([]byte, error) {
+	var cfn struct {
+		Resources map[string]struct {
+			Type       string         `json:"Type"`
+			Properties map[string]any `json:"Properties"`
+		} `json:"Resources"`
+	}
+	if err := json.Unmarshal(template, &cfn); err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for id, res := range cfn.Resources {
+		if res.Type == "AWS::StepFunctions::StateMachine" && strings.HasPrefix(id, logicalID) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	if len(ids) != 1 {
+		return nil, fmt.Errorf("template defines %d state machines %v matching %q", len(ids), ids, logicalID)
+	}
+
+	switch def := cfn.Resources[ids[0]].Properties["DefinitionString"].(type) {
+	case string:
+		return []byte(def), nil
+	case map[string]any:
+		join, ok := def["Fn::Join"].([]any)
+		if !ok || len(join) != 2 {
+			return nil, fmt.Errorf("state machine %s has unsupported definition", ids[0])
+		}
+		parts, _ := join[1].([]any)
+		sep, _ := join[0].(string)
+
+		seq := make([]string, len(parts))
+		for i, x := range parts {
+			switch v := x.(type) {
+			case string:
+				seq[i] = v
+			default:
+				seq[i] = placeholder
+			}
+		}
+		return []byte(strings.Join(seq, sep)), nil
+	default:
+		return nil, fmt.Errorf("state machine %s has no definition", ids[0])
+	}
+}
+
+// placeholder of the reference, it matches any value
+const placeholder = "${token}"
+
+type definition struct {
+	StartAt string           `json:"StartAt"`
+	States  map[string]state `json:"States"`
+}
+
+type state struct {
+	Type          string       `json:"Type"`
+	Next          string       `json:"Next"`
+	End           bool         `json:"End"`
+	Default       string       `json:"Default"`
+	Resource      string       `json:"Resource"`
+	Retry         any          `json:"Retry"`
+	Branches      []definition `json:"Branches"`
+	Iterator      *definition  `json:"Iterator"`
+	ItemProcessor *definition  `json:"ItemProcessor"`
+}
+
+// Compare definitions of state machines, changes are sorted by states
+func Compare(was, now []byte) ([]Change, error) {
+	var a, b definition
+	if err := json.Unmarshal(was, &a); err != nil {
+		return nil, fmt.Errorf("invalid deployed definition: %w", err)
+	}
+	if err := json.Unmarshal(now, &b); err != nil {
+		return nil, fmt.Errorf("invalid local definition: %w", err)
+	}
+
+	changes := compare(flatten("", a), flatten("", b))
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].State < changes[j].State })
+	return changes, nil
+}
+
+// flatten nested states into the map indexed by path
+func flatten(prefix string, def definition) map[string]state {
+	states := map[string]state{}
+	for name, s := range def.States {
+		path := prefix + name
+		states[path] = s
+
+		nested := s.Branches
+		if s.Iterator != nil {
+			nested = append(nested, *s.Iterator)
+		}
+		if s.ItemProcessor != nil {
+			nested = append(nested, *s.ItemProcessor)
+		}
+		for _, x := range nested {
+			for k, v := range flatten(path+"/", x) {
+				states[k] = v
+			}
+		}
+	}
+	return states
+}
+
+func compare(was, now map[string]state) []Change {
+	changes := []Change{}
+	for name, a := range was {
+		b, has := now[name]
+		if !has {
+			changes = append(changes, Change{State: name, Kind: StateRemoved})
+			continue
+		}
+
+		if a.Type != b.Type {
+			changes = append(changes, Change{State: name, Kind: TypeChanged, Was: a.Type, Now: b.Type})
+		}
+		if next(a) != next(b) {
+			changes = append(changes, Change{State: name, Kind: NextChanged, Was: next(a), Now: next(b)})
+		}
+		if !match(a.Resource, b.Resource) {
+			changes = append(changes, Change{State: name, Kind: ResourceChanged, Was: a.Resource, Now: b.Resource})
+		}
+		if !reflect.DeepEqual(a.Retry, b.Retry) {
+			changes = append(changes, Change{State: name, Kind: RetryChanged, Was: encode(a.Retry), Now: encode(b.Retry)})
+		}
+	}
+
+	for name := range now {
+		if _, has := was[name]; !has {
+			changes = append(changes, Change{State: name, Kind: StateAdded})
+		}
+	}
+
+	return changes
+}
+
+// next is the transition of the state
+func next(s state) string {
+	switch {
+	case s.End:
+		return "End"
+	case s.Next != "":
+		return s.Next
+	default:
+		return s.Default
+	}
+}
+
+// match deployed value with local one, placeholders match any value
+func match(was, now string) bool {
+	if !strings.Contains(now, placeholder) {
+		return was == now
+	}
+
+	seq := strings.Split(now, placeholder)
+	for i := range seq {
+		seq[i] = regexp.QuoteMeta(seq[i])
+	}
+	return regexp.MustCompile("^" + strings.Join(seq, ".*") + "$").MatchString(was)
+}
+
+func encode(v any) string {
+	if v == nil {
+		return "default"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
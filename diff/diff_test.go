@@ -0,0 +1,92 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package diff
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+)
+
+const deployedASL = `{
+  "StartAt": "MapA",
+  "States": {
+    "MapA": {"Type": "Task", "Resource": "arn:aws:states:::lambda:invoke", "Next": "SeqX", "Retry": [{"ErrorEquals": ["States.ALL"], "MaxAttempts": 3}]},
+    "SeqX": {"Type": "Map", "Next": "Sink", "ItemProcessor": {"StartAt": "MapB", "States": {"MapB": {"Type": "Task", "Resource": "arn:aws:states:::lambda:invoke", "End": true}}}},
+    "Sink": {"Type": "Task", "Resource": "arn:aws:states:::aws-sdk:sqs:sendMessage", "End": true}
+  }
+}`
+
+type mockDescriber struct{}
+
+func (mockDescriber) DescribeStateMachine(ctx context.Context, in *sfn.DescribeStateMachineInput, opts ...func(*sfn.Options)) (*sfn.DescribeStateMachineOutput, error) {
+	return &sfn.DescribeStateMachineOutput{Definition: aws.String(deployedASL)}, nil
+}
+
+func TestCompare(t *testing.T) {
+	// GIVEN
+	was, err := deployed(context.Background(), mockDescriber{}, "arn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"Resources": {
+	  "PipeStateMachine1234": {"Type": "AWS::StepFunctions::StateMachine", "Properties": {"DefinitionString": {"Fn::Join": ["", [
+	    "{\"StartAt\":\"MapA\",\"States\":{",
+	    "\"MapA\":{\"Type\":\"Task\",\"Resource\":\"arn:",
+	    {"Ref": "AWS::Partition"},
+	    ":states:::lambda:invoke\",\"Next\":\"SeqX\",\"Retry\":[{\"ErrorEquals\":[\"States.ALL\"],\"MaxAttempts\":5}]},",
+	    "\"SeqX\":{\"Type\":\"Map\",\"Next\":\"Sink\",\"ItemProcessor\":{\"StartAt\":\"MapC\",\"States\":{\"MapC\":{\"Type\":\"Task\",\"Resource\":\"arn:aws:states:::lambda:invoke\",\"End\":true}}}},",
+	    "\"Sink\":{\"Type\":\"Task\",\"Resource\":\"arn:aws:states:::aws-sdk:sns:publish\",\"End\":true}}}"
+	  ]]}}},
+	  "Queue": {"Type": "AWS::SQS::Queue"}
+	}}`)
+
+	// WHEN
+	now, err := Template(template, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Compare(was, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	expect := []Change{
+		{State: "MapA", Kind: RetryChanged, Was: `[{"ErrorEquals":["States.ALL"],"MaxAttempts":3}]`, Now: `[{"ErrorEquals":["States.ALL"],"MaxAttempts":5}]`},
+		{State: "SeqX/MapB", Kind: StateRemoved},
+		{State: "SeqX/MapC", Kind: StateAdded},
+		{State: "Sink", Kind: ResourceChanged, Was: "arn:aws:states:::aws-sdk:sqs:sendMessage", Now: "arn:aws:states:::aws-sdk:sns:publish"},
+	}
+	if !reflect.DeepEqual(changes, expect) {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+func TestCompareSame(t *testing.T) {
+	changes, err := Compare([]byte(deployedASL), []byte(deployedASL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+func TestTemplateUnknownMachine(t *testing.T) {
+	_, err := Template([]byte(`{"Resources": {}}`), "Pipe")
+	if err == nil {
+		t.Errorf("undefined state machine is not reported")
+	}
+}
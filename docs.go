@@ -0,0 +1,231 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/fogfish/golem/duct"
+)
+
+// Docs renders the Markdown document of the pipeline 𝑚: A ⟼ B, consumable by
+// developer portals. The document lists sources, the table of typed steps
+// (input and output types, owning lambda, timeout and retries), sinks,
+// the diagram of the pipeline and the failure behavior.
+//
+//	doc, err := typestep.Docs(m)
+func Docs[A, B any](m duct.Morphism[A, B]) (string, error) {
+	d := &docs{}
+	if err := m.Apply(d); err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
+
+type docs struct {
+	duct.AstVisitor
+	sources []string
+	steps   []string
+	sinks   []string
+	errors  []string
+	graph   []string
+	last    []string
+	n       int
+}
+
+// node of the diagram, it is linked with the previous one
+func (d *docs) node(label string) {
+	id := fmt.Sprintf("n%d", d.n)
+	d.n++
+
+	d.graph = append(d.graph, fmt.Sprintf("  %s[\"%s\"]", id, strings.ReplaceAll(label, `"`, "'")))
+	if last := d.last[len(d.last)-1]; last != "" {
+		d.graph = append(d.graph, fmt.Sprintf("  %s --> %s", last, id))
+	}
+	d.last[len(d.last)-1] = id
+}
+
+func (d *docs) OnEnterMorphism(depth int, node duct.AstSeq) error {
+	d.last = []string{""}
+	return nil
+}
+
+func (d *docs) OnEnterSeq(depth int, node duct.AstSeq) error {
+	d.graph = append(d.graph, fmt.Sprintf("  subgraph s%d [\"for each\"]", d.n))
+	d.last = append(d.last, d.last[len(d.last)-1])
+	return nil
+}
+
+func (d *docs) OnLeaveSeq(depth int, node duct.AstSeq) error {
+	d.graph = append(d.graph, "  end")
+	last := d.last[len(d.last)-1]
+	d.last = d.last[:len(d.last)-1]
+	d.last[len(d.last)-1] = last
+	return nil
+}
+
+func (d *docs) OnEnterFrom(depth int, node duct.AstFrom) error {
+	switch f := node.Source.(type) {
+	case source:
+		// Note: naming policy is the property of construct, it is not applied
+		cat := f.cat
+		if len(cat) == 0 {
+			cat = []string{(&typeStep{}).detailTypeOf(f.kind)}
+		}
+		d.sources = append(d.sources, fmt.Sprintf("| EventBridge `%s` | `%s` | `%s` |", *f.bus.Node().Path(), strings.Join(cat, "`, `"), node.Type))
+	case queued:
+		d.sources = append(d.sources, fmt.Sprintf("| SQS `%s` | | `%s` |", *f.q.Node().Path(), node.Type))
+	default:
+		d.sources = append(d.sources, fmt.Sprintf("| %s | | `%s` |", nameOf(f), node.Type))
+	}
+	d.node("From " + node.Type)
+	return nil
+}
+
+func (d *docs) OnEnterMap(depth int, node duct.AstMap) error {
+	if _, ok := node.F.(describe); ok {
+		return nil
+	}
+
+	name, owner, timeout, retry := nameOf(node.F), "", "", ""
+	if f, ok := lambdaOf(node.F); ok {
+		name = *f.f.Node().Id()
+		owner, timeout, retry = "`"+*f.f.Node().Path()+"`", timeoutOf(f.f), "default"
+		if f.retry != nil && f.retry.MaxAttempts != nil {
+			retry = fmt.Sprintf("%v attempts", *f.retry.MaxAttempts)
+		}
+		for _, e := range f.errors {
+			handler := "retried"
+			if e.handler.queue != nil {
+				handler = "routed to `" + *e.handler.queue.Node().Path() + "`"
+			}
+			d.errors = append(d.errors, fmt.Sprintf("| %s | `%s`, %s |", name, e.kind, handler))
+		}
+		d.errors = append(d.errors, fmt.Sprintf("| %s | function error, after retries |", name))
+	}
+
+	switch f := node.F.(type) {
+	case assertion:
+		d.errors = append(d.errors, fmt.Sprintf("| %s | `%s` |", name, ErrAssertion))
+	case lookup:
+		d.errors = append(d.errors, fmt.Sprintf("| %s | `%s` |", name, ErrLookup))
+	case quota:
+		name = "quota " + f.name
+		if !f.pause {
+			d.errors = append(d.errors, fmt.Sprintf("| %s | `%s` |", name, ErrQuotaExceeded))
+		}
+	case semaphore:
+		name = fmt.Sprintf("semaphore %s (%d)", f.name, f.limit)
+	case mutex:
+		name = "mutex " + strings.Join(f.path.names(nil), ".")
+	case job:
+		d.errors = append(d.errors, fmt.Sprintf("| %s | `%s` |", name, ErrJobFailed))
+	case awaitEvent:
+		timeout = f.timeout.String()
+		d.errors = append(d.errors, fmt.Sprintf("| %s | `%s` |", name, ErrAwaitTimeout))
+	}
+
+	d.steps = append(d.steps, fmt.Sprintf("| %d | %s | `%s` | `%s` | %s | %s | %s |", len(d.steps)+1, name, node.TypeA, node.TypeB, owner, timeout, retry))
+	d.node(name)
+	return nil
+}
+
+func (d *docs) OnEnterYield(depth int, node duct.AstYield) error {
+	target := ""
+	switch f := node.Target.(type) {
+	case queue:
+		target = "SQS `" + *f.q.Node().Path() + "`"
+	case eventbus:
+		target = "EventBridge `" + *f.bus.Node().Path() + "`"
+	case function:
+		target = "Lambda `" + *f.f.Node().Path() + "`"
+	case timestream:
+		target = "Timestream `" + *f.table.Node().Path() + "`"
+	case redshift:
+		target = "Redshift `" + f.table.Table + "`"
+	case outbox:
+		target = "DynamoDB `" + *f.table.Node().Path() + "` ⟼ EventBridge `" + *f.bus.Node().Path() + "`"
+	default:
+		target = nameOf(f)
+	}
+	d.sinks = append(d.sinks, fmt.Sprintf("| %s | `%s` |", target, node.Type))
+	d.node("To " + node.Type)
+	return nil
+}
+
+func (d *docs) String() string {
+	var sb strings.Builder
+
+	table := func(title, header string, rows []string) {
+		if len(rows) == 0 {
+			return
+		}
+		sb.WriteString("## " + title + "\n\n" + header + "\n")
+		for _, row := range rows {
+			sb.WriteString(row + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Pipeline\n\n")
+	table("Sources", "| Source | Detail-type | Type |\n| --- | --- | --- |", d.sources)
+	table("Steps", "| # | Step | Input | Output | Lambda | Timeout | Retry |\n| --- | --- | --- | --- | --- | --- | --- |", d.steps)
+	table("Sinks", "| Sink | Type |\n| --- | --- |", d.sinks)
+
+	sb.WriteString("## Diagram\n\n```mermaid\nflowchart LR\n")
+	sb.WriteString(strings.Join(d.graph, "\n"))
+	sb.WriteString("\n```\n\n")
+
+	sb.WriteString("## Failures\n\n")
+	sb.WriteString("Failed steps are routed to the dead-letter queue with the input and the error when `TypeStepProps.DeadLetterQueue` is configured, the execution fails otherwise.\n\n")
+	table("Errors", "| Step | Error |\n| --- | --- |", d.errors)
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// lambdaOf returns the function of the step
+func lambdaOf(f any) (lambda, bool) {
+	switch f := f.(type) {
+	case lambda:
+		return f, true
+	case joinWith:
+		return f.lambda, true
+	case inout:
+		return f.lambda, true
+	case pages:
+		return f.lambda, true
+	case flagged:
+		return f.lambda, true
+	case continueAsNew:
+		return f.lambda, true
+	case job:
+		return f.start, true
+	default:
+		return lambda{}, false
+	}
+}
+
+// nameOf the built-in step
+func nameOf(f any) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", f), "typestep.")
+}
+
+// timeoutOf the function deployed by the stack, imported functions are unknown
+func timeoutOf(f awslambda.IFunction) string {
+	cfn, ok := f.Node().DefaultChild().(awslambda.CfnFunction)
+	if !ok {
+		return ""
+	}
+	if cfn.Timeout() == nil {
+		return "3s"
+	}
+	return fmt.Sprintf("%vs", *cfn.Timeout())
+}
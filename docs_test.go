@@ -0,0 +1,62 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestDocs(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[User, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.Lift(b, p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	// WHEN
+	doc, err := typestep.Docs(p4)
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expect := range []string{
+		"# Pipeline",
+		"| EventBridge `Test/Events` | `User` |",
+		"| 1 | A | `User` | `[]string` | `Test/A` |  | default |",
+		"| 2 | B | `string` | `string` | `Test/B` |  | default |",
+		"| SQS `Test/Queue` |",
+		"```mermaid\nflowchart LR\n",
+		"subgraph",
+		"## Failures",
+	} {
+		if !strings.Contains(doc, expect) {
+			t.Errorf("document does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudwatch"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// ErrDrift is the error of drift monitor, which detects pipeline-owned
+// resources modified or deleted outside of the stack.
+const ErrDrift = "typestep.Drift"
+
+// resources owned by the pipeline, which are checked for the drift
+var driftTypes = map[string]bool{
+	"AWS::StepFunctions::StateMachine": true,
+	"AWS::Events::Rule":                true,
+	"AWS::Pipes::Pipe":                 true,
+	"AWS::SQS::Queue":                  true,
+	"AWS::Lambda::Function":            true,
+}
+
+// read permissions required by CloudFormation to detect drift of resources
+var driftActions = []*string{
+	jsii.String("cloudformation:DetectStackResourceDrift"),
+	jsii.String("states:DescribeStateMachine"),
+	jsii.String("states:ListTagsForResource"),
+	jsii.String("events:DescribeRule"),
+	jsii.String("events:ListTargetsByRule"),
+	jsii.String("events:ListTagsForResource"),
+	jsii.String("pipes:DescribePipe"),
+	jsii.String("sqs:GetQueueAttributes"),
+	jsii.String("sqs:ListQueueTags"),
+	jsii.String("lambda:GetFunction"),
+	jsii.String("lambda:GetFunctionCodeSigningConfig"),
+	jsii.String("lambda:GetFunctionConfiguration"),
+	jsii.String("lambda:ListTags"),
+	jsii.String("logs:DescribeLogGroups"),
+	jsii.String("iam:GetRole"),
+	jsii.String("iam:GetRolePolicy"),
+	jsii.String("iam:ListRolePolicies"),
+	jsii.String("iam:ListAttachedRolePolicies"),
+}
+
+// NewDriftMonitor periodically asks CloudFormation to detect drift of
+// resources declared by pipelines: state machines, rules and pipes, the
+// dead-letter queue and functions deployed by the stack. The execution
+// fails with [ErrDrift] listing logical ids of resources that are modified
+// or deleted outside of the stack (e.g. the rule pattern is edited in
+// the console), the alarm is raised. Call it after all pipelines are defined.
+//
+//	typestep.StateMachine(ts, m)
+//	typestep.NewDriftMonitor(ts, awsevents.Schedule_Rate(awscdk.Duration_Hours(jsii.Number(1))))
+func NewDriftMonitor(ts TypeStep, schedule awsevents.Schedule) awscloudwatch.Alarm {
+	b := ts.(*typeStep)
+	if len(b.pipelines) == 0 {
+		panic(fmt.Errorf("drift monitor requires the pipeline"))
+	}
+
+	stack := awscdk.Stack_Of(b.Construct)
+	resources := b.driftResources(stack)
+
+	check := awsstepfunctionstasks.CallAwsService_Jsonata(b.Construct, jsii.String("DriftDetect"),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Service: jsii.String("cloudformation"),
+			Action:  jsii.String("detectStackResourceDrift"),
+			Parameters: &map[string]any{
+				"StackName":         stack.StackName(),
+				"LogicalResourceId": "{% $states.input %}",
+			},
+			Outputs:      "{% $states.result.StackResourceDrift %}",
+			IamResources: &[]*string{stack.StackId()},
+			IamAction:    jsii.String("cloudformation:DetectStackResourceDrift"),
+		},
+	)
+
+	each := awsstepfunctions.Map_Jsonata(b.Construct, jsii.String("DriftCheck"),
+		&awsstepfunctions.MapJsonataProps{
+			Items:          awsstepfunctions.ProvideItems_JsonArray(&resources),
+			MaxConcurrency: jsii.Number(1),
+		},
+	).ItemProcessor(check, nil)
+
+	drift := awsstepfunctions.Fail_Jsonata(b.Construct, jsii.String("Drift"),
+		&awsstepfunctions.FailJsonataProps{
+			Error: jsii.String(ErrDrift),
+			Cause: jsii.String("{% 'drift of ' & $join($states.input[StackResourceDriftStatus in ['MODIFIED', 'DELETED']].LogicalResourceId, ', ') %}"),
+		},
+	)
+
+	choice := awsstepfunctions.Choice_Jsonata(b.Construct, jsii.String("DriftFound"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $count($states.input[StackResourceDriftStatus in ['MODIFIED', 'DELETED']]) > 0 %}")),
+		drift,
+		nil,
+	).Otherwise(
+		awsstepfunctions.NewSucceed(b.Construct, jsii.String("NoDrift"), nil),
+	)
+
+	monitor := awsstepfunctions.NewStateMachine(b.Construct, jsii.String("DriftMonitor"),
+		&awsstepfunctions.StateMachineProps{
+			DefinitionBody: awsstepfunctions.ChainDefinitionBody_FromChainable(
+				awsstepfunctions.Chain_Start(each).Next(choice),
+			),
+		},
+	)
+
+	// Note: CloudFormation reads actual properties using the caller identity
+	monitor.AddToRolePolicy(awsiam.NewPolicyStatement(
+		&awsiam.PolicyStatementProps{
+			Actions:   &driftActions,
+			Resources: jsii.Strings("*"),
+		},
+	))
+
+	awsevents.NewRule(b.Construct, jsii.String("DriftSchedule"),
+		&awsevents.RuleProps{
+			Schedule: schedule,
+			Targets: &[]awsevents.IRuleTarget{
+				awseventstargets.NewSfnStateMachine(monitor, &awseventstargets.SfnStateMachineProps{}),
+			},
+		},
+	)
+
+	return awscloudwatch.NewAlarm(b.Construct, jsii.String("DriftDetected"),
+		&awscloudwatch.AlarmProps{
+			Metric:            monitor.MetricFailed(nil),
+			Threshold:         jsii.Number(1),
+			EvaluationPeriods: jsii.Number(1),
+			TreatMissingData:  awscloudwatch.TreatMissingData_NOT_BREACHING,
+		},
+	)
+}
+
+// logical ids of resources owned by pipelines within the stack
+func (ts *typeStep) driftResources(stack awscdk.Stack) []any {
+	seen := map[string]bool{}
+	add := func(c constructs.IConstruct) {
+		cfn, ok := c.(awscdk.CfnResource)
+		if !ok || !driftTypes[*cfn.CfnResourceType()] {
+			return
+		}
+		if *awscdk.Stack_Of(cfn).Node().Path() != *stack.Node().Path() {
+			return
+		}
+		seen[*stack.GetLogicalId(cfn)] = true
+	}
+
+	for _, c := range *ts.Node().FindAll(constructs.ConstructOrder_PREORDER) {
+		add(c)
+	}
+	if ts.DeadLetterQueue != nil {
+		add(ts.DeadLetterQueue.Node().DefaultChild())
+	}
+	for _, f := range ts.functions {
+		add(f.Node().DefaultChild())
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	items := make([]any, len(ids))
+	for i, id := range ids {
+		items[i] = id
+	}
+	return items
+}
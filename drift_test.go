@@ -0,0 +1,106 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/scud"
+	"github.com/fogfish/typestep"
+	"github.com/fogfish/typestep/internal/test"
+)
+
+func TestDriftMonitor(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	dlq := awssqs.NewQueue(stack, jsii.String("DLQ"), &awssqs.QueueProps{})
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(f, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: dlq,
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	typestep.NewDriftMonitor(ts,
+		awsevents.Schedule_Rate(awscdk.Duration_Hours(jsii.Number(1))),
+	)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(2))
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"ScheduleExpression": "rate(1 hour)",
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::CloudWatch::Alarm"),
+		map[string]any{
+			"MetricName": "ExecutionsFailed",
+		},
+	)
+
+	expect := []string{
+		`:states:::aws-sdk:cloudformation:detectStackResourceDrift"`,
+		`"Error":"typestep.Drift"`,
+	}
+	for _, kind := range []string{"AWS::StepFunctions::StateMachine", "AWS::Events::Rule", "AWS::SQS::Queue", "AWS::Lambda::Function"} {
+		for id := range *template.FindResources(jsii.String(kind), nil) {
+			// Note: singletons of CDK (e.g. log retention) are not owned by pipeline
+			if !strings.HasPrefix(id, "PipeDrift") && !strings.HasPrefix(id, "LogRetention") {
+				expect = append(expect, `"`+id+`"`)
+			}
+		}
+	}
+
+	asl := definitionOf(template)
+	for _, e := range expect {
+		if !strings.Contains(asl, e) {
+			t.Errorf("state machine does not contain %s", e)
+		}
+	}
+}
+
+func TestDriftMonitorNoPipeline(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("drift monitor without pipeline should panic")
+		}
+	}()
+
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+
+	typestep.NewDriftMonitor(ts,
+		awsevents.Schedule_Rate(awscdk.Duration_Hours(jsii.Number(1))),
+	)
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/base64"
+	"reflect"
+	"sort"
+
+	"github.com/fogfish/typestep/runtime"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Encoding is the wire format of payloads passed between steps of the pipeline.
+// The encoding is applied by the runtime wrapper of typed functions only.
+// Custom codecs registered with [runtime.RegisterCodec] are selected by id
+// (e.g. `Encoding("jsoniter")`).
+type Encoding string
+
+const (
+	// Payloads are JSON objects (default).
+	EncodingJSON Encoding = runtime.CodecJSON
+
+	// Payloads are protobuf messages wrapped into base64 strings, the types
+	// used by functions must be generated protobuf messages. The function
+	// which output is read by other states (e.g. sinks, assertions, sorting)
+	// emits canonical JSON form of the message at any position.
+	EncodingProtobuf Encoding = runtime.CodecProtobuf
+)
+
+var typeProtoMessage = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// protoFileOf returns descriptor of the file declaring message type t or
+// the element of sequence []t. It returns nil for other types.
+func protoFileOf(t reflect.Type) protoreflect.FileDescriptor {
+	if t == nil {
+		return nil
+	}
+
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Ptr || !t.Implements(typeProtoMessage) {
+		return nil
+	}
+
+	msg := reflect.New(t.Elem()).Interface().(proto.Message)
+	return msg.ProtoReflect().Descriptor().ParentFile()
+}
+
+// protoFiles is the registry of protobuf schemas used by the pipeline.
+type protoFiles map[string]protoreflect.FileDescriptor
+
+func (files protoFiles) register(t reflect.Type) {
+	if fd := protoFileOf(t); fd != nil {
+		files.registerFile(fd)
+	}
+}
+
+func (files protoFiles) registerFile(fd protoreflect.FileDescriptor) {
+	if _, has := files[fd.Path()]; has {
+		return
+	}
+
+	files[fd.Path()] = fd
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		files.registerFile(imports.Get(i).FileDescriptor)
+	}
+}
+
+// encode registry as base64 FileDescriptorSet
+func (files protoFiles) encode() (string, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, path := range paths {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(files[path]))
+	}
+
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,178 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+	"github.com/fogfish/typestep/runtime"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestEncodingProtobuf(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[*wrapperspb.StringValue, *wrapperspb.Int64Value](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[*wrapperspb.StringValue](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Encoding: typestep.EncodingProtobuf,
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResource(jsii.String("AWS::StepFunctions::StateMachine"),
+		map[string]any{
+			"Metadata": assertions.Match_ObjectLike(&map[string]any{
+				"typestep:protobuf": assertions.Match_AnyValue(),
+			}),
+		},
+	)
+}
+
+// snake codec is JSON codec with snake_case names of fields
+type snake struct{}
+
+func (snake) Encode(v any) ([]byte, error)    { return json.Marshal(v) }
+func (snake) Decode(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (snake) FieldName(f reflect.StructField) string {
+	var sb strings.Builder
+	for i, r := range f.Name {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(f.Name[i-1])) {
+				sb.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func init() { runtime.RegisterCodec("snake", snake{}) }
+
+type Billing struct {
+	CustomerID string `typestep:"group"`
+}
+
+func TestEncodingFieldNamer(t *testing.T) {
+	for encoding, expect := range map[typestep.Encoding]string{
+		"snake":               `"MessageGroupId.$":"$.Payload.customer_id"`,
+		typestep.EncodingJSON: `"MessageGroupId.$":"$.Payload.CustomerID"`,
+	} {
+		// GIVEN
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+		event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue.fifo"))
+
+		a := typestep.Function_FromFunctionArn[string, Billing](stack, jsii.String("A"),
+			jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+		// THEN
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+			&typestep.TypeStepProps{
+				Encoding: encoding,
+			},
+		)
+		typestep.StateMachine(ts,
+			typestep.ToQueue(queue,
+				typestep.Join(a,
+					typestep.From[string](event),
+				),
+			),
+		)
+
+		// WHEN
+		template := assertions.Template_FromStack(stack, nil)
+		if asl := definitionOf(template); !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition of %s encoding does not contain %s", encoding, expect)
+		}
+	}
+}
+
+func TestEncodingProtobufReadable(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	inline := func(id string) awslambda.Function {
+		return awslambda.NewFunction(stack, jsii.String(id),
+			&awslambda.FunctionProps{
+				FunctionName: jsii.String(strings.ToLower(id)),
+				Runtime:      awslambda.Runtime_NODEJS_LATEST(),
+				Handler:      jsii.String("index.handler"),
+				Code:         awslambda.Code_FromInline(jsii.String("none")),
+			},
+		)
+	}
+
+	h := &typestep.Function[*wrapperspb.StringValue, *wrapperspb.StringValue]{Function: inline("H")}
+	f := &typestep.Function[*wrapperspb.StringValue, *wrapperspb.StringValue]{Function: inline("F")}
+	g := &typestep.Function[string, *wrapperspb.Int64Value]{Function: inline("G")}
+
+	// THEN
+	p1 := typestep.From[*wrapperspb.StringValue](event)
+	p2 := typestep.Join(h, p1)
+	p3 := typestep.Join(f, p2)
+	p4 := typestep.Hash("SHA-256", p3)
+	p5 := typestep.Join(g, p4)
+	p6 := typestep.ToQueue(queue, p5)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Encoding: typestep.EncodingProtobuf,
+		},
+	)
+	typestep.StateMachine(ts, p6)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	for name, codec := range map[string]string{
+		"h": runtime.CodecProtobuf,
+		"f": runtime.CodecProtoJSON,
+		"g": runtime.CodecProtoJSON,
+	} {
+		template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+			map[string]any{
+				"FunctionName": name,
+				"Environment": map[string]any{
+					"Variables": map[string]any{
+						runtime.EnvCodec: codec,
+					},
+				},
+			},
+		)
+	}
+}
@@ -0,0 +1,184 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// TagResource is the value of struct tag `typestep:"resource"`, which
+	// annotates fields listed as resources of EventBridge event.
+	TagResource = "resource"
+
+	// EventBridge limit of entries per request
+	maxEntries = 10
+
+	// ErrPublish is the error of events rejected by EventBridge after retries
+	ErrPublish = "typestep.PublishFailed"
+
+	// attempts to publish entries rejected by EventBridge
+	maxPublishAttempts = 3
+
+	// variables of the entries pending publishing and the attempt
+	varEntries = "typestepEntries"
+	varAttempt = "typestepAttempt"
+)
+
+// X-Ray trace header derived from W3C trace context of the execution
+const traceheader = "'Root=1-' & $substring($split($traceparent, '-')[1], 0, 8) & '-' & $substring($split($traceparent, '-')[1], 8) & ';Parent=' & $split($traceparent, '-')[2] & ';Sampled=1'"
+
+// sink of category B into AWS EventBridge
+type eventbus struct {
+	bus    awsevents.IEventBus
+	source string
+	cat    []string
+	kind   reflect.Type
+	dedup  *dedup
+}
+
+// publish emits results of the pipeline as events. The sequence of results is
+// emitted as individual events, batched up to 10 entries per request.
+func (ts *typeStep) publish(f eventbus, detailType string) {
+	input := "$states.input" + strings.TrimPrefix(ts.args, "$")
+	id := ts.unique("Sink")
+
+	if f.kind.Kind() != reflect.Slice {
+		sink := ts.putEvents(id, f.bus, "["+ts.entryOf(f, input, f.kind, detailType)+"]")
+		ts.appendChain(sink, id, 5)
+		return
+	}
+
+	chunks := awsstepfunctions.NewPass(ts.scope, jsii.String(id+"Batch"),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{
+				"Payload.$": fmt.Sprintf("States.ArrayPartition(%s, %d)", ts.args, maxEntries),
+			},
+		},
+	)
+	ts.append(chunks)
+
+	entry := ts.entryOf(f, "$v", f.kind.Elem(), strings.TrimPrefix(detailType, "[]"))
+	put := ts.putEvents(id+"Put", f.bus, "$map($states.input, function($v) { "+entry+" })[]")
+
+	foreach := awsstepfunctions.NewMap(ts.scope, jsii.String(id),
+		&awsstepfunctions.MapProps{
+			ItemsPath:      jsii.String("$.Payload"),
+			MaxConcurrency: jsii.Number(1),
+		},
+	)
+	foreach.ItemProcessor(put, &awsstepfunctions.ProcessorConfig{})
+	ts.appendChain(foreach, *foreach.Node().Id(), 6)
+}
+
+// putEvents creates the chain for emitting JSONata entries into the bus. The
+// entries rejected by EventBridge (FailedEntryCount) are published again with
+// backoff, the pipeline fails when entries are still rejected after attempts.
+//
+//	Pass ⟼ PutEvents ⟼ Choice ⟼ (failed) Wait ⟼ PutEvents
+//	                          ⟼ (exhausted) Diagnostic ⟼ DLQ ⟼ Fail
+//	                          ⟼ Pass
+func (ts *typeStep) putEvents(id string, bus awsevents.IEventBus, entries string) awsstepfunctions.Chain {
+	pending := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String(id+"Entries"),
+		&awsstepfunctions.PassJsonataProps{
+			Assign: &map[string]any{
+				varEntries: "{% " + entries + " %}",
+				varAttempt: 0,
+			},
+		},
+	)
+
+	put := awsstepfunctionstasks.CallAwsService_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Comment: ts.comment(),
+			Service: jsii.String("eventbridge"),
+			Action:  jsii.String("putEvents"),
+			Parameters: &map[string]any{
+				"Entries": "{% $" + varEntries + " %}",
+			},
+			Assign: &map[string]any{
+				varEntries: "{% $states.result.FailedEntryCount > 0 ? $append([], $filter($" + varEntries + ", function($v, $i) { $exists($states.result.Entries[$i].ErrorCode) })) : [] %}",
+				varAttempt: "{% $" + varAttempt + " + 1 %}",
+			},
+			Outputs:      "{% $states.input %}",
+			IamResources: jsii.Strings(*bus.EventBusArn()),
+			IamAction:    jsii.String("events:PutEvents"),
+		},
+	)
+	put.AddRetry(&awsstepfunctions.RetryProps{
+		Errors:      jsii.Strings("States.ALL"),
+		MaxAttempts: jsii.Number(3),
+		BackoffRate: jsii.Number(2),
+	})
+
+	backoff := awsstepfunctions.Wait_Jsonata(ts.scope, jsii.String(id+"Backoff"),
+		&awsstepfunctions.WaitJsonataProps{
+			Time: awsstepfunctions.WaitTime_Seconds(jsii.String("{% $power(2, $" + varAttempt + ") %}")),
+		},
+	)
+
+	check := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(id+"Check"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String(fmt.Sprintf("{%% $count($%s) > 0 and $%s < %d %%}", varEntries, varAttempt, maxPublishAttempts))),
+		backoff.Next(put),
+		nil,
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $count($"+varEntries+") > 0 %}")),
+		ts.reject(id, "Rejected", ErrPublish, "events are rejected by EventBridge"),
+		nil,
+	)
+
+	done := awsstepfunctions.NewPass(ts.scope, jsii.String(id+"Published"), &awsstepfunctions.PassProps{})
+	check.Otherwise(done)
+
+	pending.Next(put).Next(check)
+	return awsstepfunctions.Chain_Custom(pending, &[]awsstepfunctions.INextable{done}, done)
+}
+
+// entryOf builds JSONata expression of the event's entry for the value. The
+// detail is stamped with version of the pipeline (see [VersionField]), fields
+// annotated with [TagResource] are resources of the event, the trace context
+// of the execution is propagated as trace header.
+func (ts *typeStep) entryOf(f eventbus, value string, kind reflect.Type, detailType string) string {
+	entry := []string{
+		`"Source": ` + quote(f.source),
+		`"DetailType": ` + quote(detailType),
+		`"EventBusName": ` + quote(*f.bus.EventBusArn()),
+		`"Detail": $string(` + ts.stamped(value) + `)`,
+	}
+
+	if seq := fieldsOf(ts.namer, value, kind, TagResource); len(seq) != 0 {
+		resources := make([]string, len(seq))
+		for i, r := range seq {
+			resources[i] = r.path
+		}
+		entry = append(entry, `"Resources": [`+strings.Join(resources, ", ")+`]`)
+	}
+
+	if ts.tracing {
+		entry = append(entry, `"TraceHeader": `+traceheader)
+	}
+
+	return "{" + strings.Join(entry, ", ") + "}"
+}
+
+// quote the string literal of JSONata expression
+func quote(s string) string {
+	raw, _ := json.Marshal(s)
+	return string(raw)
+}
@@ -0,0 +1,233 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type Invoice struct {
+	ID       string `json:"id"`
+	Customer string `json:"customer" typestep:"resource"`
+}
+
+func TestToEventBus(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+
+	a := typestep.Function_FromFunctionArn[string, Invoice](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Tracing: true,
+		},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToEventBus("test", event,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`:states:::aws-sdk:eventbridge:putEvents"`,
+		`\"DetailType\": \"Invoice\"`,
+		`\"Resources\": [$states.input.Payload.customer]`,
+		`\"TraceHeader\": 'Root=1-' & $substring($split($traceparent, '-')[1], 0, 8)`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestToEventBusFailedEntries(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	dlq := awssqs.NewQueue(stack, jsii.String("DLQ"), nil)
+
+	a := typestep.Function_FromFunctionArn[string, Invoice](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: dlq,
+		},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToEventBus("test", event,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"SinkEntries":{"Type":"Pass","QueryLanguage":"JSONata","Next":"Sink","Assign":{"typestepAttempt":0`,
+		`"Arguments":{"Entries":"{% $typestepEntries %}"}`,
+		`"typestepEntries":"{% $states.result.FailedEntryCount > 0 ? $append([], $filter($typestepEntries, function($v, $i) { $exists($states.result.Entries[$i].ErrorCode) })) : [] %}"`,
+		`"SinkBackoff":{"Type":"Wait","QueryLanguage":"JSONata","Seconds":"{% $power(2, $typestepAttempt) %}","Next":"Sink"}`,
+		`{"Condition":"{% $count($typestepEntries) > 0 and $typestepAttempt < 3 %}","Next":"SinkBackoff"}`,
+		`{"Condition":"{% $count($typestepEntries) > 0 %}","Next":"SinkRejected"}`,
+		`"Default":"SinkPublished"`,
+		`"SinkRejected":{"Type":"Pass","QueryLanguage":"JSONata","Output":{"error":{"Cause":"events are rejected by EventBridge","Error":"` + typestep.ErrPublish + `"}`,
+		`"Next":"SinkTry"`,
+		`"SinkErr":{"Type":"Fail","Error":"` + typestep.ErrPublish + `"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestToEventBusBatch(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+
+	a := typestep.Function_FromFunctionArn[string, []Invoice](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToEventBus("test", event,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Payload.$":"States.ArrayPartition($.Payload, 10)"`,
+		`"Sink":{"Type":"Map"`,
+		`$map($states.input, function($v) { {\"Source\": \"test\", \"DetailType\": \"Invoice\"`,
+		`\"Resources\": [$v.customer]`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestToEventBusOnce(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("dedup"))
+
+	a := typestep.Function_FromFunctionArn[string, Invoice](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToEventBusOnce("test", event, table,
+			typestep.Field(func(i *Invoice) *string { return &i.ID }),
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"SinkDedup":{"QueryLanguage":"JSONata","Next":"SinkEntries"`,
+		`"ConditionExpression":"attribute_not_exists(#key)"`,
+		`"key":{"S":"{% $hash($string($states.context.Execution.Input), 'SHA-256') & '/' & $string($states.input.Payload.` + "`id`" + `) %}"}`,
+		`"ErrorEquals":["DynamoDB.ConditionalCheckFailedException"]`,
+		`:states:::aws-sdk:eventbridge:putEvents"`,
+		`"SinkPublished":{"Type":"Pass","Next":"SinkDone"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestToEventBusOnceRedrive(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("dedup"))
+	dlq := awssqs.NewQueue(stack, jsii.String("DLQ"), nil)
+
+	a := typestep.Function_FromFunctionArn[string, Invoice](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{DeadLetterQueue: dlq},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToEventBusOnce("test", event, table,
+			typestep.Field(func(i *Invoice) *string { return &i.ID }),
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+		),
+	)
+
+	// WHEN
+	typestep.NewRedrive(ts)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	// Note: the redriven execution is named by the redrive, the dedup key
+	//       depends on the input, which is re-started as-is.
+	for _, expect := range []string{
+		`"Name":"{% 'typestep-redrive-' & $uuid() %}"`,
+		`"Input":"{% $input %}"`,
+		`"input":"{% $parse($states.result.Input) %}"`,
+		`"key":{"S":"{% $hash($string($states.context.Execution.Input), 'SHA-256') & '/' &`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+	if strings.Contains(asl, "$states.context.Execution.Id & '/'") {
+		t.Errorf("dedup key must not depend on the execution")
+	}
+}
@@ -0,0 +1,302 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// Package fixture records real input events of typestep pipelines into
+// versioned fixture files and replays them, so production-shaped data drives
+// regression tests of typed pipelines. Events are captured either from the
+// audit archive (see TypeStepProps.Audit) or from the tap queue subscribed
+// to the source of the pipeline.
+//
+//	c := fixture.NewCapture(cfg)
+//	events, err := c.FromArchive(ctx, "my-bucket", "Pipe", 100)
+//	path, err := fixture.Fixture{Pipeline: "Pipe", Version: "v1", Events: events}.WriteFile("testdata")
+//
+// The fixture is replayed in-process through typed functions of the pipeline
+// (see [Each]), or through the deployed pipeline of the test account (see
+// [Player]).
+package fixture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// Fixture is the versioned set of input events of the pipeline
+type Fixture struct {
+	Pipeline string            `json:"pipeline"`
+	Version  string            `json:"version"`
+	Events   []json.RawMessage `json:"events"`
+}
+
+// WriteFile writes the fixture as `<dir>/<pipeline>/<version>.json`, it returns
+// the path to the file.
+func (f Fixture) WriteFile(dir string) (string, error) {
+	if f.Pipeline == "" || f.Version == "" {
+		return "", fmt.Errorf("fixture requires pipeline and version")
+	}
+
+	path := filepath.Join(dir, f.Pipeline, f.Version+".json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return path, os.WriteFile(path, raw, 0644)
+}
+
+// WriteEvents writes each event of the fixture as the individual file
+// `<dir>/<pipeline>/<version>/<n>.json`, which is the payload for local
+// invocation of the typed function (e.g. `sam local invoke -e`, see
+// typestep.SAM). It returns paths to files.
+func (f Fixture) WriteEvents(dir string) ([]string, error) {
+	if f.Pipeline == "" || f.Version == "" {
+		return nil, fmt.Errorf("fixture requires pipeline and version")
+	}
+
+	root := filepath.Join(dir, f.Pipeline, f.Version)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(f.Events))
+	for i, event := range f.Events {
+		paths[i] = filepath.Join(root, fmt.Sprintf("%d.json", i))
+		if err := os.WriteFile(paths[i], event, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
+
+// ReadFile reads the fixture
+func ReadFile(path string) (Fixture, error) {
+	var f Fixture
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return f, err
+	}
+
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return f, fmt.Errorf("typestep invalid fixture %s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// Each decodes events of the fixture into the input type A of the pipeline
+// and applies the function to each of them, e.g. the composition of typed
+// functions under the test.
+func Each[A any](f Fixture, fn func(A) error) error {
+	for i, event := range f.Events {
+		var a A
+		if err := json.Unmarshal(event, &a); err != nil {
+			return fmt.Errorf("typestep failed to decode event %d of %s/%s: %w", i, f.Pipeline, f.Version, err)
+		}
+
+		if err := fn(a); err != nil {
+			return fmt.Errorf("event %d of %s/%s: %w", i, f.Pipeline, f.Version, err)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type storage interface {
+	ListObjectsV2(context.Context, *s3.ListObjectsV2Input, ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+type queue interface {
+	ReceiveMessage(context.Context, *sqs.ReceiveMessageInput, ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+}
+
+// Capture of input events
+type Capture struct {
+	storage storage
+	queue   queue
+}
+
+// NewCapture creates capture of input events
+func NewCapture(cfg aws.Config) *Capture {
+	return &Capture{
+		storage: s3.NewFromConfig(cfg),
+		queue:   sqs.NewFromConfig(cfg),
+	}
+}
+
+// FromArchive captures up to n input events of the pipeline from the audit
+// archive, the archive retains the detail of source events.
+func (c *Capture) FromArchive(ctx context.Context, bucket, pipeline string, n int) ([]json.RawMessage, error) {
+	prefix := "typestep/" + pipeline + "/"
+	events := []json.RawMessage{}
+
+	var token *string
+	for {
+		out, err := c.storage.ListObjectsV2(ctx,
+			&s3.ListObjectsV2Input{
+				Bucket:            aws.String(bucket),
+				Prefix:            aws.String(prefix),
+				ContinuationToken: token,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("typestep failed to list archive: %w", err)
+		}
+
+		for _, obj := range out.Contents {
+			// typestep/<pipeline>/<execution>/<step>/<uuid>.json
+			seq := strings.Split(strings.TrimPrefix(aws.ToString(obj.Key), prefix), "/")
+			if len(seq) != 3 || seq[1] != "Source" {
+				continue
+			}
+
+			event, err := c.object(ctx, bucket, aws.ToString(obj.Key))
+			if err != nil {
+				return nil, err
+			}
+
+			events = append(events, event)
+			if len(events) == n {
+				return events, nil
+			}
+		}
+
+		if !aws.ToBool(out.IsTruncated) {
+			return events, nil
+		}
+		token = out.NextContinuationToken
+	}
+}
+
+func (c *Capture) object(ctx context.Context, bucket, key string) (json.RawMessage, error) {
+	obj, err := c.storage.GetObject(ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("typestep failed to read archive: %w", err)
+	}
+	defer obj.Body.Close()
+
+	raw, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return nil, fmt.Errorf("typestep failed to read archive: %w", err)
+	}
+
+	return raw, nil
+}
+
+// FromQueue captures up to n input events from the tap queue, which is
+// subscribed to the source of the pipeline. Messages are not deleted from
+// the queue, the detail of EventBridge events is captured.
+func (c *Capture) FromQueue(ctx context.Context, queueURL string, n int) ([]json.RawMessage, error) {
+	events := []json.RawMessage{}
+
+	for len(events) < n {
+		out, err := c.queue.ReceiveMessage(ctx,
+			&sqs.ReceiveMessageInput{
+				QueueUrl:            aws.String(queueURL),
+				MaxNumberOfMessages: int32(min(n-len(events), 10)),
+				WaitTimeSeconds:     1,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("typestep failed to receive events: %w", err)
+		}
+
+		if len(out.Messages) == 0 {
+			return events, nil
+		}
+
+		for _, msg := range out.Messages {
+			events = append(events, detailOf(aws.ToString(msg.Body)))
+		}
+	}
+
+	return events, nil
+}
+
+// detail of EventBridge event, other messages are captured as-is
+func detailOf(body string) json.RawMessage {
+	var event struct {
+		DetailType string          `json:"detail-type"`
+		Detail     json.RawMessage `json:"detail"`
+	}
+
+	if json.Unmarshal([]byte(body), &event) == nil && event.DetailType != "" && len(event.Detail) != 0 {
+		return event.Detail
+	}
+	return json.RawMessage(body)
+}
+
+//------------------------------------------------------------------------------
+
+type executor interface {
+	StartExecution(context.Context, *sfn.StartExecutionInput, ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error)
+}
+
+// Player replays fixtures through the deployed pipeline
+type Player struct {
+	machine  string
+	executor executor
+}
+
+// NewPlayer creates player of fixtures, the state machine is ARN of
+// the pipeline deployed to the test account.
+func NewPlayer(cfg aws.Config, stateMachine string) *Player {
+	return &Player{
+		machine:  stateMachine,
+		executor: sfn.NewFromConfig(cfg),
+	}
+}
+
+// Play starts the execution of the pipeline for each event of the fixture,
+// it returns ARNs of executions.
+func (p *Player) Play(ctx context.Context, f Fixture) ([]string, error) {
+	seq := make([]string, 0, len(f.Events))
+
+	for _, event := range f.Events {
+		input, err := json.Marshal(map[string]any{"detail": event})
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := p.executor.StartExecution(ctx,
+			&sfn.StartExecutionInput{
+				StateMachineArn: aws.String(p.machine),
+				Input:           aws.String(string(input)),
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("typestep failed to start execution: %w", err)
+		}
+
+		seq = append(seq, aws.ToString(out.ExecutionArn))
+	}
+
+	return seq, nil
+}
@@ -0,0 +1,183 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package fixture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+type mockStorage map[string]string
+
+func (m mockStorage) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, opts ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	seq := []types.Object{}
+	for key := range m {
+		if strings.HasPrefix(key, aws.ToString(in.Prefix)) {
+			seq = append(seq, types.Object{Key: aws.String(key)})
+		}
+	}
+	sort.Slice(seq, func(i, j int) bool { return *seq[i].Key < *seq[j].Key })
+	return &s3.ListObjectsV2Output{Contents: seq}, nil
+}
+
+func (m mockStorage) GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewBufferString(m[aws.ToString(in.Key)]))}, nil
+}
+
+type mockQueue []string
+
+func (m *mockQueue) ReceiveMessage(ctx context.Context, in *sqs.ReceiveMessageInput, opts ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	n := min(int(in.MaxNumberOfMessages), len(*m))
+	seq := []sqstypes.Message{}
+	for _, body := range (*m)[:n] {
+		seq = append(seq, sqstypes.Message{Body: aws.String(body)})
+	}
+	*m = (*m)[n:]
+	return &sqs.ReceiveMessageOutput{Messages: seq}, nil
+}
+
+type mockExecutor struct{ inputs []string }
+
+func (m *mockExecutor) StartExecution(ctx context.Context, in *sfn.StartExecutionInput, opts ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error) {
+	m.inputs = append(m.inputs, aws.ToString(in.Input))
+	return &sfn.StartExecutionOutput{ExecutionArn: aws.String("arn")}, nil
+}
+
+func TestFromArchive(t *testing.T) {
+	// GIVEN
+	c := &Capture{
+		storage: mockStorage{
+			"typestep/Pipe/ex1/Source/1.json": `{"id":"a"}`,
+			"typestep/Pipe/ex1/A/2.json":      `"x"`,
+			"typestep/Pipe/ex2/Source/3.json": `{"id":"b"}`,
+			"typestep/Pipe/ex3/Source/4.json": `{"id":"c"}`,
+		},
+	}
+
+	// WHEN
+	events, err := c.FromArchive(context.Background(), "bucket", "Pipe", 2)
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || string(events[0]) != `{"id":"a"}` || string(events[1]) != `{"id":"b"}` {
+		t.Errorf("unexpected events %s", events)
+	}
+}
+
+func TestFromQueue(t *testing.T) {
+	// GIVEN
+	q := &mockQueue{}
+	for i := 0; i < 12; i++ {
+		*q = append(*q, `{"detail-type":"User","detail":{"id":"a"}}`)
+	}
+	*q = append(*q, `{"id":"b"}`)
+	c := &Capture{queue: q}
+
+	// WHEN
+	events, err := c.FromQueue(context.Background(), "https://sqs", 100)
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 13 || string(events[0]) != `{"id":"a"}` || string(events[12]) != `{"id":"b"}` {
+		t.Errorf("unexpected events %s", events)
+	}
+}
+
+func TestFixture(t *testing.T) {
+	// GIVEN
+	f := Fixture{
+		Pipeline: "Pipe",
+		Version:  "v1",
+		Events:   []json.RawMessage{json.RawMessage(`{"id":"a"}`), json.RawMessage(`{"id":"b"}`)},
+	}
+
+	// WHEN
+	path, err := f.WriteFile(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "Pipe/v1.json") {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	g, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	ids := []string{}
+	err = Each(g, func(x struct{ ID string }) error {
+		ids = append(ids, x.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ids, ",") != "a,b" {
+		t.Errorf("unexpected events %v", ids)
+	}
+
+	// WHEN
+	executor := &mockExecutor{}
+	p := &Player{machine: "arn", executor: executor}
+	if _, err := p.Play(context.Background(), g); err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	if len(executor.inputs) != 2 || executor.inputs[0] != `{"detail":{"id":"a"}}` {
+		t.Errorf("unexpected inputs %v", executor.inputs)
+	}
+}
+
+func TestFixtureEvents(t *testing.T) {
+	// GIVEN
+	f := Fixture{
+		Pipeline: "Pipe",
+		Version:  "v1",
+		Events:   []json.RawMessage{json.RawMessage(`{"id":"a"}`), json.RawMessage(`{"id":"b"}`)},
+	}
+
+	// WHEN
+	paths, err := f.WriteEvents(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	if len(paths) != 2 || !strings.HasSuffix(paths[1], "Pipe/v1/1.json") {
+		t.Fatalf("unexpected paths %v", paths)
+	}
+
+	raw, err := os.ReadFile(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `{"id":"b"}` {
+		t.Errorf("unexpected event %s", raw)
+	}
+}
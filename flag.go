@@ -0,0 +1,86 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsssm"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// WhenEnabled composes lambda function transformer 𝑓: B ⟼ B with morphism
+// 𝑚: A ⟼ B, the function is only invoked if the feature flag is enabled.
+// The flag is SSM parameter, which value is `true` when the feature is
+// enabled, the step passes the payload as-is otherwise. The flag is read
+// once per step of the execution, which enables dark launches of new stages
+// of the pipeline without re-deployment. The parameter must exist.
+//
+//	typestep.WhenEnabled(flag, Enrich, m)
+func WhenEnabled[A, B any](flag awsssm.IStringParameter, f F[B, B], m duct.Morphism[A, B]) duct.Morphism[A, B] {
+	fn := newLambda(1, f)
+	return duct.Join(duct.L2[B, B](flagged{lambda: fn, flag: flag}), m)
+}
+
+type flagged struct {
+	lambda
+	flag awsssm.IStringParameter
+}
+
+// flagged invokes the function if the flag is enabled
+//
+//	Flag ⟼ Choice ⟼ (enabled) f
+//	              ⟼ Pass
+func (ts *typeStep) flagged(f flagged) {
+	id := ts.idOf("Flag")
+
+	read := awsstepfunctionstasks.CallAwsService_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Service: jsii.String("ssm"),
+			Action:  jsii.String("getParameter"),
+			Parameters: &map[string]any{
+				"Name": f.flag.ParameterName(),
+			},
+			IamResources: jsii.Strings(*f.flag.ParameterArn()),
+			IamAction:    jsii.String("ssm:GetParameter"),
+			Assign: &map[string]any{
+				id: "{% $states.result.Parameter.Value = 'true' %}",
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+	ts.append(read)
+
+	compute := ts.invoke(f.lambda,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath: jsii.String(ts.args),
+		},
+	)
+
+	skip := awsstepfunctions.NewPass(ts.scope, jsii.String(id+"Skip"),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{"Payload.$": ts.args},
+		},
+	)
+
+	choice := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(id+"On"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $"+id+" %}")),
+		compute,
+		nil,
+	).Otherwise(skip)
+
+	ts.appendChain(
+		choice.Afterwards(nil),
+		id+"On",
+		3,
+	)
+}
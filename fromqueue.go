@@ -0,0 +1,99 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awspipes"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Creates new morphism 𝑚, binding it with AWS SQS queue for reading messages
+// of type `A`, the body of message is JSON of `A`. The pipeline is started
+// per message by EventBridge Pipe.
+//
+// FIFO queues preserve semantics of MessageGroupId, messages of the group are
+// processed sequentially, one execution after another, while groups are
+// processed concurrently. The pipeline is express workflow, the pipe awaits
+// completion of the execution before the next message of the group, failed
+// executions are retried by the queue (use its dead-letter queue). The
+// visibility timeout of the queue shall exceed the duration of the pipeline.
+//
+//	typestep.FromQueue[Order](queue)
+func FromQueue[A any](q awssqs.IQueue) duct.Morphism[A, A] {
+	return duct.From(duct.L1[A](queued{q: q}))
+}
+
+type queued struct {
+	q awssqs.IQueue
+}
+
+// fifo queues are declared by the stack or imported by ARN (`.fifo` suffix)
+func (q queued) fifo() bool {
+	fifo := q.q.Fifo()
+	return fifo != nil && *fifo
+}
+
+// express pipelines are not observable by status change events
+func (ts *typeStep) express() bool {
+	return ts.api != nil || (ts.queued != nil && ts.queued.fifo())
+}
+
+// dequeue the message, the body of message is the event's detail
+func (ts *typeStep) dequeue() {
+	msg := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("Message"),
+		&awsstepfunctions.PassJsonataProps{
+			Outputs: map[string]any{
+				"detail": "{% $parse($states.input[0].body) %}",
+			},
+		},
+	)
+	ts.append(msg)
+}
+
+// consume messages of the queue by EventBridge Pipe, one message per execution
+func (ts *typeStep) consume(states awsstepfunctions.IStateMachine) error {
+	role := awsiam.NewRole(ts.scope, jsii.String("QueueRole"),
+		&awsiam.RoleProps{
+			AssumedBy: awsiam.NewServicePrincipal(jsii.String("pipes.amazonaws.com"), nil),
+		},
+	)
+	ts.queued.q.GrantConsumeMessages(role)
+
+	invocation := "FIRE_AND_FORGET"
+	if ts.queued.fifo() {
+		invocation = "REQUEST_RESPONSE"
+		states.GrantStartSyncExecution(role)
+	} else {
+		states.GrantStartExecution(role)
+	}
+
+	awspipes.NewCfnPipe(ts.scope, jsii.String("Pipe"),
+		&awspipes.CfnPipeProps{
+			RoleArn: role.RoleArn(),
+			Source:  ts.queued.q.QueueArn(),
+			Target:  states.StateMachineArn(),
+			SourceParameters: &awspipes.CfnPipe_PipeSourceParametersProperty{
+				SqsQueueParameters: &awspipes.CfnPipe_PipeSourceSqsQueueParametersProperty{
+					BatchSize: jsii.Number(1),
+				},
+			},
+			TargetParameters: &awspipes.CfnPipe_PipeTargetParametersProperty{
+				StepFunctionStateMachineParameters: &awspipes.CfnPipe_PipeTargetStateMachineParametersProperty{
+					InvocationType: jsii.String(invocation),
+				},
+			},
+		},
+	)
+
+	return nil
+}
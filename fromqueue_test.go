@@ -0,0 +1,78 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestFromQueue(t *testing.T) {
+	for _, tc := range []struct {
+		arn        string
+		invocation string
+		kind       any
+	}{
+		{arn: "arn:aws:sqs:eu-west-1:000000000000:orders", invocation: "FIRE_AND_FORGET", kind: assertions.Match_Absent()},
+		{arn: "arn:aws:sqs:eu-west-1:000000000000:orders.fifo", invocation: "REQUEST_RESPONSE", kind: "EXPRESS"},
+	} {
+		// GIVEN
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+		source := awssqs.Queue_FromQueueArn(stack, jsii.String("Source"), jsii.String(tc.arn))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+		a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+			jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+		// THEN
+		p1 := typestep.FromQueue[string](source)
+		p2 := typestep.Join(a, p1)
+		p3 := typestep.ToQueue(queue, p2)
+
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+		typestep.StateMachine(ts, p3)
+
+		// WHEN
+		template := assertions.Template_FromStack(stack, nil)
+		template.ResourceCountIs(jsii.String("AWS::Events::Rule"), jsii.Number(0))
+		template.HasResourceProperties(jsii.String("AWS::StepFunctions::StateMachine"),
+			map[string]any{
+				"StateMachineType": tc.kind,
+			},
+		)
+		template.HasResourceProperties(jsii.String("AWS::Pipes::Pipe"),
+			map[string]any{
+				"Source": tc.arn,
+				"SourceParameters": map[string]any{
+					"SqsQueueParameters": map[string]any{"BatchSize": 1},
+				},
+				"TargetParameters": map[string]any{
+					"StepFunctionStateMachineParameters": map[string]any{"InvocationType": tc.invocation},
+				},
+			},
+		)
+
+		asl := definitionOf(template)
+		for _, expect := range []string{
+			`"Message":{"Type":"Pass","QueryLanguage":"JSONata","Output":{"detail":"{% $parse($states.input[0].body) %}"},"Next":"MapA"}`,
+			`"InputPath":"$.detail"`,
+		} {
+			if !strings.Contains(asl, expect) {
+				t.Errorf("state machine definition does not contain %s", expect)
+			}
+		}
+	}
+}
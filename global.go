@@ -0,0 +1,142 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudwatch"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// GlobalEndpoint binds the source of pipelines with EventBridge global
+// endpoint, which fails over events to the secondary region.
+//
+// The stack is deployed into both regions, each region hosts the replica of
+// the pipeline. The event bus of the source must have the same name in both
+// regions. The endpoint and its health check are defined by the stack of
+// the primary region, the health check fails when the pipeline's executions
+// fail in the primary region.
+type GlobalEndpoint struct {
+	// Primary region of the endpoint
+	Primary string
+
+	// Secondary region of the endpoint, it receives events on failover
+	Secondary string
+
+	// Replication of events to the secondary region
+	Replication bool
+}
+
+// endpoint defines global endpoint of the pipeline
+func (ts *typeStep) endpoint(states awsstepfunctions.StateMachine) error {
+	stack := awscdk.Stack_Of(ts.scope)
+	if *awscdk.Token_IsUnresolved(stack.Region()) {
+		return fmt.Errorf("global endpoint requires the stack bound to region")
+	}
+
+	if *stack.Region() != ts.global.Primary {
+		return nil
+	}
+
+	alarm := awscloudwatch.NewAlarm(ts.scope, jsii.String("Health"),
+		&awscloudwatch.AlarmProps{
+			Metric:            states.MetricFailed(nil),
+			Threshold:         jsii.Number(1),
+			EvaluationPeriods: jsii.Number(1),
+			TreatMissingData:  awscloudwatch.TreatMissingData_NOT_BREACHING,
+		},
+	)
+
+	check := awsroute53.NewCfnHealthCheck(ts.scope, jsii.String("HealthCheck"),
+		&awsroute53.CfnHealthCheckProps{
+			HealthCheckConfig: &awsroute53.CfnHealthCheck_HealthCheckConfigProperty{
+				Type: jsii.String("CLOUDWATCH_METRIC"),
+				AlarmIdentifier: &awsroute53.CfnHealthCheck_AlarmIdentifierProperty{
+					Name:   alarm.AlarmName(),
+					Region: stack.Region(),
+				},
+				InsufficientDataHealthStatus: jsii.String("Healthy"),
+			},
+		},
+	)
+
+	buses := []string{
+		ts.busArnAt(stack, ts.global.Primary),
+		ts.busArnAt(stack, ts.global.Secondary),
+	}
+
+	replication := "DISABLED"
+	var role *string
+	if ts.global.Replication {
+		replication = "ENABLED"
+		r := awsiam.NewRole(ts.scope, jsii.String("EndpointRole"),
+			&awsiam.RoleProps{
+				AssumedBy: awsiam.NewServicePrincipal(jsii.String("events.amazonaws.com"), nil),
+			},
+		)
+		r.AddToPolicy(
+			awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+				Actions:   jsii.Strings("events:PutEvents"),
+				Resources: jsii.Strings(buses...),
+			}),
+		)
+		role = r.RoleArn()
+	}
+
+	awsevents.NewCfnEndpoint(ts.scope, jsii.String("Endpoint"),
+		&awsevents.CfnEndpointProps{
+			Name: jsii.String(ts.endpointName(stack)),
+			EventBuses: &[]*awsevents.CfnEndpoint_EndpointEventBusProperty{
+				{EventBusArn: jsii.String(buses[0])},
+				{EventBusArn: jsii.String(buses[1])},
+			},
+			RoutingConfig: &awsevents.CfnEndpoint_RoutingConfigProperty{
+				FailoverConfig: &awsevents.CfnEndpoint_FailoverConfigProperty{
+					Primary: &awsevents.CfnEndpoint_PrimaryProperty{
+						HealthCheck: jsii.String(fmt.Sprintf("arn:aws:route53:::healthcheck/%s", *check.AttrHealthCheckId())),
+					},
+					Secondary: &awsevents.CfnEndpoint_SecondaryProperty{
+						Route: jsii.String(ts.global.Secondary),
+					},
+				},
+			},
+			ReplicationConfig: &awsevents.CfnEndpoint_ReplicationConfigProperty{
+				State: jsii.String(replication),
+			},
+			RoleArn: role,
+		},
+	)
+
+	return nil
+}
+
+// arn of the source event bus in the region
+func (ts *typeStep) busArnAt(stack awscdk.Stack, region string) string {
+	return *stack.FormatArn(&awscdk.ArnComponents{
+		Service:      jsii.String("events"),
+		Region:       jsii.String(region),
+		Resource:     jsii.String("event-bus"),
+		ResourceName: ts.bus.EventBusName(),
+	})
+}
+
+// name of the endpoint, unique within the account
+func (ts *typeStep) endpointName(stack awscdk.Stack) string {
+	name := *stack.StackName() + "-" + ts.name()
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
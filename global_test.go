@@ -0,0 +1,72 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestGlobalEndpoint(t *testing.T) {
+	for region, endpoints := range map[string]float64{"eu-west-1": 1, "eu-central-1": 0} {
+		// GIVEN
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"),
+			&awscdk.StackProps{
+				Env: &awscdk.Environment{
+					Account: jsii.String("000000000000"),
+					Region:  jsii.String(region),
+				},
+			},
+		)
+		event := awsevents.EventBus_FromEventBusName(stack, jsii.String("Events"), jsii.String("my-event-bus"))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+		a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+			jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+		// THEN
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+			&typestep.TypeStepProps{
+				GlobalEndpoint: &typestep.GlobalEndpoint{
+					Primary:     "eu-west-1",
+					Secondary:   "eu-central-1",
+					Replication: true,
+				},
+			},
+		)
+		typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[string](event))))
+
+		// WHEN
+		template := assertions.Template_FromStack(stack, nil)
+		template.ResourceCountIs(jsii.String("AWS::Events::Endpoint"), jsii.Number(endpoints))
+		template.ResourceCountIs(jsii.String("AWS::Route53::HealthCheck"), jsii.Number(endpoints))
+		template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(1))
+
+		if endpoints != 0 {
+			template.HasResourceProperties(jsii.String("AWS::Events::Endpoint"),
+				map[string]any{
+					"Name": "Test-Pipe",
+					"RoutingConfig": map[string]any{
+						"FailoverConfig": map[string]any{
+							"Secondary": map[string]any{"Route": "eu-central-1"},
+						},
+					},
+					"ReplicationConfig": map[string]any{"State": "ENABLED"},
+				},
+			)
+		}
+	}
+}
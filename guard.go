@@ -0,0 +1,92 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// the limit of payload size passed between states
+	payloadLimit = 256 * 1024
+
+	// ErrPayloadTooLarge is the error of execution, which payload exceeds
+	// the limit of AWS Step Functions.
+	ErrPayloadTooLarge = "typestep.PayloadTooLarge"
+)
+
+// oversize measures the payload in UTF-8 bytes, the quota of AWS Step
+// Functions, while JSONata `$length` counts characters of 1 to 4 bytes.
+// Only the ambiguous length is percent-encoded, each escaped byte is `%XX`.
+var oversize = fmt.Sprintf(
+	"($s := $string($states.input); $n := $length($s); $n > %d or ($n > %d and ($e := $encodeUrlComponent($s); 2 * $length($replace($e, '%%', '')) - $length($e)) > %d))",
+	payloadLimit, payloadLimit/4, payloadLimit,
+)
+
+// guard checks the size of payload of type before it enters the step
+//
+//	Choice ⟼ (oversize) Diagnostic ⟼ DLQ ⟼ Fail
+//	       ⟼ ...
+func (ts *typeStep) guard(id string, kind string) {
+	check := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String("Size"+id),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% "+oversize+" %}")),
+		ts.oversize("Size"+id, kind),
+		nil,
+	)
+
+	ts.appendChain(
+		check.Afterwards(&awsstepfunctions.AfterwardsOptions{IncludeOtherwise: jsii.Bool(true)}),
+		*check.Node().Id(),
+		1,
+	)
+}
+
+// oversize is the diagnostic of payload, which exceeds the limit
+func (ts *typeStep) oversize(id string, kind string) awsstepfunctions.IChainable {
+	cause := fmt.Sprintf("payload of %s exceeds %d bytes at %s", kind, payloadLimit, id)
+	return ts.reject(id, "Oversize", ErrPayloadTooLarge, cause)
+}
+
+// reject fails the execution with typed diagnostic, which is routed to
+// the dead-letter queue
+func (ts *typeStep) reject(id, kind, err, cause string) awsstepfunctions.IChainable {
+	diagnostic := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String(id+kind),
+		&awsstepfunctions.PassJsonataProps{
+			Outputs: map[string]any{
+				"error": map[string]any{
+					"Error": err,
+					"Cause": cause,
+				},
+				"execution": "{% $states.context.Execution.Id %}",
+			},
+		},
+	)
+
+	fail := awsstepfunctions.NewFail(ts.scope, jsii.String(id+"Err"),
+		&awsstepfunctions.FailProps{
+			Error: jsii.String(err),
+			Cause: jsii.String(cause),
+		},
+	)
+
+	ts.sizes[len(ts.sizes)-1] += 2
+	if ts.DeadLetterQueue == nil {
+		return diagnostic.Next(fail)
+	}
+
+	dlq := ts.deadLetter(id + "Try")
+	ts.sizes[len(ts.sizes)-1] += 1
+
+	return diagnostic.Next(dlq).Next(fail)
+}
@@ -0,0 +1,92 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestSizeGuard(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.Lift(b, p2)
+	p4 := typestep.ToQueue(queue, typestep.Unit(p3))
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: queue,
+			SizeGuard:       true,
+		},
+	)
+	typestep.StateMachine(ts, p4)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"SizeSource":{"Type":"Choice","QueryLanguage":"JSONata","Choices":[{"Condition":"{% ($s := $string($states.input); $n := $length($s); $n > 262144 or ($n > 65536 and ($e := $encodeUrlComponent($s); 2 * $length($replace($e, '%', '')) - $length($e)) > 262144)) %}","Next":"SizeSourceOversize"}],"Default":"MapA"}`,
+		`"SizeBSeq":{"Type":"Choice"`,
+		`"Cause":"payload of string exceeds 262144 bytes at SizeSource"`,
+		`"Next":"SizeSourceTry"`,
+		`"ErrorEquals":["States.DataLimitExceeded"]`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestSizeGuardMultiByte(t *testing.T) {
+	// the guard counts bytes of percent-encoded payload, each escaped byte is %XX
+	size := func(s string) int {
+		e := url.QueryEscape(s)
+		return 2*len(strings.ReplaceAll(e, "%", "")) - len(e)
+	}
+
+	for _, s := range []string{
+		`"ascii"`,
+		`"` + strings.Repeat("ä", 90000) + `"`,
+		`"` + strings.Repeat("€", 90000) + `"`,
+		`"` + strings.Repeat("😀", 70000) + `"`,
+		`{"text":"a%b ä€😀"}`,
+	} {
+		if size(s) != len(s) {
+			t.Errorf("size of payload %d is not %d bytes", size(s), len(s))
+		}
+	}
+
+	// characters under the limit, bytes above it
+	s := `"` + strings.Repeat("€", 90000) + `"`
+	if n := len([]rune(s)); n > 256*1024 || n <= 256*1024/4 || size(s) <= 256*1024 {
+		t.Errorf("multi-byte payload of %d characters is not oversize", n)
+	}
+}
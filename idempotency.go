@@ -0,0 +1,137 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// intake is the express state machine that starts the pipeline with the
+// execution name derived from the hash of idempotency key or from the template
+// of execution name (see TypeStepProps.ExecutionName). AWS Step Functions
+// does not start the execution twice with the same name, so that duplicate
+// events do not start duplicate executions.
+//
+// Note: EventBridge target does not support naming of executions.
+func (ts *typeStep) intake(states awsstepfunctions.IStateMachine) awsstepfunctions.IStateMachine {
+	var name *string
+	switch {
+	case ts.executionName != "":
+		name = awsstepfunctions.JsonPath_StringAt(jsii.String(ts.executionName))
+	default:
+		key := "$.detail" + strings.TrimPrefix(ts.idempotencyKey, "$")
+		name = awsstepfunctions.JsonPath_Hash(
+			awsstepfunctions.JsonPath_JsonToString(awsstepfunctions.JsonPath_ObjectAt(jsii.String(key))),
+			jsii.String("SHA-256"),
+		)
+	}
+
+	start := awsstepfunctionstasks.NewStepFunctionsStartExecution(ts.scope, jsii.String("Intake"),
+		&awsstepfunctionstasks.StepFunctionsStartExecutionProps{
+			StateMachine: states,
+			Name:         name,
+			Input:        awsstepfunctions.TaskInput_FromJsonPathAt(jsii.String("$")),
+		},
+	)
+
+	start.AddCatch(
+		awsstepfunctions.NewSucceed(ts.scope, jsii.String("Duplicate"), &awsstepfunctions.SucceedProps{}),
+		&awsstepfunctions.CatchProps{
+			Errors: jsii.Strings("SFN.ExecutionAlreadyExistsException"),
+		},
+	)
+
+	intake := awsstepfunctions.NewStateMachine(ts.scope, jsii.String("IntakeStateMachine"),
+		&awsstepfunctions.StateMachineProps{
+			StateMachineType: awsstepfunctions.StateMachineType_EXPRESS,
+			DefinitionBody:   awsstepfunctions.ChainDefinitionBody_FromChainable(start),
+		},
+	)
+	ts.machines = append(ts.machines, intake)
+	return intake
+}
+
+// placeholder of the execution name template, e.g. `{{.ID}}`
+var placeholder = regexp.MustCompile(`{{\s*((\.[A-Za-z_][A-Za-z0-9_]*)+)\s*}}`)
+
+// characters that are not allowed by names of executions
+const unsafeExecutionName = " <>{}[]?*\"#%\\^|~`$&,;:/"
+
+// executionNameOf compiles the template of execution name (e.g. `order-{{.ID}}`)
+// into `States.Format` over fields of the event detail. The placeholder is
+// the path of fields of type A, either Go names or JSON names of fields.
+func executionNameOf(n runtime.FieldNamer, template string, kind reflect.Type) (string, error) {
+	args := []string{}
+	format := placeholder.ReplaceAllStringFunc(template, func(s string) string {
+		args = append(args, placeholder.FindStringSubmatch(s)[1])
+		return "{}"
+	})
+	if len(args) == 0 {
+		return "", fmt.Errorf("execution name %s does not refer fields of %s", template, kind)
+	}
+	if strings.ContainsAny(strings.ReplaceAll(format, "{}", ""), unsafeExecutionName) {
+		return "", fmt.Errorf("execution name %s contains characters not allowed by AWS Step Functions", template)
+	}
+
+	for i, arg := range args {
+		path, err := jsonPathOf(n, kind, strings.Split(strings.TrimPrefix(arg, "."), "."))
+		if err != nil {
+			return "", fmt.Errorf("execution name %s: %w", template, err)
+		}
+		args[i] = "$.detail" + path
+	}
+
+	return "States.Format(" + literal(format) + ", " + strings.Join(args, ", ") + ")", nil
+}
+
+// jsonPathOf resolves the path of Go or JSON names of fields into JSON path
+func jsonPathOf(n runtime.FieldNamer, t reflect.Type, names []string) (string, error) {
+	if len(names) == 0 {
+		return "", nil
+	}
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("field %s is not defined by %v", names[0], t)
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := runtime.FieldName(n, f)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if f.Name == names[0] || name == names[0] {
+			path, err := jsonPathOf(n, f.Type, names[1:])
+			if err != nil {
+				return "", err
+			}
+			return "." + name + path, nil
+		}
+	}
+
+	return "", fmt.Errorf("field %s is not defined by %s", names[0], t)
+}
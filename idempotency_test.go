@@ -0,0 +1,110 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.ToQueue(queue, p1)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			IdempotencyKey: "$.id",
+		},
+	)
+	typestep.StateMachine(ts, p2)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(2))
+	template.HasResourceProperties(jsii.String("AWS::StepFunctions::StateMachine"),
+		map[string]any{
+			"StateMachineType": "EXPRESS",
+		},
+	)
+
+	asl := definitionOf(template)
+	expect := `"Name.$":"States.Hash(States.JsonToString($.detail.id), 'SHA-256')"`
+	if !strings.Contains(asl, expect) {
+		t.Errorf("state machine definition does not contain %s", expect)
+	}
+}
+
+func TestExecutionName(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	// THEN
+	p1 := typestep.From[User](event)
+	p2 := typestep.ToQueue(queue, p1)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			ExecutionName: "user-{{.ID}}-{{ .name }}",
+		},
+	)
+	typestep.StateMachine(ts, p2)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(2))
+
+	asl := definitionOf(template)
+	expect := `"Name.$":"States.Format('user-{}-{}', $.detail.id, $.detail.name)"`
+	if !strings.Contains(asl, expect) {
+		t.Errorf("state machine definition does not contain %s", expect)
+	}
+}
+
+func TestExecutionNameUndefinedField(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("execution name of undefined field must panic")
+		}
+	}()
+
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.ToQueue(queue, p1)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			ExecutionName: "user-{{.Email}}",
+		},
+	)
+
+	// WHEN
+	typestep.StateMachine(ts, p2)
+}
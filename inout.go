@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// InOut is the input of the function alongside its output
+type InOut[A, B any] struct {
+	In  A `json:"in"`
+	Out B `json:"out"`
+}
+
+// JoinInOut is equivalent to [Join] but preserves the input of the function.
+// The output of lambda function transformer 𝑓: B ⟼ C is written alongside
+// its input producing a new morphism 𝑚: A ⟼ InOut[B, C], so that downstream
+// steps receive both the original input and the new result.
+func JoinInOut[A, B, C any](
+	f F[B, C],
+	m duct.Morphism[A, B],
+) duct.Morphism[A, InOut[B, C]] {
+	fn := newLambda(1, f)
+	return duct.Join(duct.L2[B, InOut[B, C]](inout{lambda: fn}), m)
+}
+
+type inout struct{ lambda }
+
+// inout writes the result of function into the path of the payload
+//
+//	f (ResultPath) ⟼ Pass
+func (ts *typeStep) inout(f inout) {
+	uuid := ts.uuid
+
+	compute := ts.invoke(f.lambda,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath:      jsii.String(ts.args),
+			ResultSelector: &map[string]any{"Payload.$": "$.Payload"},
+			ResultPath:     jsii.String("$.Reply"),
+		},
+	)
+
+	pack := awsstepfunctions.NewPass(ts.scope, jsii.String("InOut"+uuid),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{
+				"Payload": map[string]any{
+					"in.$":  ts.args,
+					"out.$": "$.Reply.Payload",
+				},
+			},
+		},
+	)
+
+	ts.appendChain(
+		awsstepfunctions.Chain_Start(compute).Next(pack),
+		"InOut"+uuid,
+		2,
+	)
+}
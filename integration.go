@@ -0,0 +1,180 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Invocation is the integration pattern of long-running steps
+type Invocation int
+
+const (
+	// RunJob waits for completion of the job (`.sync`), the state machine is
+	// granted to manage EventBridge rules used by Step Functions to track it.
+	RunJob Invocation = iota
+
+	// RequestResponse starts the job and continues without waiting.
+	RequestResponse
+
+	// WaitForCallback waits for the task token (`.waitForTaskToken`), the job
+	// receives the token within the input and reports the result with
+	// SendTaskSuccess.
+	WaitForCallback
+)
+
+func (i Invocation) pattern() awsstepfunctions.IntegrationPattern {
+	switch i {
+	case RequestResponse:
+		return awsstepfunctions.IntegrationPattern_REQUEST_RESPONSE
+	case WaitForCallback:
+		return awsstepfunctions.IntegrationPattern_WAIT_FOR_TASK_TOKEN
+	default:
+		return awsstepfunctions.IntegrationPattern_RUN_JOB
+	}
+}
+
+// ExecuteProps of the nested state machine
+type ExecuteProps struct {
+	// Invocation of the state machine, either [RunJob] (default) or
+	// [WaitForCallback]. The state machine receives `{"TaskToken": ...,
+	// "Payload": B}` when it is invoked with callback.
+	Invocation Invocation
+}
+
+// Execute composes the state machine 𝑓: B ⟼ C with morphism 𝑚: A ⟼ B. The
+// state machine is the existing workflow, its output is the typed result C.
+//
+//	typestep.Execute[Order, Invoice](billing, m)
+func Execute[A, B, C any](machine awsstepfunctions.IStateMachine, m duct.Morphism[A, B], opts ...*ExecuteProps) duct.Morphism[A, C] {
+	f := execute{
+		machine: machine,
+		input:   reflect.TypeOf(new(B)).Elem(),
+		reply:   reflect.TypeOf(new(C)).Elem(),
+	}
+	if len(opts) != 0 && opts[0] != nil {
+		f.invocation = opts[0].Invocation
+	}
+	return duct.Join(duct.L2[B, C](f), m)
+}
+
+// Imports an existing AWS Step Functions state machine with type-safe
+// annotations. Types of the input and output are declared at the boundary,
+// they are trusted by the pipeline.
+type IStateMachine[A, B any] struct {
+	Handler awsstepfunctions.IStateMachine
+}
+
+// Import existing state machine
+func StateMachine_FromArn[A, B any](scope constructs.Construct, id *string, arn *string) *IStateMachine[A, B] {
+	return &IStateMachine[A, B]{
+		Handler: awsstepfunctions.StateMachine_FromStateMachineArn(scope, id, arn),
+	}
+}
+
+// JoinPipeline composes the typed state machine 𝑓: B ⟼ C with morphism
+// 𝑚: A ⟼ B, see [StateMachine_FromArn]. Legacy workflows are composed into
+// pipelines same way as [Execute] does but types are inferred from the handle.
+//
+//	billing := typestep.StateMachine_FromArn[Order, Invoice](stack, jsii.String("Billing"), arn)
+//	typestep.JoinPipeline(billing, m)
+func JoinPipeline[A, B, C any](f *IStateMachine[B, C], m duct.Morphism[A, B], opts ...*ExecuteProps) duct.Morphism[A, C] {
+	return Execute[A, B, C](f.Handler, m, opts...)
+}
+
+type execute struct {
+	machine    awsstepfunctions.IStateMachine
+	input      reflect.Type
+	reply      reflect.Type
+	invocation Invocation
+}
+
+// execute starts the state machine, the output is packed as Payload
+func (ts *typeStep) execute(f execute) error {
+	id := ts.idOf("Execute")
+
+	input := awsstepfunctions.TaskInput_FromJsonPathAt(jsii.String(ts.args))
+	selector := map[string]any{"Payload.$": "$.Output"}
+
+	switch f.invocation {
+	case RunJob:
+	case WaitForCallback:
+		input = awsstepfunctions.TaskInput_FromObject(&map[string]any{
+			"TaskToken": awsstepfunctions.JsonPath_TaskToken(),
+			"Payload":   awsstepfunctions.JsonPath_ObjectAt(jsii.String(ts.args)),
+		})
+		selector = map[string]any{"Payload.$": "$"}
+	default:
+		return fmt.Errorf("execute %s ⟼ %s requires invocation RunJob or WaitForCallback", f.input, f.reply)
+	}
+
+	task := awsstepfunctionstasks.NewStepFunctionsStartExecution(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.StepFunctionsStartExecutionProps{
+			StateMachine:       f.machine,
+			IntegrationPattern: f.invocation.pattern(),
+			Input:              input,
+			ResultSelector:     &selector,
+		},
+	)
+	ts.append(task)
+
+	return nil
+}
+
+// GlueJobProps of the job
+type GlueJobProps struct {
+	// Invocation of the job, [RunJob] is default. The job receives the task
+	// token as the argument `--task-token` when it is invoked with callback.
+	Invocation Invocation
+}
+
+// RunGlueJob runs AWS Glue job with the payload of morphism 𝑚: A ⟼ B, the job
+// receives JSON of the payload as the argument `--payload`. The payload is
+// passed as-is, the job is the side-effect (e.g. ETL of the dataset).
+//
+//	typestep.RunGlueJob("compaction", m)
+func RunGlueJob[A, B any](job string, m duct.Morphism[A, B], opts ...*GlueJobProps) duct.Morphism[A, B] {
+	f := glueJob{job: job}
+	if len(opts) != 0 && opts[0] != nil {
+		f.invocation = opts[0].Invocation
+	}
+	return duct.Join(duct.L2[B, B](f), m)
+}
+
+type glueJob struct {
+	job        string
+	invocation Invocation
+}
+
+// glue runs the job, the result of job is discarded
+func (ts *typeStep) glue(f glueJob) {
+	args := map[string]any{
+		"--payload": awsstepfunctions.JsonPath_JsonToString(awsstepfunctions.JsonPath_ObjectAt(jsii.String(ts.args))),
+	}
+	if f.invocation == WaitForCallback {
+		args["--task-token"] = awsstepfunctions.JsonPath_TaskToken()
+	}
+
+	task := awsstepfunctionstasks.NewGlueStartJobRun(ts.scope, jsii.String(ts.idOf("Glue")),
+		&awsstepfunctionstasks.GlueStartJobRunProps{
+			GlueJobName:        jsii.String(f.job),
+			IntegrationPattern: f.invocation.pattern(),
+			Arguments:          awsstepfunctions.TaskInput_FromObject(&args),
+			ResultPath:         awsstepfunctions.JsonPath_DISCARD(),
+		},
+	)
+	ts.append(task)
+}
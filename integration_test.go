@@ -0,0 +1,166 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestExecute(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	machine := awsstepfunctions.StateMachine_FromStateMachineArn(stack, jsii.String("Billing"),
+		jsii.String("arn:aws:states:eu-west-1:000000000000:stateMachine:billing"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.Execute[User, User, string](machine, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+
+	// WHEN
+	typestep.StateMachine(ts, p3)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::IAM::Policy"),
+		map[string]any{
+			"PolicyDocument": map[string]any{
+				"Statement": assertions.Match_ArrayWith(&[]any{
+					assertions.Match_ObjectLike(&map[string]any{
+						"Action": []string{"events:PutTargets", "events:PutRule", "events:DescribeRule"},
+					}),
+				}),
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`:states:startExecution.sync:2"`,
+		`"Input.$":"$.detail"`,
+		`"ResultSelector":{"Payload.$":"$.Output"}`,
+		`"MessageBody.$":"$.Payload"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
+
+func TestJoinPipeline(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	billing := typestep.StateMachine_FromArn[User, string](stack, jsii.String("Billing"),
+		jsii.String("arn:aws:states:eu-west-1:000000000000:stateMachine:billing"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.JoinPipeline(billing, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+
+	// WHEN
+	typestep.StateMachine(ts, p3)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`:states:startExecution.sync:2"`,
+		`"StateMachineArn":"arn:aws:states:eu-west-1:000000000000:stateMachine:billing"`,
+		`"ResultSelector":{"Payload.$":"$.Output"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
+
+func TestExecuteCallback(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	machine := awsstepfunctions.StateMachine_FromStateMachineArn(stack, jsii.String("Billing"),
+		jsii.String("arn:aws:states:eu-west-1:000000000000:stateMachine:billing"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.Execute[User, User, string](machine, p1,
+		&typestep.ExecuteProps{Invocation: typestep.WaitForCallback},
+	)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+
+	// WHEN
+	typestep.StateMachine(ts, p3)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`:states:startExecution.waitForTaskToken"`,
+		`"TaskToken.$":"$$.Task.Token"`,
+		`"Payload.$":"$.detail"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
+
+func TestRunGlueJob(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.RunGlueJob("compaction", p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+
+	// WHEN
+	typestep.StateMachine(ts, p3)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`:glue:startJobRun.sync"`,
+		`"JobName":"compaction"`,
+		`"--payload.$":"States.JsonToString($.detail)"`,
+		`"ResultPath":null`,
+		`"MessageBody.$":"States.JsonToString($.detail)"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
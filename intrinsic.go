@@ -0,0 +1,194 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// Selector of the field of type A, the field is selected by the function
+// returning the pointer to the field (e.g. `func(u *User) *string { return &u.Name }`).
+type Selector[A any] struct {
+	path fieldPath
+	kind reflect.Type
+}
+
+// Field selects the field of type A
+func Field[A, T any](field func(*A) *T) Selector[A] {
+	return Selector[A]{path: pathOf(field), kind: reflect.TypeOf(new(T)).Elem()}
+}
+
+// Path returns JSONPath of the field of type A (e.g. `$.address.city`), it is
+// type-safe alternative to JSONPath literals used by options (keys of cache
+// and idempotency, correlation, metrics), so that refactoring of fields breaks
+// the build instead of the deployed state machine. The selector returning
+// its argument is the entire value `$`. The path uses JSON names of fields,
+// the pipeline resolves keys derived by Path through the naming policy of its
+// codec (see [runtime.FieldNamer]). Metrics are evaluated by the runtime
+// wrapper over JSON names.
+//
+//	typestep.Path(func(u *User) *string { return &u.ID })
+func Path[A, T any](field func(*A) *T) string {
+	var a A
+	if reflect.TypeOf(new(T)).Elem() == reflect.TypeOf(a) && unsafe.Pointer(field(&a)) == unsafe.Pointer(&a) {
+		return "$"
+	}
+
+	path := pathOf(field)
+	id := reflect.TypeOf(a).String()
+	for _, f := range path {
+		id += "." + f.Name
+	}
+
+	json := "$." + strings.Join(path.names(nil), ".")
+	seq, _ := paths.LoadOrStore(json, &sync.Map{})
+	seq.(*sync.Map).Store(id, path)
+	return json
+}
+
+// paths derived by [Path], indexed by JSONPath
+var paths sync.Map
+
+// path resolves JSONPath derived by [Path] through the naming policy of
+// the pipeline, other paths are used as-is. JSONPath derived from fields
+// named differently by the policy is ambiguous.
+func (ts *typeStep) path(json string) (string, error) {
+	if ts.namer == nil {
+		return json, nil
+	}
+
+	seq, has := paths.Load(json)
+	if !has {
+		return json, nil
+	}
+
+	resolved := ""
+	var err error
+	seq.(*sync.Map).Range(func(_, v any) bool {
+		path := "$." + strings.Join(v.(fieldPath).names(ts.namer), ".")
+		if resolved != "" && resolved != path {
+			err = fmt.Errorf("path %s is ambiguous, it refers %s and %s", json, resolved, path)
+			return false
+		}
+		resolved = path
+		return true
+	})
+
+	return resolved, err
+}
+
+// Format the string from fields of the payload 𝑚: A ⟼ B using intrinsic
+// function `States.Format`, each `{}` of the template is replaced by field.
+//
+//	typestep.Format("order-{}", m, typestep.Field(func(o *Order) *string { return &o.ID }))
+func Format[A, B any](template string, m duct.Morphism[A, B], fields ...Selector[B]) duct.Morphism[A, string] {
+	args := []any{template}
+	for _, f := range fields {
+		args = append(args, f)
+	}
+	return duct.Join(duct.L2[B, string](intrinsic{kind: "Format", fn: "States.Format", args: args}), m)
+}
+
+// ArrayLength of the sequence 𝑚: A ⟼ []B using intrinsic function `States.ArrayLength`.
+func ArrayLength[A, B any](m duct.Morphism[A, []B]) duct.Morphism[A, int] {
+	return duct.Join(duct.L2[[]B, int](intrinsic{kind: "ArrayLength", fn: "States.ArrayLength", args: []any{Selector[[]B]{}}}), m)
+}
+
+// MathAdd adds the value to the number 𝑚: A ⟼ int using intrinsic function `States.MathAdd`.
+func MathAdd[A any](value int, m duct.Morphism[A, int]) duct.Morphism[A, int] {
+	return duct.Join(duct.L2[int, int](intrinsic{kind: "MathAdd", fn: "States.MathAdd", args: []any{Selector[int]{}, value}}), m)
+}
+
+// UUID generates v4 unique identifier using intrinsic function `States.UUID`,
+// the payload of 𝑚: A ⟼ B is discarded.
+func UUID[A, B any](m duct.Morphism[A, B]) duct.Morphism[A, string] {
+	return duct.Join(duct.L2[B, string](intrinsic{kind: "UUID", fn: "States.UUID"}), m)
+}
+
+// Hash of the payload 𝑚: A ⟼ B using intrinsic function `States.Hash`, the
+// algorithm is one of MD5, SHA-1, SHA-256, SHA-384 or SHA-512. Payloads other
+// than string are hashed in JSON.
+func Hash[A, B any](algorithm string, m duct.Morphism[A, B]) duct.Morphism[A, string] {
+	var value any = Selector[B]{}
+	if reflect.TypeOf(new(B)).Elem().Kind() != reflect.String {
+		value = intrinsic{fn: "States.JsonToString", args: []any{value}}
+	}
+	return duct.Join(duct.L2[B, string](intrinsic{kind: "Hash", fn: "States.Hash", args: []any{value, algorithm}}), m)
+}
+
+// intrinsic function applied to the payload, the argument is either literal
+// or the selector of the field relative to the payload.
+type intrinsic struct {
+	kind string
+	fn   string
+	args []any
+}
+
+// expression of intrinsic function over the payload at path
+func (f intrinsic) expression(n runtime.FieldNamer, path string) string {
+	args := make([]string, len(f.args))
+	for i, arg := range f.args {
+		switch v := arg.(type) {
+		case intrinsic:
+			args[i] = v.expression(n, path)
+		case string:
+			args[i] = literal(v)
+		case interface{ selector() fieldPath }:
+			args[i] = strings.Join(append([]string{path}, v.selector().names(n)...), ".")
+		default:
+			args[i] = fmt.Sprint(v)
+		}
+	}
+	return f.fn + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (s Selector[A]) selector() fieldPath { return s.path }
+
+// literal string of intrinsic function, placeholders `{}` are retained
+func literal(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `{`, `\{`, `}`, `\}`)
+	seq := strings.Split(s, "{}")
+	for i := range seq {
+		seq[i] = r.Replace(seq[i])
+	}
+	return "'" + strings.Join(seq, "{}") + "'"
+}
+
+// apply intrinsic function to the payload
+func (ts *typeStep) intrinsic(f intrinsic) {
+	pass := awsstepfunctions.NewPass(ts.scope, jsii.String(ts.idOf(f.kind)),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{
+				"Payload.$": f.expression(ts.namer, ts.args),
+			},
+		},
+	)
+	ts.append(pass)
+}
+
+// shape the payload using JSONata expression
+func (ts *typeStep) shape(kind, expr string) {
+	pass := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String(ts.idOf(kind)),
+		&awsstepfunctions.PassJsonataProps{
+			Outputs: map[string]any{
+				"Payload": expr,
+			},
+		},
+	)
+	ts.append(pass)
+}
@@ -0,0 +1,144 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestIntrinsic(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[Order](event)
+	p2 := typestep.Format("order-{}/{} 'x'", p1,
+		typestep.Field(func(o *Order) *string { return &o.Customer }),
+		typestep.Field(func(o *Order) *int { return &o.Seq }),
+	)
+	p3 := typestep.Hash("SHA-256", p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	q1 := typestep.From[string](event)
+	q2 := typestep.Join(a, q1)
+	q3 := typestep.ArrayLength(q2)
+	q4 := typestep.MathAdd(1, q3)
+	q5 := typestep.Hash("MD5", typestep.UUID(q4))
+	q6 := typestep.ToQueue(queue, q5)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p4)
+	typestep.StateMachine(ts, q6)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"Format0":{"Type":"Pass","Parameters":{"Payload.$":"States.Format('order-{}/{} \\'x\\'', $.detail.customer, $.detail.seq)"},"Next":"Hash0"}`,
+		`"Hash0":{"Type":"Pass","Parameters":{"Payload.$":"States.Hash($.Payload, 'SHA-256')"}`,
+		`"Payload.$":"States.ArrayLength($.Payload)"`,
+		`"Payload.$":"States.MathAdd($.Payload, 1)"`,
+		`"Payload.$":"States.UUID()"`,
+		`"Payload.$":"States.Hash($.Payload, 'MD5')"`,
+		`"MessageBody.$":"$.Payload"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	for expect, path := range map[string]string{
+		"$":               typestep.Path(func(c *Customer) *Customer { return c }),
+		"$.id":            typestep.Path(func(c *Customer) *string { return &c.ID }),
+		"$.location.city": typestep.Path(func(c *Customer) *string { return &c.Location.City }),
+		"$.location":      typestep.Path(func(c *Customer) *Location { return &c.Location }),
+	} {
+		if path != expect {
+			t.Errorf("unexpected path %s, expected %s", path, expect)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("selector of non-field is accepted")
+		}
+	}()
+	typestep.Path(func(c *Customer) *string { return new(string) })
+}
+
+type Parcel struct {
+	OrderID string
+	Carrier string
+}
+
+func TestPathNamer(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("cache"))
+
+	a := typestep.Function_FromFunctionArn[Parcel, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	key := typestep.Path(func(s *Parcel) *string { return &s.OrderID })
+	if key != "$.OrderID" {
+		t.Errorf("unexpected path %s", key)
+	}
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Encoding:       "snake",
+			IdempotencyKey: key,
+			CorrelationKey: typestep.Path(func(s *Parcel) *string { return &s.Carrier }),
+		},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(typestep.Cached(a, table, 1*time.Hour, key),
+				typestep.From[Parcel](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"Name.$":"States.Hash(States.JsonToString($.detail.order_id), 'SHA-256')"`,
+		`"correlation.$":"$.detail.carrier"`,
+		`"key":{"S.$":"States.Format('A/{}', $.Payload.order_id)"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// ErrJobFailed is the error of execution, which job is failed
+const ErrJobFailed = "typestep.JobFailed"
+
+// Status of the job, the job is completed when it is either done or failed.
+type Status[C any] struct {
+	Done  bool   `json:"done,omitempty"`
+	Error string `json:"error,omitempty"`
+	Value C      `json:"value,omitempty"`
+}
+
+// Job integrates the external system without native `.sync` support. The
+// function 𝑓: B ⟼ R submits the job, the function 𝑔: R ⟼ Status[C] polls
+// its status with the interval until the job is done, producing a new
+// morphism 𝑚: A ⟼ C. The execution fails with [ErrJobFailed] if the status
+// of the job has the error.
+//
+//	typestep.Job(Submit, Check, time.Minute, m)
+//
+// The job is polled until the timeout of the state machine unless it is
+// completed.
+func Job[A, B, R, C any](start F[B, R], check F[R, Status[C]], interval time.Duration, m duct.Morphism[A, B]) duct.Morphism[A, C] {
+	f := job{start: newLambda(1, start), check: newLambda(1, check), interval: interval}
+	return duct.Join(duct.L2[B, C](f), m)
+}
+
+type job struct {
+	start    lambda
+	check    lambda
+	interval time.Duration
+}
+
+// job submits the job and polls its status
+//
+//	f ⟼ Wait ⟼ g ⟼ Choice ⟼ (failed) Fail
+//	                      ⟼ (done) Pass
+//	                      ⟼ Wait
+func (ts *typeStep) job(f job) error {
+	if f.interval < time.Second {
+		return fmt.Errorf("job %s is polled with interval %s less than 1s", *f.start.f.Node().Id(), f.interval)
+	}
+
+	id := ts.uuid
+	submit := ts.invoke(f.start,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath: jsii.String(ts.args),
+		},
+	)
+
+	wait := awsstepfunctions.NewWait(ts.scope, jsii.String("JobWait"+id),
+		&awsstepfunctions.WaitProps{
+			Time: awsstepfunctions.WaitTime_Duration(awscdk.Duration_Seconds(jsii.Number(f.interval.Seconds()))),
+		},
+	)
+
+	// Note: the status is the sibling of job reference, which is polled again
+	ts.uuid = ts.unique(*f.check.f.Node().Id())
+	poll := ts.invoke(f.check,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath:      jsii.String("$.Payload"),
+			ResultSelector: &map[string]any{"Payload.$": "$.Payload"},
+			ResultPath:     jsii.String("$.status"),
+		},
+	)
+
+	done := awsstepfunctions.NewPass(ts.scope, jsii.String("JobDone"+id),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{"Payload.$": "$.status.Payload.value"},
+		},
+	)
+
+	status := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String("JobStatus"+id),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $exists($states.input.status.Payload.error) and $states.input.status.Payload.error != '' %}")),
+		ts.reject("Job"+id, "Failed", ErrJobFailed, fmt.Sprintf("job %s is failed", id)),
+		nil,
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $states.input.status.Payload.done = true %}")),
+		done,
+		nil,
+	).Otherwise(wait)
+
+	submit.Next(wait).Next(poll).Next(status)
+
+	ts.appendChain(
+		awsstepfunctions.Chain_Custom(submit, &[]awsstepfunctions.INextable{done}, done),
+		*submit.Node().Id(),
+		5,
+	)
+	return nil
+}
@@ -0,0 +1,65 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type JobRef struct {
+	ID string `json:"id"`
+}
+
+func TestJob(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, JobRef](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[JobRef, typestep.Status[string]](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Job(a, b, time.Minute, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"MapA":{"Next":"JobWaitA"`,
+		`"JobWaitA":{"Type":"Wait","Seconds":60,"Next":"MapB"}`,
+		`"InputPath":"$.Payload","ResultPath":"$.status","ResultSelector":{"Payload.$":"$.Payload"}`,
+		`"Choices":[{"Condition":"{% $exists($states.input.status.Payload.error) and $states.input.status.Payload.error != '' %}","Next":"JobAFailed"},{"Condition":"{% $states.input.status.Payload.done = true %}","Next":"JobDoneA"}],"Default":"JobWaitA"`,
+		`"JobDoneA":{"Type":"Pass","Parameters":{"Payload.$":"$.status.Payload.value"},"Next":"Sink"}`,
+		`"JobAErr":{"Type":"Fail","Error":"typestep.JobFailed"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
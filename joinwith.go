@@ -0,0 +1,121 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Pair of values
+type Pair[A, B any] struct {
+	A A `json:"a"`
+	B B `json:"b"`
+}
+
+// Branch starts the side computation 𝑚: B ⟼ B, which is composed with
+// same combinators as pipelines and joined using [JoinWith].
+func Branch[B any]() duct.Morphism[B, B] {
+	return duct.From(duct.L1[B](inlet{}))
+}
+
+// the source of side computation
+type inlet struct{}
+
+// JoinWith runs the side computation 𝑠: B ⟼ C to obtain C (e.g. enrichment
+// against auxiliary functions), pairs it with B and applies lambda function
+// transformer 𝑓: Pair[B, C] ⟼ D producing a new morphism 𝑚: A ⟼ D.
+// The side computation runs in parallel with identity of B.
+//
+//	side := typestep.Join(GetProfile, typestep.Branch[User]())
+//	b := typestep.JoinWith(Merge, side, a)
+func JoinWith[A, B, C, D any](
+	f F[Pair[B, C], D],
+	side duct.Morphism[B, C],
+	m duct.Morphism[A, B],
+) duct.Morphism[A, D] {
+	fn := newLambda(1, f)
+	return duct.Join(duct.L2[B, D](joinWith{lambda: fn, side: side}), m)
+}
+
+type joinWith struct {
+	lambda
+	side interface{ Apply(duct.Visitor) error }
+}
+
+// branch of the pipeline compiles the side computation into the stack of
+// the builder, the side computation is not the state machine of its own.
+type branch struct{ *typeStep }
+
+func (branch) OnEnterMorphism(depth int, node duct.AstSeq) error { return nil }
+func (branch) OnLeaveMorphism(depth int, node duct.AstSeq) error { return nil }
+func (branch) OnEnterFrom(depth int, node duct.AstFrom) error    { return nil }
+
+// joinWith runs side computation within the parallel state
+//
+//	Parallel ⟼ [ Pass, side... ] ⟼ Pair ⟼ f
+func (ts *typeStep) joinWith(f joinWith) error {
+	id := ts.uuid
+	args := ts.args
+
+	ts.stack = append(ts.stack, nil)
+	ts.names = append(ts.names, "")
+	ts.sizes = append(ts.sizes, 0)
+	ts.paths = append(ts.paths, args)
+	ts.args = "$"
+
+	if err := f.side.Apply(branch{ts}); err != nil {
+		return err
+	}
+
+	last := len(ts.stack) - 1
+	side, size := ts.stack[last], ts.sizes[last]
+	ts.stack = ts.stack[:last]
+	ts.names = ts.names[:last]
+	ts.sizes = ts.sizes[:last]
+	ts.paths = ts.paths[:last]
+
+	// Note: the side computation is identity
+	if side == nil {
+		side = awsstepfunctions.Chain_Start(awsstepfunctions.NewPass(ts.scope, jsii.String("With"+id+"Side"), nil))
+		size = 1
+	}
+
+	parallel := awsstepfunctions.NewParallel(ts.scope, jsii.String("With"+id),
+		&awsstepfunctions.ParallelProps{
+			InputPath: jsii.String(args),
+			ResultSelector: &map[string]any{
+				"Payload": map[string]any{
+					"a.$": "$[0]",
+					"b.$": "$[1]" + strings.TrimPrefix(ts.args, "$"),
+				},
+			},
+		},
+	)
+	parallel.Branch(
+		awsstepfunctions.NewPass(ts.scope, jsii.String("With"+id+"Self"), nil),
+		side,
+	)
+	ts.appendChain(parallel, *parallel.Node().Id(), size+2)
+
+	// Note: steps of the side computation define their own ids
+	ts.uuid = id
+	compute := ts.invoke(f.lambda,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath: jsii.String("$.Payload"),
+		},
+	)
+	ts.append(compute)
+
+	return nil
+}
@@ -0,0 +1,206 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// Package localstack runs integration tests of typestep pipelines against
+// LocalStack, in CI without AWS account. The stack is deployed with
+// `cdklocal deploy`, the test pushes typed events to the pipeline and asserts
+// typed replies of the queue, which is the sink of the pipeline.
+//
+//	cfg, err := localstack.Config(ctx)
+//	machine, err := localstack.StateMachine(ctx, cfg, "PipeStateMachine")
+//
+//	p := localstack.NewPipeline[User, Account](cfg, machine, replyQueueURL)
+//	account, err := p.Execute(ctx, User{ID: "test"})
+//
+// The endpoint of LocalStack is defined by environment variable
+// `LOCALSTACK_ENDPOINT`, default is http://localhost:4566.
+package localstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// Defaults of LocalStack
+const (
+	Endpoint    = "http://localhost:4566"
+	EnvEndpoint = "LOCALSTACK_ENDPOINT"
+	Region      = "us-east-1"
+)
+
+// Config of AWS SDK, which overrides endpoints of services with LocalStack.
+// The region is AWS_REGION, credentials are static test ones.
+func Config(ctx context.Context, opts ...func(*config.LoadOptions) error) (aws.Config, error) {
+	endpoint := os.Getenv(EnvEndpoint)
+	if endpoint == "" {
+		endpoint = Endpoint
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = Region
+	}
+
+	return config.LoadDefaultConfig(ctx,
+		append([]func(*config.LoadOptions) error{
+			config.WithRegion(region),
+			config.WithBaseEndpoint(endpoint),
+			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("test", "test", "")),
+		}, opts...)...,
+	)
+}
+
+//------------------------------------------------------------------------------
+
+type lister interface {
+	ListStateMachines(context.Context, *sfn.ListStateMachinesInput, ...func(*sfn.Options)) (*sfn.ListStateMachinesOutput, error)
+}
+
+// StateMachine looks up ARN of the deployed state machine by prefix of its
+// name, the name is generated by CloudFormation from the logical id of
+// the state machine (e.g. PipeStateMachine).
+func StateMachine(ctx context.Context, cfg aws.Config, prefix string) (string, error) {
+	return stateMachine(ctx, sfn.NewFromConfig(cfg), prefix)
+}
+
+func stateMachine(ctx context.Context, api lister, prefix string) (string, error) {
+	seq := []string{}
+
+	var token *string
+	for {
+		out, err := api.ListStateMachines(ctx, &sfn.ListStateMachinesInput{NextToken: token})
+		if err != nil {
+			return "", fmt.Errorf("typestep failed to list state machines: %w", err)
+		}
+
+		for _, sm := range out.StateMachines {
+			if strings.HasPrefix(aws.ToString(sm.Name), prefix) {
+				seq = append(seq, aws.ToString(sm.StateMachineArn))
+			}
+		}
+
+		if out.NextToken == nil {
+			break
+		}
+		token = out.NextToken
+	}
+
+	if len(seq) != 1 {
+		return "", fmt.Errorf("%d state machines %v matching %q", len(seq), seq, prefix)
+	}
+	return seq[0], nil
+}
+
+//------------------------------------------------------------------------------
+
+type executor interface {
+	StartExecution(context.Context, *sfn.StartExecutionInput, ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error)
+}
+
+type queue interface {
+	ReceiveMessage(context.Context, *sqs.ReceiveMessageInput, ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(context.Context, *sqs.DeleteMessageInput, ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
+// Pipeline is the test harness of the deployed pipeline 𝑚: A ⟼ B, which
+// yields results to the queue.
+type Pipeline[A, B any] struct {
+	machine  string
+	reply    string
+	executor executor
+	queue    queue
+}
+
+// NewPipeline creates the harness of the pipeline, the state machine is ARN
+// of the pipeline, the reply is URL of the queue consumed by assertions.
+func NewPipeline[A, B any](cfg aws.Config, stateMachine, replyQueue string) *Pipeline[A, B] {
+	return &Pipeline[A, B]{
+		machine:  stateMachine,
+		reply:    replyQueue,
+		executor: sfn.NewFromConfig(cfg),
+		queue:    sqs.NewFromConfig(cfg),
+	}
+}
+
+// Push starts the execution of the pipeline with the typed event, the event
+// is the detail of the pipeline's input. It returns ARN of the execution.
+func (p *Pipeline[A, B]) Push(ctx context.Context, a A) (string, error) {
+	input, err := json.Marshal(map[string]any{"detail": a})
+	if err != nil {
+		return "", err
+	}
+
+	out, err := p.executor.StartExecution(ctx,
+		&sfn.StartExecutionInput{
+			StateMachineArn: aws.String(p.machine),
+			Input:           aws.String(string(input)),
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("typestep failed to start execution: %w", err)
+	}
+
+	return aws.ToString(out.ExecutionArn), nil
+}
+
+// Expect receives the typed reply of the pipeline from the queue, it waits
+// until the reply is available or the context is cancelled. The message is
+// deleted once it is decoded.
+func (p *Pipeline[A, B]) Expect(ctx context.Context) (B, error) {
+	var b B
+
+	for {
+		out, err := p.queue.ReceiveMessage(ctx,
+			&sqs.ReceiveMessageInput{
+				QueueUrl:            aws.String(p.reply),
+				MaxNumberOfMessages: 1,
+				WaitTimeSeconds:     1,
+			},
+		)
+		if err != nil {
+			return b, fmt.Errorf("typestep failed to receive reply: %w", err)
+		}
+
+		if len(out.Messages) != 0 {
+			msg := out.Messages[0]
+			if err := json.Unmarshal([]byte(aws.ToString(msg.Body)), &b); err != nil {
+				return b, fmt.Errorf("typestep invalid reply %T: %w", b, err)
+			}
+
+			_, err := p.queue.DeleteMessage(ctx,
+				&sqs.DeleteMessageInput{
+					QueueUrl:      aws.String(p.reply),
+					ReceiptHandle: msg.ReceiptHandle,
+				},
+			)
+			return b, err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return b, fmt.Errorf("typestep reply is not received: %w", err)
+		}
+	}
+}
+
+// Execute pushes the event and expects the reply
+func (p *Pipeline[A, B]) Execute(ctx context.Context, a A) (B, error) {
+	if _, err := p.Push(ctx, a); err != nil {
+		var b B
+		return b, err
+	}
+	return p.Expect(ctx)
+}
@@ -0,0 +1,146 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package localstack
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+type User struct {
+	ID string `json:"id"`
+}
+
+type Account struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type mockLister struct{}
+
+func (mockLister) ListStateMachines(ctx context.Context, in *sfn.ListStateMachinesInput, opts ...func(*sfn.Options)) (*sfn.ListStateMachinesOutput, error) {
+	if in.NextToken == nil {
+		return &sfn.ListStateMachinesOutput{
+			StateMachines: []sfntypes.StateMachineListItem{
+				{Name: aws.String("PipeStateMachine813A825A-abc"), StateMachineArn: aws.String("arn:pipe")},
+				{Name: aws.String("CanaryE1B6D3E4-abc"), StateMachineArn: aws.String("arn:canary")},
+			},
+			NextToken: aws.String("next"),
+		}, nil
+	}
+	return &sfn.ListStateMachinesOutput{
+		StateMachines: []sfntypes.StateMachineListItem{
+			{Name: aws.String("Pipeline2StateMachine1234-abc"), StateMachineArn: aws.String("arn:pipe2")},
+		},
+	}, nil
+}
+
+type mockExecutor struct{ inputs []string }
+
+func (m *mockExecutor) StartExecution(ctx context.Context, in *sfn.StartExecutionInput, opts ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error) {
+	m.inputs = append(m.inputs, aws.ToString(in.Input))
+	return &sfn.StartExecutionOutput{ExecutionArn: aws.String("arn:execution")}, nil
+}
+
+type mockQueue struct {
+	polls   int
+	deleted []string
+}
+
+func (m *mockQueue) ReceiveMessage(ctx context.Context, in *sqs.ReceiveMessageInput, opts ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	m.polls++
+	if m.polls < 3 {
+		return &sqs.ReceiveMessageOutput{}, nil
+	}
+	return &sqs.ReceiveMessageOutput{
+		Messages: []sqstypes.Message{
+			{Body: aws.String(`{"id":"test","name":"Test"}`), ReceiptHandle: aws.String("handle")},
+		},
+	}, nil
+}
+
+func (m *mockQueue) DeleteMessage(ctx context.Context, in *sqs.DeleteMessageInput, opts ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	m.deleted = append(m.deleted, aws.ToString(in.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func TestConfig(t *testing.T) {
+	t.Setenv(EnvEndpoint, "http://localstack:4566")
+	t.Setenv("AWS_REGION", "")
+
+	cfg, err := Config(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if aws.ToString(cfg.BaseEndpoint) != "http://localstack:4566" || cfg.Region != Region {
+		t.Errorf("unexpected config %s %s", aws.ToString(cfg.BaseEndpoint), cfg.Region)
+	}
+
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil || creds.AccessKeyID != "test" {
+		t.Errorf("unexpected credentials %v", err)
+	}
+}
+
+func TestStateMachine(t *testing.T) {
+	arn, err := stateMachine(context.Background(), mockLister{}, "PipeStateMachine")
+	if err != nil || arn != "arn:pipe" {
+		t.Errorf("unexpected state machine %s %v", arn, err)
+	}
+
+	if _, err := stateMachine(context.Background(), mockLister{}, "Pipe"); err == nil {
+		t.Errorf("ambiguous prefix is accepted")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	// GIVEN
+	executor := &mockExecutor{}
+	queue := &mockQueue{}
+	p := &Pipeline[User, Account]{machine: "arn:pipe", reply: "https://sqs", executor: executor, queue: queue}
+
+	// WHEN
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	account, err := p.Execute(ctx, User{ID: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	if len(executor.inputs) != 1 || executor.inputs[0] != `{"detail":{"id":"test"}}` {
+		t.Errorf("unexpected inputs %v", executor.inputs)
+	}
+	if account.ID != "test" || account.Name != "Test" {
+		t.Errorf("unexpected reply %v", account)
+	}
+	if len(queue.deleted) != 1 {
+		t.Errorf("reply is not deleted")
+	}
+}
+
+func TestPipelineTimeout(t *testing.T) {
+	p := &Pipeline[User, Account]{machine: "arn:pipe", reply: "https://sqs", executor: &mockExecutor{}, queue: &mockQueue{polls: -1 << 30}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := p.Expect(ctx); err == nil {
+		t.Errorf("reply is expected after timeout")
+	}
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// ErrLookup is the error of execution, which key is not defined by the table
+// of [Lookup].
+const ErrLookup = "typestep.LookupFailed"
+
+// Lookup maps the key field of the payload 𝑚: A ⟼ B through the static table
+// producing a new morphism 𝑚: A ⟼ V. The table is baked into the definition
+// of state machine, no function or database round-trip is needed. Use it for
+// small, fixed mappings (e.g. country ⟼ region). The execution fails if
+// the key is not defined by the table.
+//
+//	typestep.Lookup(regions, func(u *User) *string { return &u.Country }, m)
+func Lookup[A, B any, K comparable, V any](table map[K]V, key func(*B) *K, m duct.Morphism[A, B]) duct.Morphism[A, V] {
+	raw, err := json.Marshal(table)
+	if err != nil {
+		panic(err)
+	}
+
+	f := lookup{
+		table:   string(raw),
+		path:    pathOf(key),
+		numeric: reflect.TypeOf(new(K)).Elem().Kind() != reflect.String,
+	}
+	return duct.Join(duct.L2[B, V](f), m)
+}
+
+// static mapping of the key, the table is JSON object
+type lookup struct {
+	table   string
+	path    fieldPath
+	numeric bool
+}
+
+// expression of the lookup over the payload at path
+func (f lookup) expression(n runtime.FieldNamer, path string) string {
+	key := "$states.input" + strings.TrimPrefix(path, "$")
+	for _, x := range f.path.names(n) {
+		key += ".`" + x + "`"
+	}
+	if f.numeric {
+		key = "$string(" + key + ")"
+	}
+	return fmt.Sprintf("{%% $lookup(%s, %s) %%}", f.table, key)
+}
+
+// lookup the key within the table
+//
+//	Pass ⟼ Choice ⟼ (undefined) Diagnostic ⟼ DLQ ⟼ Fail
+//	              ⟼ ...
+func (ts *typeStep) lookup(f lookup) {
+	id := ts.idOf("Lookup")
+
+	pass := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctions.PassJsonataProps{
+			Outputs: map[string]any{
+				"Payload": f.expression(ts.namer, ts.args),
+			},
+		},
+	)
+	ts.append(pass)
+
+	check := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(id+"Some"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $not($exists($states.input.Payload)) %}")),
+		ts.reject(id, "Undefined", ErrLookup, "key of "+strings.Join(f.path.names(ts.namer), ".")+" is not defined by "+id),
+		nil,
+	)
+
+	ts.appendChain(
+		check.Afterwards(&awsstepfunctions.AfterwardsOptions{IncludeOtherwise: jsii.Bool(true)}),
+		id+"Some",
+		1,
+	)
+}
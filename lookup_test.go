@@ -0,0 +1,59 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestLookup(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	limits := map[int]string{1: "basic", 2: "premium"}
+
+	// THEN
+	p1 := typestep.From[Order](event)
+	p2 := typestep.Lookup(limits, func(o *Order) *int { return &o.Seq }, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: queue,
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"Lookup0":{"Type":"Pass","QueryLanguage":"JSONata","Output":{"Payload":"{% $lookup({\"1\":\"basic\",\"2\":\"premium\"}, $string($states.input.detail.` + "`seq`" + `)) %}"},"Next":"Lookup0Some"}`,
+		`"Condition":"{% $not($exists($states.input.Payload)) %}","Next":"Lookup0Undefined"`,
+		`"Error":"typestep.LookupFailed"`,
+		`"Next":"Lookup0Try"`,
+		`"MessageBody.$":"$.Payload"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
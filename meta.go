@@ -0,0 +1,80 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// correlate assigns the correlation id of the execution to the variable,
+// either the id of source event, the declared field of the input or the name
+// of execution for windowed source.
+func (ts *typeStep) correlate() {
+	key := "$.id"
+	if ts.windowed != nil {
+		key = "$$.Execution.Name"
+	} else if ts.correlationKey != "" {
+		key = "$.detail" + strings.TrimPrefix(ts.correlationKey, "$")
+	}
+
+	assign := awsstepfunctions.NewPass(ts.scope, jsii.String("Correlation"),
+		&awsstepfunctions.PassProps{
+			Assign: &map[string]any{"correlation.$": key},
+		},
+	)
+	ts.append(assign)
+}
+
+// W3C trace context derived from the execution id
+const traceparent = "{% '00-' & $hash($states.context.Execution.Id, 'MD5') & '-' & $substring($hash($uuid(), 'MD5'), 0, 16) & '-01' %}"
+
+// trace assigns W3C trace context of the execution to the variable. The trace
+// id is derived from the execution id, the parent span is random.
+func (ts *typeStep) trace() {
+	assign := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("Trace"),
+		&awsstepfunctions.PassJsonataProps{
+			Assign: &map[string]any{
+				"traceparent": traceparent,
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+	ts.append(assign)
+}
+
+// envelope of the payload, it injects the workflow metadata alongside the
+// payload for the runtime wrapper. It returns nil if metadata is not required.
+func (ts *typeStep) envelope() awsstepfunctions.TaskInput {
+	meta := map[string]any{}
+	if ts.correlation {
+		meta["correlation.$"] = "$correlation"
+	}
+	if ts.tracing {
+		meta["traceparent.$"] = "$traceparent"
+	}
+	if ts.metadata {
+		meta["execution.$"] = "$$.Execution.Id"
+		meta["state.$"] = "$$.State.Name"
+		meta["attempt.$"] = "$$.State.RetryCount"
+		meta["version"] = ts.version
+	}
+
+	if len(meta) == 0 {
+		return nil
+	}
+
+	return awsstepfunctions.TaskInput_FromObject(&map[string]any{
+		runtime.EnvelopeMeta:           meta,
+		runtime.EnvelopePayload + ".$": "$",
+	})
+}
@@ -0,0 +1,60 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// WithMetric declares business metric of the function 𝑓: A ⟼ B. The runtime
+// wrapper emits the metric as CloudWatch EMF from each invocation. The path is
+// JSONPath within B (e.g. `$.products`, see [Path]), the number is emitted
+// as-is, the length is emitted for arrays (see [runtime.Metric] for details).
+//
+//	typestep.Join(typestep.WithMetric(f, "ProductsPicked", "$"), m)
+func WithMetric[A, B any](f F[A, B], name string, path string) F[A, B] {
+	return withMetric[A, B]{
+		f:      f,
+		metric: runtime.Metric{Name: name, Path: path},
+	}
+}
+
+type withMetric[A, B any] struct {
+	f      F[A, B]
+	metric runtime.Metric
+}
+
+func (c withMetric[A, B]) HKT1(func(A) B)         {}
+func (c withMetric[A, B]) F() awslambda.IFunction { return c.f.F() }
+
+func (c withMetric[A, B]) decorate(fn *lambda) {
+	fn.metrics = append(fn.metrics, c.metric)
+	decorate(c.f, fn)
+}
+
+// metrics configures the runtime wrapper to emit declared metrics
+func (ts *typeStep) metrics(f lambda) {
+	if len(f.metrics) == 0 {
+		return
+	}
+
+	spec, err := json.Marshal(f.metrics)
+	if err != nil {
+		panic(err)
+	}
+
+	ts.setenv(f.f, runtime.EnvMetrics, string(spec))
+	ts.setenv(f.f, runtime.EnvPipeline, ts.name())
+	if ts.metricsNamespace != "" {
+		ts.setenv(f.f, runtime.EnvMetricsNamespace, ts.metricsNamespace)
+	}
+}
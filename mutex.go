@@ -0,0 +1,408 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+const (
+	// detail-type of events queuing executions awaiting the lock of mutex
+	mutexDetailType = "typestep.mutex"
+
+	// the queued execution attempts to acquire the lock again after the wait,
+	// so that the lost wake up (e.g. failure of router) does not stall it.
+	mutexWait = 5 * time.Minute
+)
+
+// Mutex ensures executions with the same business key of the payload
+// 𝑚: A ⟼ B (e.g. account id) pass the step one at a time, so that pipelines
+// mutating per-entity state do not race. The execution acquires the lock of
+// the key, other executions are queued with the callback pattern and resumed
+// one by one. The queued execution attempts to acquire the lock again every
+// 5 minutes. The lock is held until the execution completes with any status,
+// the release is triggered by the status change event of the execution. Locks
+// are items of DynamoDB table defined by the pipeline, the item is removed
+// when the lock is released and no executions are queued. The payload is
+// passed as-is.
+//
+//	typestep.Join(Rebalance, typestep.Mutex(typestep.Field(func(a *Account) *string { return &a.ID }), m))
+//
+// Only standard workflows are supported, status of express workflows is
+// not observable. The mutex is not supported by nested computations.
+func Mutex[A, B any](key Selector[B], m duct.Morphism[A, B]) duct.Morphism[A, B] {
+	f := mutex{path: key.path}
+	return duct.Join(duct.L2[B, B](f), m)
+}
+
+type mutex struct {
+	path  fieldPath
+	id    string
+	table awsdynamodb.ITable
+}
+
+// mutex acquires the lock of the key, the execution is queued if the lock
+// is owned by other execution
+//
+//	PutItem ⟼ UpdateItem ⟼ (locked) PutEvents.waitForTaskToken ⟼ UpdateItem
+//	                                                         ⟼ (timeout) UpdateItem
+//	                     ⟼ ...
+func (ts *typeStep) mutex(f mutex) error {
+	if len(ts.stack) != 1 {
+		return fmt.Errorf("mutex %v is not supported by nested computations", f.path.names(ts.namer))
+	}
+	if ts.express() {
+		return fmt.Errorf("mutex %v is not supported by express workflows", f.path.names(ts.namer))
+	}
+
+	f.id = ts.idOf("Mutex")
+	f.table = awsdynamodb.NewTable(ts.scope, jsii.String(f.id+"Table"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{
+				Name: jsii.String("key"),
+				Type: awsdynamodb.AttributeType_STRING,
+			},
+			BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		},
+	)
+
+	route := ts.name() + f.id
+	key := "{% $string(" + selectorOf("$states.input"+strings.TrimPrefix(ts.args, "$"), f.path.names(ts.namer)) + ") %}"
+
+	// Note: the key of the lock is recorded before the lock is acquired, so
+	// that the lock is released by the status change event of the execution.
+	owner := awsstepfunctionstasks.DynamoPutItem_Jsonata(ts.scope, jsii.String(f.id),
+		&awsstepfunctionstasks.DynamoPutItemJsonataProps{
+			Table: f.table,
+			Item: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key":  awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% 'execution/' & $states.context.Execution.Id %}")),
+				"lock": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String(key)),
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	acquire := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(f.id+"Lock"),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table: f.table,
+			Key: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String(key)),
+			},
+			UpdateExpression:    jsii.String("SET #owner = :execution, #waiters = if_not_exists(#waiters, :empty)"),
+			ConditionExpression: jsii.String("attribute_not_exists(#owner) OR #owner = :execution"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#owner":   jsii.String("owner"),
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":execution": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.context.Execution.Id %}")),
+				":empty":     awsstepfunctionstasks.DynamoAttributeValue_FromList(&[]awsstepfunctionstasks.DynamoAttributeValue{}),
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	queue := awsstepfunctionstasks.EventBridgePutEvents_Jsonata(ts.scope, jsii.String(f.id+"Queue"),
+		&awsstepfunctionstasks.EventBridgePutEventsJsonataProps{
+			IntegrationPattern: awsstepfunctions.IntegrationPattern_WAIT_FOR_TASK_TOKEN,
+			Entries: &[]*awsstepfunctionstasks.EventBridgePutEventsEntry{
+				{
+					Source:     jsii.String("typestep"),
+					DetailType: jsii.String(mutexDetailType),
+					Detail: awsstepfunctions.TaskInput_FromObject(&map[string]any{
+						"mutex": route,
+						"key":   key,
+						"token": "{% $states.context.Task.Token %}",
+					}),
+				},
+			},
+			TaskTimeout: awsstepfunctions.Timeout_Duration(awscdk.Duration_Seconds(jsii.Number(mutexWait.Seconds()))),
+			Outputs:     "{% $states.input %}",
+		},
+	)
+	queue.Next(acquire)
+	ts.sizes[len(ts.sizes)-1] += 1
+
+	// Note: the token of timed out task remains queued, the release skips it.
+	queue.AddCatch(acquire,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("States.Timeout"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	acquire.AddCatch(queue,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	ts.appendChain(owner.Next(acquire), f.id, 2)
+	ts.mutexes = append(ts.mutexes, f)
+	ts.mutexRouter(route, f)
+	return nil
+}
+
+// mutexRouter is the express state machine that queues executions, the
+// execution is resumed immediately if the lock is released meanwhile.
+//
+//	UpdateItem ⟼ (unlocked) SendTaskSuccess
+func (ts *typeStep) mutexRouter(route string, f mutex) {
+	enqueue := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(f.id+"Enqueue"),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table: f.table,
+			Key: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.detail.key %}")),
+			},
+			UpdateExpression:    jsii.String("SET #waiters = list_append(#waiters, :token)"),
+			ConditionExpression: jsii.String("attribute_exists(#owner)"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#owner":   jsii.String("owner"),
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":token": awsstepfunctionstasks.DynamoAttributeValue_FromList(
+					&[]awsstepfunctionstasks.DynamoAttributeValue{
+						awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.detail.token %}")),
+					},
+				),
+			},
+		},
+	)
+
+	transient(enqueue, "DynamoDB.ConditionalCheckFailedException")
+
+	resume := ts.wake(f.id+"Resume", "{% $states.input.detail.token %}")
+	transient(resume, "Sfn.TaskTimedOutException", "Sfn.TaskDoesNotExistException", "Sfn.InvalidTokenException")
+
+	enqueue.AddCatch(resume,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	router := awsstepfunctions.NewStateMachine(ts.scope, jsii.String(f.id+"Router"),
+		&awsstepfunctions.StateMachineProps{
+			StateMachineType: awsstepfunctions.StateMachineType_EXPRESS,
+			DefinitionBody:   awsstepfunctions.ChainDefinitionBody_FromChainable(enqueue),
+		},
+	)
+
+	awsevents.NewRule(ts.scope, jsii.String(f.id+"Registration"),
+		&awsevents.RuleProps{
+			EventPattern: &awsevents.EventPattern{
+				Source:     jsii.Strings("typestep"),
+				DetailType: jsii.Strings(mutexDetailType),
+				Detail:     &map[string]any{"mutex": []string{route}},
+			},
+			Targets: &[]awsevents.IRuleTarget{
+				awseventstargets.NewSfnStateMachine(router, &awseventstargets.SfnStateMachineProps{}),
+			},
+		},
+	)
+}
+
+// wake resumes the queued execution
+func (ts *typeStep) wake(id, token string) awsstepfunctionstasks.CallAwsService {
+	return awsstepfunctionstasks.CallAwsService_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Service: jsii.String("sfn"),
+			Action:  jsii.String("sendTaskSuccess"),
+			Parameters: &map[string]any{
+				"TaskToken": token,
+				"Output":    "{}",
+			},
+			IamResources: jsii.Strings("*"),
+			IamAction:    jsii.String("states:SendTaskSuccess"),
+			Outputs:      "{% $states.input %}",
+		},
+	)
+}
+
+// transient failures of the coordination task are retried. Errors that are
+// the part of the protocol (e.g. failed condition) are not retried, they are
+// dispatched by catch rules of the task.
+func transient(task awsstepfunctions.TaskStateBase, protocol ...string) {
+	if len(protocol) != 0 {
+		task.AddRetry(&awsstepfunctions.RetryProps{
+			Errors:      jsii.Strings(protocol...),
+			MaxAttempts: jsii.Number(0),
+		})
+	}
+	task.AddRetry(&awsstepfunctions.RetryProps{
+		Errors:      jsii.Strings("States.ALL"),
+		MaxAttempts: jsii.Number(3),
+		BackoffRate: jsii.Number(2),
+	})
+}
+
+// unlock releases the lock owned by the execution and resumes the first
+// queued execution, queued executions which are not running are skipped.
+// The lock is removed if no executions are queued.
+//
+//	GetItem ⟼ (owner) UpdateItem ⟼ (queued) SendTaskSuccess ⟼ DeleteItem
+//	                             ⟼ (failed) UpdateItem ⟼ ...
+//	                             ⟼ (empty) DeleteItem ⟼ DeleteItem
+func (ts *typeStep) unlock(f mutex) (awsstepfunctions.IChainable, awsstepfunctions.Pass) {
+	lock := func(value string) *map[string]awsstepfunctionstasks.DynamoAttributeValue {
+		return &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+			"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String(value)),
+		}
+	}
+
+	outputs := map[string]any{
+		"detail": "{% $states.input.detail %}",
+		"lock":   "{% $states.input.lock %}",
+		"token":  "{% $states.result.Attributes.waiters.L[0].S %}",
+	}
+
+	done := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String(f.id+"Released"), &awsstepfunctions.PassJsonataProps{})
+
+	lookup := awsstepfunctionstasks.DynamoGetItem_Jsonata(ts.scope, jsii.String(f.id+"Owner"),
+		&awsstepfunctionstasks.DynamoGetItemJsonataProps{
+			Table:          f.table,
+			Key:            lock("{% 'execution/' & $states.input.detail.executionArn %}"),
+			ConsistentRead: jsii.Bool(true),
+			Outputs: map[string]any{
+				"detail": "{% $states.input.detail %}",
+				"lock":   "{% $states.result.Item.lock.S %}",
+			},
+		},
+	)
+	transient(lookup)
+
+	forget := awsstepfunctionstasks.DynamoDeleteItem_Jsonata(ts.scope, jsii.String(f.id+"Forget"),
+		&awsstepfunctionstasks.DynamoDeleteItemJsonataProps{
+			Table:   f.table,
+			Key:     lock("{% 'execution/' & $states.input.detail.executionArn %}"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+	transient(forget)
+	forget.Next(done)
+
+	// Note: the lock acquired or queued meanwhile is not removed
+	drop := awsstepfunctionstasks.DynamoDeleteItem_Jsonata(ts.scope, jsii.String(f.id+"Drop"),
+		&awsstepfunctionstasks.DynamoDeleteItemJsonataProps{
+			Table:               f.table,
+			Key:                 lock("{% $states.input.lock %}"),
+			ConditionExpression: jsii.String("attribute_not_exists(#owner) AND size(#waiters) = :zero"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#owner":   jsii.String("owner"),
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":zero": awsstepfunctionstasks.DynamoAttributeValue_FromNumber(jsii.Number(0)),
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+	transient(drop, "DynamoDB.ConditionalCheckFailedException")
+	drop.AddCatch(forget,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+	drop.Next(forget)
+
+	unlock := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(f.id+"Unlock"),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table:               f.table,
+			Key:                 lock("{% $states.input.lock %}"),
+			UpdateExpression:    jsii.String("REMOVE #owner, #waiters[0]"),
+			ConditionExpression: jsii.String("#owner = :execution"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#owner":   jsii.String("owner"),
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":execution": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.detail.executionArn %}")),
+			},
+			ReturnValues: awsstepfunctionstasks.DynamoReturnValues_ALL_OLD,
+			Outputs:      outputs,
+		},
+	)
+	transient(unlock, "DynamoDB.ConditionalCheckFailedException")
+	unlock.AddCatch(forget,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	pop := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(f.id+"Next"),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table:               f.table,
+			Key:                 lock("{% $states.input.lock %}"),
+			UpdateExpression:    jsii.String("REMOVE #waiters[0]"),
+			ConditionExpression: jsii.String("attribute_not_exists(#owner) AND size(#waiters) > :zero"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#owner":   jsii.String("owner"),
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":zero": awsstepfunctionstasks.DynamoAttributeValue_FromNumber(jsii.Number(0)),
+			},
+			ReturnValues: awsstepfunctionstasks.DynamoReturnValues_ALL_OLD,
+			Outputs:      outputs,
+		},
+	)
+	transient(pop, "DynamoDB.ConditionalCheckFailedException")
+	pop.AddCatch(drop,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	wake := ts.wake(f.id+"Wake", "{% $states.input.token %}")
+	transient(wake, "Sfn.TaskTimedOutException", "Sfn.TaskDoesNotExistException", "Sfn.InvalidTokenException")
+	wake.AddCatch(pop,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("States.ALL"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+	wake.Next(forget)
+
+	queued := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(f.id+"Queued"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $exists($states.input.token) %}")),
+		wake,
+		nil,
+	).Otherwise(drop)
+	unlock.Next(queued)
+	pop.Next(queued)
+
+	owned := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(f.id+"Owned"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $exists($states.input.lock) %}")),
+		unlock,
+		nil,
+	).Otherwise(done)
+	lookup.Next(owned)
+
+	return lookup, done
+}
@@ -0,0 +1,97 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestMutex(t *testing.T) {
+	type Account struct {
+		ID string `json:"id"`
+	}
+
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[Account, Account](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[Account](event)
+	p2 := typestep.Mutex(typestep.Field(func(a *Account) *string { return &a.ID }), p1)
+	p3 := typestep.Join(a, p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p4)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::DynamoDB::Table"), jsii.Number(1))
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(3))
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"source":      []any{"typestep"},
+				"detail-type": []any{"typestep.mutex"},
+				"detail":      map[string]any{"mutex": []any{"PipeMutex0"}},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"source":      []any{"aws.states"},
+				"detail-type": []any{"Step Functions Execution Status Change"},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		// lock
+		"\"lock\":{\"S\":\"{% $string($states.input.detail.`id`) %}\"}",
+		`"ConditionExpression":"attribute_not_exists(#owner) OR #owner = :execution"`,
+		`"ErrorEquals":["DynamoDB.ConditionalCheckFailedException"],"Next":"Mutex0Queue"`,
+		`"Resource":"arn::states:::events:putEvents.waitForTaskToken"`,
+		`"InputPath":"$.detail"`,
+		// queue
+		`"UpdateExpression":"SET #waiters = list_append(#waiters, :token)"`,
+		// release
+		`"UpdateExpression":"REMOVE #owner, #waiters[0]"`,
+		`"ConditionExpression":"attribute_not_exists(#owner) AND size(#waiters) > :zero"`,
+		`"Resource":"arn::states:::aws-sdk:sfn:sendTaskSuccess"`,
+		// timeout
+		`"Catch":[{"Output":"{% $states.input %}","ErrorEquals":["States.Timeout"],"Next":"Mutex0Lock"}]`,
+		`"TimeoutSeconds":300`,
+		// retry
+		`"Retry":[{"ErrorEquals":["DynamoDB.ConditionalCheckFailedException"],"MaxAttempts":0},{"ErrorEquals":["States.ALL"],"MaxAttempts":3,"BackoffRate":2}]`,
+		`"Retry":[{"ErrorEquals":["Sfn.TaskTimedOutException","Sfn.TaskDoesNotExistException","Sfn.InvalidTokenException"],"MaxAttempts":0},{"ErrorEquals":["States.ALL"],"MaxAttempts":3,"BackoffRate":2}]`,
+		// cleanup
+		`"Default":"Mutex0Drop"`,
+		`"ConditionExpression":"attribute_not_exists(#owner) AND size(#waiters) = :zero"`,
+		`"Catch":[{"Output":"{% $states.input %}","ErrorEquals":["DynamoDB.ConditionalCheckFailedException"],"Next":"Mutex0Drop"}]`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import "reflect"
+
+// Named type declares detail-type of its events, it decouples the wire
+// contract from the name of Go type (e.g. versioned `User.v2`).
+//
+//	func (User) DetailType() string { return "User.v2" }
+type Named interface {
+	DetailType() string
+}
+
+// Naming policy of detail-types consumed (see [From]) and emitted (see
+// [ToEventBus]) by pipelines, it is also used to index schemas of types
+// (see TypeStepProps.SchemaCompatibility). The detail-type is derived from
+// the name of Go type unless the type is [Named] or it is overridden by
+// the policy. Categories declared explicitly at the source or sink are used
+// as-is.
+type Naming struct {
+	// Prefix of the detail-type (e.g. `com.example.`)
+	Prefix string
+
+	// Overrides of the detail-type, indexed by the name of Go type
+	// (e.g. {"User": "Account.v2"}).
+	Overrides map[string]string
+}
+
+var typeNamed = reflect.TypeOf((*Named)(nil)).Elem()
+
+// detailTypeOf derives detail-type of the type according to the policy
+func (ts *typeStep) detailTypeOf(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + ts.detailTypeOf(t.Elem())
+	case reflect.Slice:
+		return "[]" + ts.detailTypeOf(t.Elem())
+	}
+
+	name := t.Name()
+	if t.Implements(typeNamed) {
+		name = reflect.Zero(t).Interface().(Named).DetailType()
+	}
+
+	if ts.naming == nil {
+		return name
+	}
+
+	if alt, has := ts.naming.Overrides[t.Name()]; has {
+		name = alt
+	}
+	return ts.naming.Prefix + name
+}
+
+// contractOf is the name of type's schema. The schema is indexed by Go type
+// unless the naming policy is defined, which keeps schemas of deployed
+// pipelines comparable.
+func (ts *typeStep) contractOf(t reflect.Type) string {
+	if ts.naming == nil && !named(t) {
+		return t.String()
+	}
+	return ts.detailTypeOf(t)
+}
+
+// named checks if the type or its element is [Named]
+func named(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t.Implements(typeNamed)
+}
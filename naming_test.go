@@ -0,0 +1,85 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type Account struct {
+	ID string `json:"id"`
+}
+
+func (Account) DetailType() string { return "Account.v2" }
+
+func TestNaming(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"),
+		&awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("000000000000"),
+				Region:  jsii.String("eu-west-1"),
+			},
+		},
+	)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+
+	a := typestep.Function_FromFunctionArn[Account, User](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			SchemaCompatibility: true,
+			Naming: &typestep.Naming{
+				Prefix:    "com.example.",
+				Overrides: map[string]string{"User": "Person"},
+			},
+		},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToEventBus("test", event,
+			typestep.Join(a,
+				typestep.From[Account](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"detail-type": []string{"com.example.Account.v2"},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::SSM::Parameter"),
+		map[string]any{
+			"Value": assertions.Match_StringLikeRegexp(jsii.String(`"com.example.Person":`)),
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`\"DetailType\": \"com.example.Person\"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// default number of states within the iteration of inline Map
+	defaultInlineStates = 40
+
+	// the budget of states within single definition, it approximates
+	// the definition size quota (1MB) of Step Functions
+	definitionStates = 1000
+
+	// keys of nested state machine input
+	nestedVars    = "vars"
+	nestedPayload = "payload"
+)
+
+// oversized checks if the iteration at the stack level requires decomposition
+func (ts *typeStep) oversized(level int) bool {
+	total := 0
+	for _, n := range ts.sizes {
+		total += n
+	}
+
+	return ts.sizes[level] > ts.inlineStates || total > definitionStates
+}
+
+// nest decomposes the iteration into nested state machine, the returned task
+// starts it synchronously and yields its output. Variables are not visible to
+// the nested state machine, they are passed within its input and restored.
+func (ts *typeStep) nest(id string, chain awsstepfunctions.Chain) awsstepfunctions.IChainable {
+	vars := ts.variables()
+
+	input := awsstepfunctions.TaskInput_FromJsonPathAt(jsii.String("$"))
+	if len(vars) != 0 {
+		pass := map[string]any{}
+		restore := map[string]any{}
+		for _, v := range vars {
+			pass[v+".$"] = "$" + v
+			restore[v] = "{% $states.input." + nestedVars + "." + v + " %}"
+		}
+
+		input = awsstepfunctions.TaskInput_FromObject(&map[string]any{
+			nestedVars:           pass,
+			nestedPayload + ".$": "$",
+		})
+
+		assign := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("Nested"+id+"Vars"),
+			&awsstepfunctions.PassJsonataProps{
+				Assign:  &restore,
+				Outputs: "{% $states.input." + nestedPayload + " %}",
+			},
+		)
+		chain = awsstepfunctions.Chain_Start(assign).Next(chain)
+	}
+
+	nested := awsstepfunctions.NewStateMachine(ts.scope, jsii.String("Nested"+id),
+		&awsstepfunctions.StateMachineProps{
+			DefinitionBody: awsstepfunctions.ChainDefinitionBody_FromChainable(chain),
+		},
+	)
+	ts.machines = append(ts.machines, nested)
+
+	return awsstepfunctionstasks.NewStepFunctionsStartExecution(ts.scope, jsii.String("Nest"+id),
+		&awsstepfunctionstasks.StepFunctionsStartExecutionProps{
+			StateMachine:       nested,
+			IntegrationPattern: awsstepfunctions.IntegrationPattern_RUN_JOB,
+			Input:              input,
+			OutputPath:         jsii.String("$.Output"),
+		},
+	)
+}
+
+// variables of the pipeline
+func (ts *typeStep) variables() []string {
+	vars := []string{}
+	if ts.correlation {
+		vars = append(vars, "correlation")
+	}
+	if ts.tracing {
+		vars = append(vars, "traceparent")
+	}
+	if ts.tenancy != nil {
+		vars = append(vars, varTenant, varTenantConcurrency)
+	}
+	return vars
+}
@@ -0,0 +1,156 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awspipes"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// OutboxAttribute is the attribute of item written by [ToOutbox], it marks
+// items relayed into the bus by the pipeline.
+const OutboxAttribute = "typestep-outbox"
+
+// Yield results of 𝑚: A ⟼ B binding it with the transactional outbox. Each
+// result is written as the item of DynamoDB table, the relay (EventBridge Pipe
+// over DynamoDB Streams) emits the item as the event into the bus. The result
+// is persisted and published atomically, the event is emitted only if the item
+// is written. The table is keyed by the string attribute `key`, its value is
+// the selected field of the result, the stream of the table is required.
+//
+//	typestep.ToOutbox(table, typestep.Field(func(o *Order) *string { return &o.ID }), "order", bus, m)
+//
+// The relay delivers events at least once, including updates of the item.
+func ToOutbox[A, B any](
+	table awsdynamodb.ITable,
+	key Selector[B],
+	source string,
+	bus awsevents.IEventBus,
+	m duct.Morphism[A, B],
+) duct.Morphism[A, duct.Void] {
+	sink := outbox{
+		table:  table,
+		key:    key.path,
+		bus:    bus,
+		source: source,
+		kind:   reflect.TypeOf(new(B)).Elem(),
+	}
+	return duct.Yield(duct.L1[B](sink), m)
+}
+
+// sink of category B into DynamoDB table relayed to AWS EventBridge
+type outbox struct {
+	table  awsdynamodb.ITable
+	key    fieldPath
+	bus    awsevents.IEventBus
+	source string
+	kind   reflect.Type
+}
+
+// outbox writes the value into the table, the relay publishes it
+//
+//	PutItem ⟼ (DynamoDB Streams) Pipe ⟼ EventBridge
+func (ts *typeStep) outbox(f outbox) error {
+	if f.kind.Kind() == reflect.Slice {
+		return fmt.Errorf("outbox sink does not support sequence %s, use Lift", f.kind)
+	}
+
+	if len(f.key) == 0 {
+		return fmt.Errorf("undefined key of outbox item %s", f.kind)
+	}
+
+	if f.table.TableStreamArn() == nil {
+		return fmt.Errorf("outbox table %s has no stream", *f.table.Node().Path())
+	}
+
+	id := ts.unique("Sink")
+	marker := ts.name() + id
+	input := "$states.input" + strings.TrimPrefix(ts.args, "$")
+
+	sink := awsstepfunctionstasks.DynamoPutItem_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.DynamoPutItemJsonataProps{
+			Comment: ts.comment(),
+			Table:   f.table,
+			Item: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key":           awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $string(" + selectorOf(input, f.key.names(ts.namer)) + ") %}")),
+				"detail":        awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $string(" + ts.stamped(input) + ") %}")),
+				OutboxAttribute: awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String(marker)),
+			},
+		},
+	)
+	ts.append(sink)
+
+	return ts.relay(id, f, marker)
+}
+
+// relay items of the outbox into the bus by EventBridge Pipe, items written
+// by other sinks are filtered out by the marker.
+func (ts *typeStep) relay(id string, f outbox, marker string) error {
+	role := awsiam.NewRole(ts.scope, jsii.String(id+"RelayRole"),
+		&awsiam.RoleProps{
+			AssumedBy: awsiam.NewServicePrincipal(jsii.String("pipes.amazonaws.com"), nil),
+		},
+	)
+	f.table.GrantStreamRead(role)
+	role.AddToPolicy(
+		awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+			Actions:   jsii.Strings("events:PutEvents"),
+			Resources: jsii.Strings(*f.bus.EventBusArn()),
+		}),
+	)
+
+	pattern, err := json.Marshal(map[string]any{
+		"eventName": []string{"INSERT", "MODIFY"},
+		"dynamodb": map[string]any{
+			"NewImage": map[string]any{
+				OutboxAttribute: map[string]any{"S": []string{marker}},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	awspipes.NewCfnPipe(ts.scope, jsii.String(id+"Relay"),
+		&awspipes.CfnPipeProps{
+			RoleArn: role.RoleArn(),
+			Source:  f.table.TableStreamArn(),
+			Target:  f.bus.EventBusArn(),
+			SourceParameters: &awspipes.CfnPipe_PipeSourceParametersProperty{
+				DynamoDbStreamParameters: &awspipes.CfnPipe_PipeSourceDynamoDBStreamParametersProperty{
+					StartingPosition: jsii.String("LATEST"),
+				},
+				FilterCriteria: &awspipes.CfnPipe_FilterCriteriaProperty{
+					Filters: &[]any{
+						&awspipes.CfnPipe_FilterProperty{Pattern: jsii.String(string(pattern))},
+					},
+				},
+			},
+			TargetParameters: &awspipes.CfnPipe_PipeTargetParametersProperty{
+				InputTemplate: jsii.String("<$.dynamodb.NewImage.detail.S>"),
+				EventBridgeEventBusParameters: &awspipes.CfnPipe_PipeTargetEventBridgeEventBusParametersProperty{
+					Source:     jsii.String(f.source),
+					DetailType: jsii.String(ts.detailTypeOf(f.kind)),
+				},
+			},
+		},
+	)
+
+	return nil
+}
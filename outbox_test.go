@@ -0,0 +1,118 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestToOutbox(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	table := awsdynamodb.NewTable(stack, jsii.String("Table"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{Name: jsii.String("key"), Type: awsdynamodb.AttributeType_STRING},
+			Stream:       awsdynamodb.StreamViewType_NEW_IMAGE,
+		},
+	)
+
+	a := typestep.Function_FromFunctionArn[string, Order](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToOutbox(table,
+		typestep.Field(func(o *Order) *string { return &o.Customer }),
+		"order", event, p2,
+	)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Pipes::Pipe"),
+		map[string]any{
+			"SourceParameters": map[string]any{
+				"DynamoDBStreamParameters": map[string]any{
+					"StartingPosition": "LATEST",
+				},
+				"FilterCriteria": map[string]any{
+					"Filters": []any{
+						map[string]any{
+							"Pattern": assertions.Match_StringLikeRegexp(jsii.String(typestep.OutboxAttribute)),
+						},
+					},
+				},
+			},
+			"Target": "arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus",
+			"TargetParameters": map[string]any{
+				"InputTemplate": "<$.dynamodb.NewImage.detail.S>",
+				"EventBridgeEventBusParameters": map[string]any{
+					"Source":     "order",
+					"DetailType": "Order",
+				},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`:states:::dynamodb:putItem`,
+		`"key":{"S":"{% $string($states.input.Payload.` + "`customer`" + `) %}"}`,
+		`"typestep-outbox":{"S":"PipeSink"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestToOutboxNoStream(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	table := awsdynamodb.NewTable(stack, jsii.String("Table"),
+		&awsdynamodb.TableProps{
+			PartitionKey: &awsdynamodb.Attribute{Name: jsii.String("key"), Type: awsdynamodb.AttributeType_STRING},
+		},
+	)
+
+	a := typestep.Function_FromFunctionArn[string, Order](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	defer func() {
+		if recover() == nil {
+			t.Errorf("outbox table without stream is not rejected")
+		}
+	}()
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToOutbox(table,
+		typestep.Field(func(o *Order) *string { return &o.Customer }),
+		"order", event, p2,
+	)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+}
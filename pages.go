@@ -0,0 +1,100 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Cursor of the paginated API, the empty token requests the first page.
+type Cursor struct {
+	Token string `json:"token,omitempty"`
+}
+
+// Page of the paginated API, the empty cursor denotes the last page.
+type Page[B any] struct {
+	Items  []B    `json:"items"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// FromPages ingests the paginated API through the iterator function
+// 𝑓: Cursor ⟼ Page[B], starting from the cursor produced by morphism 𝑚.
+// The function is invoked until the page has no cursor, pages are
+// concatenated into the typed sequence 𝑚: A ⟼ []B, which feeds the rest of
+// the pipeline (e.g. use [Lift]). All pages are accumulated within
+// the payload of state machine, which is limited to 256KB.
+//
+//	typestep.Lift(g, typestep.FromPages(ListOrders, m))
+func FromPages[A, B any](f F[Cursor, Page[B]], m duct.Morphism[A, Cursor]) duct.Morphism[A, []B] {
+	fn := newLambda(1, f)
+	return duct.Join(duct.L2[Cursor, []B](pages{lambda: fn}), m)
+}
+
+type pages struct{ lambda }
+
+// pages iterates over the paginated API
+//
+//	Pass ⟼ f ⟼ Append ⟼ Choice ⟼ (cursor) f
+//	                           ⟼ Pass
+func (ts *typeStep) pages(f pages) {
+	uuid := ts.uuid
+
+	init := awsstepfunctions.NewPass(ts.scope, jsii.String("Pages"+uuid),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{
+				"cursor.$": ts.args,
+				"items":    []any{},
+			},
+		},
+	)
+
+	compute := ts.invoke(f.lambda,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath:      jsii.String("$.cursor"),
+			ResultSelector: &map[string]any{"Payload.$": "$.Payload"},
+			ResultPath:     jsii.String("$.page"),
+		},
+	)
+
+	acc := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("PagesAppend"+uuid),
+		&awsstepfunctions.PassJsonataProps{
+			Outputs: map[string]any{
+				"cursor": map[string]any{
+					"token": "{% $states.input.page.Payload.cursor %}",
+				},
+				"items": "{% $append($states.input.items, $states.input.page.Payload.items) %}",
+			},
+		},
+	)
+
+	done := awsstepfunctions.NewPass(ts.scope, jsii.String("PagesDone"+uuid),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{"Payload.$": "$.items"},
+		},
+	)
+
+	next := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String("PagesNext"+uuid),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $exists($states.input.cursor.token) and $states.input.cursor.token != '' %}")),
+		compute,
+		nil,
+	).Otherwise(done)
+
+	init.Next(compute).Next(acc).Next(next)
+
+	ts.appendChain(
+		awsstepfunctions.Chain_Custom(init, &[]awsstepfunctions.INextable{done}, done),
+		"Pages"+uuid,
+		5,
+	)
+}
@@ -0,0 +1,60 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestFromPages(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[typestep.Cursor, typestep.Page[Order]](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[Order, string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[typestep.Cursor](event)
+	p2 := typestep.FromPages(a, p1)
+	p3 := typestep.Lift(b, p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p4)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"PagesA":{"Type":"Pass","Parameters":{"cursor.$":"$.detail","items":[]},"Next":"MapA"}`,
+		`"InputPath":"$.cursor","ResultPath":"$.page","ResultSelector":{"Payload.$":"$.Payload"}`,
+		`"Output":{"cursor":{"token":"{% $states.input.page.Payload.cursor %}"},"items":"{% $append($states.input.items, $states.input.page.Payload.items) %}"}`,
+		`"Choices":[{"Condition":"{% $exists($states.input.cursor.token) and $states.input.cursor.token != '' %}","Next":"MapA"}],"Default":"PagesDoneA"`,
+		`"PagesDoneA":{"Type":"Pass","Parameters":{"Payload.$":"$.items"},"Next":"Some`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
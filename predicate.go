@@ -0,0 +1,172 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// Predicate on the field of event A, it is compiled into content filtering
+// pattern of EventBridge rule. Uninteresting events never start executions.
+//
+// The field is selected by the function returning the pointer to the field
+// (e.g. `func(u *User) *string { return &u.Name }`), fields of nested
+// structs are supported unless they are pointers.
+type Predicate[A any] struct {
+	path    fieldPath
+	pattern any
+}
+
+// Number is a type constraint for numeric fields
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Eq matches events where the field equals the value.
+func Eq[A, T any](field func(*A) *T, value T) Predicate[A] {
+	return Predicate[A]{path: pathOf(field), pattern: value}
+}
+
+// Prefix matches events where the field starts with the prefix.
+func Prefix[A any](field func(*A) *string, prefix string) Predicate[A] {
+	return Predicate[A]{path: pathOf(field), pattern: map[string]any{"prefix": prefix}}
+}
+
+// Exists matches events where the field is present.
+func Exists[A, T any](field func(*A) *T) Predicate[A] {
+	return Predicate[A]{path: pathOf(field), pattern: map[string]any{"exists": true}}
+}
+
+// Numeric matches events where the field compares with the value using
+// the operator (`<`, `<=`, `=`, `>=`, `>`).
+func Numeric[A any, T Number](field func(*A) *T, op string, value T) Predicate[A] {
+	switch op {
+	case "<", "<=", "=", ">=", ">":
+	default:
+		panic(fmt.Errorf("unsupported numeric operator %s", op))
+	}
+	return Predicate[A]{path: pathOf(field), pattern: map[string]any{"numeric": []any{op, value}}}
+}
+
+// Creates new morphism 𝑚, binding it with EventBridge for reading category
+// `A` events, which match predicates. Predicates on distinct fields are
+// conjunctive, predicates on the same field are alternatives.
+func FromWhere[A any](in awsevents.IEventBus, where []Predicate[A], cat ...string) duct.Morphism[A, A] {
+	clauses := make([]clause, len(where))
+	for i, p := range where {
+		clauses[i] = clause{path: p.path, pattern: p.pattern}
+	}
+
+	return duct.From(duct.L1[A](source{cat: cat, bus: in, kind: reflect.TypeOf(new(A)).Elem(), where: clauses}))
+}
+
+// clause of the content filtering pattern
+type clause struct {
+	path    fieldPath
+	pattern any
+}
+
+// detailOf compiles clauses into the content filtering pattern of event detail
+func detailOf(n runtime.FieldNamer, where []clause) map[string]any {
+	detail := map[string]any{}
+	for _, p := range where {
+		path := p.path.names(n)
+
+		at := detail
+		for _, key := range path[:len(path)-1] {
+			next, ok := at[key].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				at[key] = next
+			}
+			at = next
+		}
+
+		key := path[len(path)-1]
+		seq, _ := at[key].([]any)
+		at[key] = append(seq, p.pattern)
+	}
+	return detail
+}
+
+// fieldPath is the path to the field selected by the function, names of
+// fields on the wire are resolved by the pipeline (see [runtime.FieldNamer]).
+type fieldPath []reflect.StructField
+
+// names of fields on the wire, embedded structs are flatten unless
+// they are named by json tag
+func (p fieldPath) names(n runtime.FieldNamer) []string {
+	if p == nil {
+		return nil
+	}
+
+	seq := []string{}
+	for i, f := range p {
+		if i < len(p)-1 && f.Anonymous && f.Tag.Get("json") == "" {
+			continue
+		}
+
+		name := runtime.FieldName(n, f)
+		if name == "" {
+			name = f.Name
+		}
+		seq = append(seq, name)
+	}
+	return seq
+}
+
+// pathOf resolves the path to the field selected by the function
+func pathOf[A, T any](field func(*A) *T) fieldPath {
+	var a A
+	base := uintptr(unsafe.Pointer(&a))
+	addr := uintptr(unsafe.Pointer(field(&a)))
+	if addr < base || addr >= base+unsafe.Sizeof(a) {
+		panic(fmt.Errorf("selector of %T does not refer the field", a))
+	}
+
+	path, ok := fieldAt(reflect.TypeOf(a), addr-base, reflect.TypeOf(new(T)).Elem())
+	if !ok {
+		panic(fmt.Errorf("selector of %T does not refer the field", a))
+	}
+	return path
+}
+
+func fieldAt(t reflect.Type, offset uintptr, kind reflect.Type) (fieldPath, bool) {
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if offset < f.Offset || offset >= f.Offset+f.Type.Size() {
+			continue
+		}
+
+		if offset == f.Offset && f.Type == kind {
+			return fieldPath{f}, true
+		}
+
+		suffix, ok := fieldAt(f.Type, offset-f.Offset, kind)
+		if !ok {
+			continue
+		}
+
+		return append(fieldPath{f}, suffix...), true
+	}
+
+	return nil, false
+}
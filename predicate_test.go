@@ -0,0 +1,85 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type Location struct {
+	Country string `json:"country"`
+	City    string `json:"city,omitempty"`
+}
+
+type Customer struct {
+	ID       string   `json:"id"`
+	Tier     string   `json:"tier"`
+	Score    float64  `json:"score"`
+	Location Location `json:"location"`
+}
+
+func TestFromWhere(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.FromWhere(event,
+				[]typestep.Predicate[Customer]{
+					typestep.Eq(func(c *Customer) *string { return &c.Tier }, "gold"),
+					typestep.Eq(func(c *Customer) *string { return &c.Tier }, "silver"),
+					typestep.Numeric(func(c *Customer) *float64 { return &c.Score }, ">=", 0.5),
+					typestep.Prefix(func(c *Customer) *string { return &c.Location.Country }, "F"),
+					typestep.Exists(func(c *Customer) *string { return &c.Location.City }),
+				},
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"detail-type": []string{"Customer"},
+				"detail": map[string]any{
+					"tier":  []any{"gold", "silver"},
+					"score": []any{map[string]any{"numeric": []any{">=", 0.5}}},
+					"location": map[string]any{
+						"country": []any{map[string]any{"prefix": "F"}},
+						"city":    []any{map[string]any{"exists": true}},
+					},
+				},
+			},
+		},
+	)
+}
+
+func TestFromWhereInvalidSelector(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("selector shall refer the field of event")
+		}
+	}()
+
+	var s string
+	typestep.Exists(func(c *Customer) *string { return &s })
+}
@@ -0,0 +1,213 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"math"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudwatch"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Profile of the environment (e.g. dev, stage, prod), it scales resources of
+// the pipeline from single declaration of the morphism. The profile is applied
+// to all typed functions and state machines of the construct.
+type Profile struct {
+	// Concurrency scales the maximum number of concurrent invocations
+	// declared for nested computations (see [LiftP]).
+	Concurrency float64
+
+	// Batch scales the size of batches declared with [LiftB].
+	Batch float64
+
+	// MemorySize of typed functions deployed by the stack, unless it is
+	// explicitly defined by the function.
+	MemorySize float64
+
+	// LogRetention of state machine execution logs.
+	LogRetention awslogs.RetentionDays
+
+	// LogLevel of state machine execution logs.
+	LogLevel awsstepfunctions.LogLevel
+
+	// Alarms on failed executions of state machine.
+	Alarms bool
+
+	// StepLatency is the threshold of p99 duration of each typed step, it
+	// defines alarms per step, requires Alarms.
+	StepLatency time.Duration
+}
+
+var (
+	// ProfileDev is the profile of development environment
+	ProfileDev = &Profile{
+		Concurrency:  0.1,
+		Batch:        0.1,
+		MemorySize:   128,
+		LogRetention: awslogs.RetentionDays_ONE_DAY,
+		LogLevel:     awsstepfunctions.LogLevel_ALL,
+	}
+
+	// ProfileStage is the profile of staging environment
+	ProfileStage = &Profile{
+		Concurrency:  0.5,
+		Batch:        1,
+		MemorySize:   256,
+		LogRetention: awslogs.RetentionDays_ONE_WEEK,
+		LogLevel:     awsstepfunctions.LogLevel_ERROR,
+		Alarms:       true,
+	}
+
+	// ProfileProd is the profile of production environment
+	ProfileProd = &Profile{
+		Concurrency:  1,
+		Batch:        1,
+		MemorySize:   1024,
+		LogRetention: awslogs.RetentionDays_THREE_MONTHS,
+		LogLevel:     awsstepfunctions.LogLevel_ERROR,
+		Alarms:       true,
+	}
+)
+
+// scale the value by the factor, it is never less than 1
+func scale(x int, factor float64) int {
+	if factor == 0 {
+		return x
+	}
+	return int(math.Max(1, math.Round(float64(x)*factor)))
+}
+
+// concurrency of nested computation scaled by the profile
+func (ts *typeStep) concurrencyOf(n int) int {
+	if ts.profile == nil {
+		return n
+	}
+	return scale(n, ts.profile.Concurrency)
+}
+
+// batch size scaled by the profile
+func (ts *typeStep) batchOf(n int) int {
+	if ts.profile == nil {
+		return n
+	}
+	return scale(n, ts.profile.Batch)
+}
+
+// provision the function deployed by the stack according to the profile
+func (ts *typeStep) provision(f awslambda.IFunction) {
+	if ts.profile == nil || ts.profile.MemorySize == 0 {
+		return
+	}
+
+	fn, ok := f.(awslambda.Function)
+	if !ok {
+		return
+	}
+
+	if cfn, ok := fn.Node().DefaultChild().(awslambda.CfnFunction); ok && cfn.MemorySize() == nil {
+		cfn.SetMemorySize(jsii.Number(ts.profile.MemorySize))
+	}
+}
+
+// logs of state machine according to the profile and the retention policy,
+// nil if logging is not required
+func (ts *typeStep) logs() *awsstepfunctions.LogOptions {
+	level := awsstepfunctions.LogLevel_OFF
+	retention := ts.logRetention
+	if ts.profile != nil && ts.profile.LogLevel != "" {
+		level = ts.profile.LogLevel
+		if retention == "" {
+			retention = ts.profile.LogRetention
+		}
+	}
+
+	if level == awsstepfunctions.LogLevel_OFF && retention == "" {
+		return nil
+	}
+	if level == awsstepfunctions.LogLevel_OFF {
+		level = awsstepfunctions.LogLevel_ERROR
+	}
+	if retention == "" {
+		retention = awslogs.RetentionDays_ONE_WEEK
+	}
+
+	return &awsstepfunctions.LogOptions{
+		Destination: awslogs.NewLogGroup(ts.scope, jsii.String("Logs"),
+			&awslogs.LogGroupProps{
+				Retention: retention,
+			},
+		),
+		Level: level,
+	}
+}
+
+// alarms on state machine according to the profile
+func (ts *typeStep) alarms(states awsstepfunctions.StateMachine) {
+	if ts.profile == nil || !ts.profile.Alarms {
+		return
+	}
+
+	awscloudwatch.NewAlarm(ts.scope, jsii.String("Failed"),
+		&awscloudwatch.AlarmProps{
+			Metric:            states.MetricFailed(nil),
+			Threshold:         jsii.Number(1),
+			EvaluationPeriods: jsii.Number(1),
+			TreatMissingData:  awscloudwatch.TreatMissingData_NOT_BREACHING,
+		},
+	)
+
+	if ts.profile.StepLatency == 0 {
+		return
+	}
+
+	// Note: the duration of step is the worst of the duration observed by
+	// the state machine (scheduling and invocation) and the function
+	for _, step := range ts.latencies {
+		p99 := awscloudwatch.NewMathExpression(
+			&awscloudwatch.MathExpressionProps{
+				Expression: jsii.String("MAX([states, function])"),
+				UsingMetrics: &map[string]awscloudwatch.IMetric{
+					"states": awscloudwatch.NewMetric(
+						&awscloudwatch.MetricProps{
+							Namespace:  jsii.String("AWS/States"),
+							MetricName: jsii.String("LambdaFunctionTime"),
+							DimensionsMap: &map[string]*string{
+								"LambdaFunctionArn": step.f.FunctionArn(),
+							},
+							Statistic: jsii.String("p99"),
+						},
+					),
+					"function": step.f.MetricDuration(
+						&awscloudwatch.MetricOptions{Statistic: jsii.String("p99")},
+					),
+				},
+				Label: jsii.String(step.name + " p99"),
+			},
+		)
+
+		awscloudwatch.NewAlarm(ts.scope, jsii.String("Latency"+step.name),
+			&awscloudwatch.AlarmProps{
+				Metric:            p99,
+				Threshold:         jsii.Number(float64(ts.profile.StepLatency.Milliseconds())),
+				EvaluationPeriods: jsii.Number(1),
+				TreatMissingData:  awscloudwatch.TreatMissingData_NOT_BREACHING,
+			},
+		)
+	}
+}
+
+// latency of the typed step, the function is invoked by the step
+type latency struct {
+	name string
+	f    awslambda.IFunction
+}
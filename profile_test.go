@@ -0,0 +1,142 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/scud"
+	"github.com/fogfish/typestep"
+	"github.com/fogfish/typestep/internal/test"
+)
+
+func TestProfile(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[[]string, []string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(f, p1)
+	p3 := typestep.Join(a, p2)
+	p4 := typestep.LiftB(50, 100, b, p3)
+	p5 := typestep.ToQueue(queue, p4)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Profile: typestep.ProfileDev,
+		},
+	)
+	typestep.StateMachine(ts, p5)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"MemorySize": 128,
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Logs::LogGroup"),
+		map[string]any{
+			"RetentionInDays": 1,
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::StepFunctions::StateMachine"),
+		map[string]any{
+			"LoggingConfiguration": map[string]any{"Level": "ALL"},
+		},
+	)
+	template.ResourceCountIs(jsii.String("AWS::CloudWatch::Alarm"), jsii.Number(0))
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Payload.$":"States.ArrayPartition($.Payload, 10)"`,
+		`"MaxConcurrency":5`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestProfileStepLatency(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Profile: &typestep.Profile{Alarms: true, StepLatency: 2 * time.Second},
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::CloudWatch::Alarm"), jsii.Number(2))
+	template.HasResourceProperties(jsii.String("AWS::CloudWatch::Alarm"),
+		map[string]any{
+			"Threshold": 2000,
+			"Metrics": assertions.Match_ArrayWith(&[]any{
+				map[string]any{
+					"Expression": "MAX([states, function])",
+					"Id":         "expr_1",
+					"Label":      "A p99",
+				},
+				assertions.Match_ObjectLike(&map[string]any{
+					"Id": "states",
+					"MetricStat": assertions.Match_ObjectLike(&map[string]any{
+						"Metric": map[string]any{
+							"Dimensions": []any{
+								map[string]any{"Name": "LambdaFunctionArn", "Value": "arn:aws:lambda:eu-west-1:000000000000:function:my-function"},
+							},
+							"MetricName": "LambdaFunctionTime",
+							"Namespace":  "AWS/States",
+						},
+						"Stat": "p99",
+					}),
+					"ReturnData": false,
+				}),
+			}),
+		},
+	)
+}
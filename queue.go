@@ -0,0 +1,292 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep/runtime"
+)
+
+const (
+	// TagGroup is the value of struct tag `typestep:"group"`, which annotates
+	// the field of MessageGroupId when the sink is FIFO queue.
+	TagGroup = "group"
+
+	// TagDedup is the value of struct tag `typestep:"dedup"`, which annotates
+	// the field of MessageDeduplicationId when the sink is FIFO queue.
+	TagDedup = "dedup"
+
+	// TagAttribute is the value of struct tag `typestep:"attribute"`, which
+	// annotates fields sent as message attributes, named after JSON fields.
+	TagAttribute = "attribute"
+
+	// TaskTokenAttribute is the attribute of acknowledged SQS messages, which
+	// contains the task token of the execution. The consumer acknowledges
+	// processing of the message with SendTaskSuccess (or SendTaskFailure).
+	TaskTokenAttribute = "typestep-task-token"
+
+	// SQS limits
+	maxAttributes = 10
+	maxDelay      = 15 * time.Minute
+
+	defaultAckTimeout = time.Hour
+)
+
+// QueueProps of the sink
+type QueueProps struct {
+	// Delay of the message delivery, up to 15 minutes. FIFO queues do not
+	// support delay of individual messages.
+	Delay time.Duration
+
+	// Acknowledge enables confirmation of delivery, the execution completes
+	// only after the consumer acknowledges processing of the message using
+	// the task token (see [TaskTokenAttribute]).
+	Acknowledge bool
+
+	// AckTimeout is the maximum time to acknowledge the message, default is
+	// 1 hour. The execution fails with `States.Timeout` otherwise.
+	AckTimeout time.Duration
+}
+
+// sink of category B into AWS SQS
+type queue struct {
+	q     awssqs.IQueue
+	kind  reflect.Type
+	delay time.Duration
+	dlq   bool
+	ack   time.Duration
+}
+
+// send creates the task for sending the value at the path into the queue. The
+// message is stamped with version of the pipeline (see [VersionAttribute]),
+// dead-letters are stamped with the execution (see [ExecutionAttribute]).
+// String values are sent as-is, other values are serialized to JSON. Fields
+// annotated with [TagAttribute] are sent as message attributes. FIFO queues
+// require ordering of messages (see [TagGroup], [TagDedup]).
+func (ts *typeStep) send(id string, sink queue, path string) (awsstepfunctionstasks.CallAwsService, error) {
+	q, kind := sink.q, sink.kind
+
+	body := "States.JsonToString(" + path + ")"
+	if kind != nil && kind.Kind() == reflect.String {
+		body = path
+	}
+
+	attributes := map[string]any{
+		VersionAttribute: map[string]any{
+			"DataType":    "String",
+			"StringValue": ts.version,
+		},
+	}
+	if sink.dlq {
+		attributes[ExecutionAttribute] = map[string]any{
+			"DataType":      "String",
+			"StringValue.$": "$$.Execution.Id",
+		}
+	}
+	if sink.ack != 0 {
+		attributes[TaskTokenAttribute] = map[string]any{
+			"DataType":      "String",
+			"StringValue.$": "$$.Task.Token",
+		}
+	}
+	if ts.tenancy != nil {
+		attributes[TenantAttribute] = map[string]any{
+			"DataType":      "String",
+			"StringValue.$": "$" + varTenant,
+		}
+	}
+	for _, f := range fieldsOf(ts.namer, path, kind, TagAttribute) {
+		kind := "String"
+		if f.numeric() {
+			kind = "Number"
+		}
+		attributes[f.name] = map[string]any{
+			"DataType":      kind,
+			"StringValue.$": f.value(),
+		}
+	}
+	if len(attributes) > maxAttributes {
+		return nil, fmt.Errorf("sqs message of %s exceeds %d attributes", kind, maxAttributes)
+	}
+
+	params := map[string]any{
+		"QueueUrl":          q.QueueUrl(),
+		"MessageBody.$":     body,
+		"MessageAttributes": attributes,
+	}
+
+	fifo := q.Fifo() != nil && *q.Fifo()
+
+	if sink.delay != 0 {
+		if fifo {
+			return nil, fmt.Errorf("fifo queue does not support delay of messages")
+		}
+		if sink.delay > maxDelay {
+			return nil, fmt.Errorf("sqs message delay %s exceeds %s", sink.delay, maxDelay)
+		}
+		params["DelaySeconds"] = int(sink.delay.Seconds())
+	}
+
+	if fifo {
+		group, dedup, err := orderOf(ts.namer, path, kind)
+		if err != nil {
+			return nil, err
+		}
+		params["MessageGroupId.$"] = group
+		params["MessageDeduplicationId.$"] = dedup
+	}
+
+	ts.queues = append(ts.queues, q)
+
+	// Note: dead-letters are not described
+	var comment *string
+	if !sink.dlq {
+		comment = ts.comment()
+	}
+
+	props := &awsstepfunctionstasks.CallAwsServiceProps{
+		Comment:      comment,
+		Service:      jsii.String("sqs"),
+		Action:       jsii.String("sendMessage"),
+		Parameters:   &params,
+		IamResources: jsii.Strings(*q.QueueArn()),
+		IamAction:    jsii.String("sqs:SendMessage"),
+	}
+
+	// Note: the execution awaits acknowledgement of the message by the consumer
+	if sink.ack != 0 {
+		props.IntegrationPattern = awsstepfunctions.IntegrationPattern_WAIT_FOR_TASK_TOKEN
+		props.TaskTimeout = awsstepfunctions.Timeout_Duration(awscdk.Duration_Seconds(jsii.Number(sink.ack.Seconds())))
+	}
+
+	return awsstepfunctionstasks.NewCallAwsService(ts.scope, jsii.String(id), props), nil
+}
+
+// orderOf derives MessageGroupId and MessageDeduplicationId of FIFO queue from
+// fields of the type B annotated with `typestep:"group"` and `typestep:"dedup"`.
+// The group is required, the deduplication id defaults to the hash of the
+// message. Messages of untyped states (e.g. dead-letters) are grouped by
+// the execution.
+func orderOf(n runtime.FieldNamer, path string, kind reflect.Type) (string, string, error) {
+	dedup := "States.Hash(States.JsonToString(" + path + "), 'SHA-256')"
+	if kind == nil {
+		return "$$.Execution.Name", dedup, nil
+	}
+
+	group := fieldsOf(n, path, kind, TagGroup)
+	if len(group) == 0 {
+		return "", "", fmt.Errorf("fifo queue requires field of %s annotated with `typestep:\"%s\"`", kind, TagGroup)
+	}
+
+	if seq := fieldsOf(n, path, kind, TagDedup); len(seq) != 0 {
+		dedup = seq[0].value()
+	}
+
+	return group[0].value(), dedup, nil
+}
+
+// field of the type annotated with the tag
+type field struct {
+	name string
+	path string
+	kind reflect.Kind
+}
+
+// numeric field
+func (f field) numeric() bool {
+	switch f.kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// expression of the string value, values other than strings are formatted.
+func (f field) value() string {
+	if f.kind == reflect.String {
+		return f.path
+	}
+	return "States.Format('{}', " + f.path + ")"
+}
+
+// fieldsOf returns fields annotated with the tag, the option is looked up
+// among comma-separated options of `typestep` tag.
+func fieldsOf(n runtime.FieldNamer, path string, t reflect.Type, tag string) []field {
+	return fieldsOfType(n, path, t, tag, map[reflect.Type]bool{})
+}
+
+// fieldsOfType walks the type, recursive types are walked once along the path
+func fieldsOfType(n runtime.FieldNamer, path string, t reflect.Type, tag string, visited map[reflect.Type]bool) []field {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	seq := []field{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := runtime.FieldName(n, f)
+		if name == "-" {
+			continue
+		}
+
+		at := path
+		if !(f.Anonymous && name == "") {
+			if name == "" {
+				name = f.Name
+			}
+			at = path + "." + name
+		}
+
+		if !runtime.HasTag(f, tag) {
+			seq = append(seq, fieldsOfType(n, at, f.Type, tag, visited)...)
+			continue
+		}
+
+		seq = append(seq, field{name: name, path: at, kind: f.Type.Kind()})
+	}
+
+	return seq
+}
+
+// deadLetter sends the state to the dead-letter queue
+func (ts *typeStep) deadLetter(id string) awsstepfunctionstasks.CallAwsService {
+	// Note: untyped messages do not fail ordering of FIFO queue
+	dlq, _ := ts.send(id, queue{q: ts.DeadLetterQueue, dlq: true}, "$")
+	return dlq
+}
+
+// grant the state machine access to queues, including encryption keys
+func (ts *typeStep) grant(states awsstepfunctions.StateMachine) {
+	for _, q := range ts.queues {
+		q.GrantSendMessages(states)
+	}
+}
@@ -0,0 +1,241 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type Order struct {
+	Customer string `json:"customer" typestep:"group"`
+	Seq      int    `json:"seq" typestep:"dedup"`
+}
+
+func TestToQueueFifo(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue.fifo"))
+
+	a := typestep.Function_FromFunctionArn[string, Order](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"MessageGroupId.$":"$.Payload.customer"`,
+		`"MessageDeduplicationId.$":"States.Format('{}', $.Payload.seq)"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestToQueueFifoUngrouped(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue.fifo"))
+
+	a := typestep.Function_FromFunctionArn[string, User](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("fifo queue shall require group of messages")
+		}
+	}()
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+		),
+	)
+}
+
+type Shipment struct {
+	Region   string `json:"region" typestep:"attribute"`
+	Priority int    `json:"priority" typestep:"attribute"`
+	Address  string `json:"address"`
+}
+
+func TestToQueueAttributes(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, Shipment](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+			&typestep.QueueProps{Delay: 5 * time.Minute},
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"DelaySeconds":300`,
+		`"region":{"DataType":"String","StringValue.$":"$.Payload.region"}`,
+		`"priority":{"DataType":"Number","StringValue.$":"States.Format('{}', $.Payload.priority)"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+	if strings.Contains(asl, `"address":{`) {
+		t.Errorf("state machine definition shall not contain attribute address")
+	}
+}
+
+type Ticket struct {
+	Customer string `json:"customer" typestep:"group,attribute"`
+	Seq      int    `json:"seq" typestep:"dedup"`
+}
+
+func TestToQueueFifoAttributes(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue.fifo"))
+
+	a := typestep.Function_FromFunctionArn[string, Ticket](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"MessageGroupId.$":"$.Payload.customer"`,
+		`"customer":{"DataType":"String","StringValue.$":"$.Payload.customer"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+type Tree struct {
+	ID     string `json:"id" typestep:"attribute"`
+	Parent *Tree  `json:"parent,omitempty"`
+}
+
+func TestToQueueRecursive(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.From[Tree](event),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"id":{"DataType":"String","StringValue.$":"$.detail.id"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestToQueueAcknowledge(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(a,
+				typestep.From[string](event),
+			),
+			&typestep.QueueProps{Acknowledge: true, AckTimeout: 10 * time.Minute},
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`:states:::aws-sdk:sqs:sendMessage.waitForTaskToken"`,
+		`"TimeoutSeconds":600`,
+		`"` + typestep.TaskTokenAttribute + `":{"DataType":"String","StringValue.$":"$$.Task.Token"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// ErrQuotaExceeded is the error of execution, which exhausts the daily budget
+// declared by [Quota].
+const ErrQuotaExceeded = "typestep.QuotaExceeded"
+
+// QuotaProps of the budget
+type QuotaProps struct {
+	// Pause the execution until the next day (UTC) when the budget is
+	// exhausted, the execution fails with [ErrQuotaExceeded] otherwise.
+	Pause bool
+}
+
+// Quota guards the payload 𝑚: A ⟼ B by the daily budget of downstream calls
+// (e.g. pay-per-call third-party APIs). The counter of calls is the item of
+// DynamoDB table, which is atomically incremented by each execution passing
+// the step. The table uses string partition key `key`, counters expire via
+// attribute `ttl`. The payload is passed as-is.
+//
+//	typestep.Join(Geocode, typestep.Quota(table, "geocode", 10000, m))
+func Quota[A, B any](table awsdynamodb.ITable, name string, limit int, m duct.Morphism[A, B], opts ...*QuotaProps) duct.Morphism[A, B] {
+	f := quota{table: table, name: name, limit: limit}
+	if len(opts) != 0 && opts[0] != nil {
+		f.pause = opts[0].Pause
+	}
+	return duct.Join(duct.L2[B, B](f), m)
+}
+
+type quota struct {
+	table awsdynamodb.ITable
+	name  string
+	limit int
+	pause bool
+}
+
+// quota increments the counter of the day unless the budget is exhausted
+//
+//	UpdateItem ⟼ (exhausted) Wait ⟼ UpdateItem
+//	           ⟼ (exhausted) Diagnostic ⟼ DLQ ⟼ Fail
+//	           ⟼ ...
+func (ts *typeStep) quota(f quota) {
+	id := ts.idOf("Quota")
+
+	update := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table: f.table,
+			Key: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(
+					jsii.String(fmt.Sprintf("{%% %s & '/' & $substring($now(), 0, 10) %%}", quote(f.name))),
+				),
+			},
+			UpdateExpression:    jsii.String("ADD #calls :one SET #ttl = if_not_exists(#ttl, :ttl)"),
+			ConditionExpression: jsii.String("attribute_not_exists(#calls) OR #calls < :limit"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#calls": jsii.String("calls"),
+				"#ttl":   jsii.String("ttl"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":one":   awsstepfunctionstasks.DynamoAttributeValue_FromNumber(jsii.Number(1)),
+				":limit": awsstepfunctionstasks.DynamoAttributeValue_NumberFromString(jsii.String(strconv.Itoa(f.limit))),
+				":ttl": awsstepfunctionstasks.DynamoAttributeValue_NumberFromString(
+					jsii.String("{% $string($floor($millis() / 1000) + 172800) %}"),
+				),
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	var exhausted awsstepfunctions.IChainable
+	if f.pause {
+		wait := awsstepfunctions.Wait_Jsonata(ts.scope, jsii.String(id+"Wait"),
+			&awsstepfunctions.WaitJsonataProps{
+				Time: awsstepfunctions.WaitTime_Timestamp(
+					jsii.String("{% $fromMillis(($floor($millis() / 86400000) + 1) * 86400000) %}"),
+				),
+			},
+		)
+		wait.Next(update)
+		exhausted = wait
+		ts.sizes[len(ts.sizes)-1] += 1
+	} else {
+		exhausted = ts.reject(id, "Exhausted", ErrQuotaExceeded, "daily budget of "+f.name+" is exhausted")
+	}
+
+	update.AddCatch(exhausted,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+	ts.append(update)
+}
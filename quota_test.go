@@ -0,0 +1,68 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestQuota(t *testing.T) {
+	for _, tc := range []struct {
+		pause  bool
+		expect string
+	}{
+		{pause: false, expect: `"Catch":[{"Output":"{% $states.input %}","ErrorEquals":["DynamoDB.ConditionalCheckFailedException"],"Next":"Quota0Exhausted"}]`},
+		{pause: true, expect: `"Quota0Wait":{"Type":"Wait","QueryLanguage":"JSONata","Timestamp":"{% $fromMillis(($floor($millis() / 86400000) + 1) * 86400000) %}","Next":"Quota0"}`},
+	} {
+		// GIVEN
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+		event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+		table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("quota"))
+
+		a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+			jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+		// THEN
+		p1 := typestep.From[string](event)
+		p2 := typestep.Quota(table, "geocode", 1000, p1, &typestep.QuotaProps{Pause: tc.pause})
+		p3 := typestep.Join(a, p2)
+		p4 := typestep.ToQueue(queue, p3)
+
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+		typestep.StateMachine(ts, p4)
+
+		// WHEN
+		template := assertions.Template_FromStack(stack, nil)
+		asl := definitionOf(template)
+		for _, expect := range []string{
+			`"Resource":"arn::states:::dynamodb:updateItem"`,
+			`"Key":{"key":{"S":"{% \"geocode\" & '/' & $substring($now(), 0, 10) %}"}}`,
+			`"ConditionExpression":"attribute_not_exists(#calls) OR #calls < :limit"`,
+			`":limit":{"N":"1000"}`,
+			`"MapA":{"Next":"Sink","Retry":`,
+			`"InputPath":"$.detail"`,
+			tc.expect,
+		} {
+			if !strings.Contains(asl, expect) {
+				t.Errorf("state machine definition does not contain %s", expect)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Recover is equivalent to Join but substitutes the failure of lambda function
+// 𝑓: B ⟼ C with the default value, the pipeline continues with the degraded
+// output instead of dead-lettering.
+func Recover[A, B, C any](
+	f F[B, C],
+	value C,
+	m duct.Morphism[A, B],
+) duct.Morphism[A, C] {
+	fn := newLambda(1, f)
+	fn.fallback = &fallback{value: value}
+	return duct.Join(duct.L2[B, C](fn), m)
+}
+
+type fallback struct {
+	value any
+}
+
+// recovered step of the state machine is
+//
+//	LambdaInvoke ⟼ (catch) Pass
+//
+// The default value is packed as lambda's response.
+func (ts *typeStep) recovered(f lambda) error {
+	raw, err := json.Marshal(f.fallback.value)
+	if err != nil {
+		return err
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+
+	uuid := ts.uuid
+	compute := ts.invoke(f,
+		&awsstepfunctionstasks.LambdaInvokeProps{
+			InputPath: jsii.String(ts.args),
+		},
+	)
+
+	recover := awsstepfunctions.NewPass(ts.scope, jsii.String("Recover"+uuid),
+		&awsstepfunctions.PassProps{
+			Result: awsstepfunctions.Result_FromObject(&map[string]any{"Payload": value}),
+		},
+	)
+	compute.AddCatch(recover, &awsstepfunctions.CatchProps{})
+
+	ts.appendChain(
+		awsstepfunctions.Chain_Custom(compute, &[]awsstepfunctions.INextable{compute, recover}, compute),
+		*compute.Node().Id(),
+		2,
+	)
+	return nil
+}
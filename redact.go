@@ -0,0 +1,60 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// redact masks fields annotated with `typestep:"pii"` within the input of
+// the function before it is sent to the dead-letter queue. It returns nil if
+// the input has no sensitive fields.
+func (ts *typeStep) redact(id string, input reflect.Type) awsstepfunctions.Pass {
+	paths := runtime.Sensitive(ts.namer, input)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// the location of input within the state, which is JSONPath including `$`
+	root := strings.TrimPrefix(strings.TrimPrefix(ts.args, "$"), ".")
+
+	// JSONata transform operator masks the field if it exists at the location
+	expr := "$states.input"
+	for _, path := range paths {
+		seq := []string{}
+		if root != "" {
+			seq = append(seq, "`"+strings.ReplaceAll(root, ".", "`.`")+"`")
+		}
+		for _, key := range path[:len(path)-1] {
+			if key != "[]" {
+				seq = append(seq, "`"+key+"`")
+			}
+		}
+
+		location := "$"
+		if len(seq) != 0 {
+			location = strings.Join(seq, ".")
+		}
+
+		key := path[len(path)-1]
+		expr += fmt.Sprintf(" ~> |%s|{'%s': $exists(`%s`) ? '%s'}|", location, key, key, runtime.Redacted)
+	}
+
+	return awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("Redact"+id),
+		&awsstepfunctions.PassJsonataProps{
+			Outputs: "{% " + expr + " %}",
+		},
+	)
+}
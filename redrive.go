@@ -0,0 +1,335 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awspipes"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// ExecutionAttribute is the attribute of dead-letter messages, which contains
+// ARN of the failed execution.
+const ExecutionAttribute = "typestep-execution"
+
+// AttemptAttribute is the attribute of dead-letter messages requeued by
+// the redrive, which contains the number of attempts to re-start the failed
+// execution.
+const AttemptAttribute = "typestep-attempt"
+
+// ErrorAttribute is the attribute of parked messages, which contains the
+// reason of parking (e.g. [ErrRedriveExhausted]).
+const ErrorAttribute = "typestep-error"
+
+// ErrRedriveExhausted is the error of redrive, which exhausts attempts to
+// re-start the failed execution.
+const ErrRedriveExhausted = "typestep.RedriveExhausted"
+
+// ErrRedriveMalformed is the error of redrive, which consumes the message
+// not referring the execution of pipelines.
+const ErrRedriveMalformed = "typestep.RedriveMalformed"
+
+// defaults of the redrive
+const (
+	defaultRedriveAttempts = 3
+	defaultRedriveBackoff  = time.Minute
+)
+
+// prefix of executions started by the redrive
+const redrivePrefix = "typestep-redrive-"
+
+// RedriveProps configures the redrive
+type RedriveProps struct {
+	// MaxAttempts to re-start the execution, default is 3.
+	MaxAttempts int
+
+	// Backoff is the delay before the first attempt, it is doubled by each
+	// following attempt. Default is 1 minute.
+	Backoff time.Duration
+
+	// ParkingQueue receives messages which are not redriven, the queue is
+	// created if it is not defined.
+	ParkingQueue awssqs.IQueue
+}
+
+// NewRedrive consumes the dead-letter queue of pipelines and re-starts failed
+// executions with the original input, the attempt n is delayed by backoff·2ⁿ.
+// The execution is re-started synchronously, the failed attempt requeues
+// the message into the dead-letter queue with the counter [AttemptAttribute].
+// Dead-letters of executions started by the redrive are skipped, the redrive
+// owns the failure of its attempt. The message is parked to the parking queue
+// with the attribute [ErrorAttribute] once attempts are exhausted
+// ([ErrRedriveExhausted]) or if it does not refer the execution of pipelines
+// ([ErrRedriveMalformed]), the message is never lost. It gives at-least-once
+// processing with backoff semantic for transient outages of downstream
+// services. Call it after all pipelines are defined.
+//
+// The redrive drains the dead-letter queue, it is not compatible with
+// the backpressure on the depth of dead-letter queue.
+//
+//	typestep.StateMachine(ts, m)
+//	typestep.NewRedrive(ts, &typestep.RedriveProps{MaxAttempts: 5})
+func NewRedrive(ts TypeStep, opts ...*RedriveProps) awsstepfunctions.StateMachine {
+	b := ts.(*typeStep)
+	if b.DeadLetterQueue == nil {
+		panic(fmt.Errorf("redrive requires the dead-letter queue"))
+	}
+	if len(b.pipelines) == 0 {
+		panic(fmt.Errorf("redrive requires the pipeline"))
+	}
+	if b.backpressure != nil && b.backpressure.DeadLetterDepth > 0 {
+		panic(fmt.Errorf("redrive drains the dead-letter queue, it is not compatible with backpressure on dead-letter depth"))
+	}
+
+	attempts, backoff := defaultRedriveAttempts, defaultRedriveBackoff
+	var parking awssqs.IQueue
+	for _, opt := range opts {
+		if opt != nil && opt.MaxAttempts != 0 {
+			attempts = opt.MaxAttempts
+		}
+		if opt != nil && opt.Backoff != 0 {
+			backoff = opt.Backoff
+		}
+		if opt != nil && opt.ParkingQueue != nil {
+			parking = opt.ParkingQueue
+		}
+	}
+
+	if parking == nil {
+		parking = awssqs.NewQueue(b.Construct, jsii.String("RedriveParking"),
+			&awssqs.QueueProps{
+				RetentionPeriod: awscdk.Duration_Days(jsii.Number(14)),
+			},
+		)
+	}
+
+	stack := awscdk.Stack_Of(b.Construct)
+	machines := []*string{}
+	executions := []*string{}
+	for _, states := range b.machines {
+		machines = append(machines, states.StateMachineArn())
+		executions = append(executions, stack.FormatArn(&awscdk.ArnComponents{
+			Service:      jsii.String("states"),
+			Resource:     jsii.String("execution"),
+			ResourceName: jsii.String(*states.StateMachineName() + ":*"),
+			ArnFormat:    awscdk.ArnFormat_COLON_RESOURCE_NAME,
+		}))
+	}
+
+	// Note: the pipe starts redrive with the batch of single message
+	message := "$states.input[0]"
+	execution := message + ".messageAttributes.`" + ExecutionAttribute + "`.stringValue"
+	counter := message + ".messageAttributes.`" + AttemptAttribute + "`.stringValue"
+
+	send := func(id string, q awssqs.IQueue, attrs map[string]any) awsstepfunctionstasks.CallAwsService {
+		task := awsstepfunctionstasks.CallAwsService_Jsonata(b.Construct, jsii.String(id),
+			&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+				Service: jsii.String("sqs"),
+				Action:  jsii.String("sendMessage"),
+				Parameters: &map[string]any{
+					"QueueUrl":          q.QueueUrl(),
+					"MessageBody":       "{% " + message + ".body %}",
+					"MessageAttributes": attrs,
+				},
+				IamResources: &[]*string{q.QueueArn()},
+				IamAction:    jsii.String("sqs:SendMessage"),
+			},
+		)
+		task.AddRetry(&awsstepfunctions.RetryProps{
+			Errors:      jsii.Strings("States.ALL"),
+			MaxAttempts: jsii.Number(3),
+			BackoffRate: jsii.Number(2),
+		})
+		return task
+	}
+
+	origin := map[string]any{
+		"DataType":    "String",
+		"StringValue": "{% " + execution + " %}",
+	}
+
+	malformed := send("RedriveMalformed", parking,
+		map[string]any{
+			ErrorAttribute: map[string]any{"DataType": "String", "StringValue": ErrRedriveMalformed},
+		},
+	)
+
+	exhausted := send("RedriveExhausted", parking,
+		map[string]any{
+			ExecutionAttribute: origin,
+			ErrorAttribute:     map[string]any{"DataType": "String", "StringValue": ErrRedriveExhausted},
+		},
+	)
+
+	// Note: the message refers the original execution, the requeue counts
+	//       the attempt.
+	requeue := send("RedriveRequeue", b.DeadLetterQueue,
+		map[string]any{
+			ExecutionAttribute: origin,
+			AttemptAttribute:   map[string]any{"DataType": "Number", "StringValue": "{% $string($attempt + 1) %}"},
+		},
+	)
+
+	attempt := awsstepfunctions.Pass_Jsonata(b.Construct, jsii.String("RedriveAttempt"),
+		&awsstepfunctions.PassJsonataProps{
+			Assign: &map[string]any{
+				"execution": "{% " + execution + " %}",
+				"attempt":   "{% $exists(" + counter + ") ? $number(" + counter + ") : 0 %}",
+			},
+		},
+	)
+
+	wait := awsstepfunctions.Wait_Jsonata(b.Construct, jsii.String("RedriveBackoff"),
+		&awsstepfunctions.WaitJsonataProps{
+			Time: awsstepfunctions.WaitTime_Seconds(
+				jsii.String(fmt.Sprintf("{%% %d * $power(2, $attempt) %%}", int(backoff.Seconds()))),
+			),
+		},
+	)
+
+	describe := awsstepfunctionstasks.CallAwsService_Jsonata(b.Construct, jsii.String("RedriveDescribe"),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Service: jsii.String("sfn"),
+			Action:  jsii.String("describeExecution"),
+			Parameters: &map[string]any{
+				"ExecutionArn": "{% $execution %}",
+			},
+			IamResources: &executions,
+			IamAction:    jsii.String("states:DescribeExecution"),
+			Outputs:      "{% $states.input %}",
+			Assign: &map[string]any{
+				"machine": "{% $states.result.StateMachineArn %}",
+				"input":   "{% $parse($states.result.Input) %}",
+			},
+		},
+	)
+	describe.AddCatch(malformed,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("Sfn.ExecutionDoesNotExistException", "Sfn.InvalidArnException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+	describe.AddCatch(requeue,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("States.ALL"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	// Note: the task is the custom state, the synchronous start of executions
+	//       is not supported by SDK integrations, the permission is granted
+	//       once the state machine is defined.
+	start := awsstepfunctions.NewCustomState(b.Construct, jsii.String("RedriveStart"),
+		&awsstepfunctions.CustomStateProps{
+			StateJson: &map[string]any{
+				"Type":          "Task",
+				"QueryLanguage": "JSONata",
+				"Resource":      "arn:" + *awscdk.Aws_PARTITION() + ":states:::states:startExecution.sync:2",
+				"Arguments": map[string]any{
+					"StateMachineArn": "{% $machine %}",
+					"Name":            "{% '" + redrivePrefix + "' & $uuid() %}",
+					"Input":           "{% $input %}",
+				},
+				"Output": "{% $states.input %}",
+			},
+		},
+	)
+	start.AddCatch(requeue,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("States.ALL"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	choice := awsstepfunctions.Choice_Jsonata(b.Construct, jsii.String("RedriveCheck"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String(fmt.Sprintf("{%% $attempt >= %d %%}", attempts))),
+		exhausted,
+		nil,
+	).Otherwise(
+		wait.Next(describe).Next(start),
+	)
+
+	// Note: the execution ARN is arn:partition:states:region:account:execution:machine:name
+	guard := awsstepfunctions.Choice_Jsonata(b.Construct, jsii.String("RedriveGuard"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $not($exists("+execution+")) or $count($split("+execution+", ':')) != 8 %}")),
+		malformed,
+		nil,
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String(fmt.Sprintf("{%% $substring($split(%s, ':')[7], 0, %d) = '%s' %%}", execution, len(redrivePrefix), redrivePrefix))),
+		awsstepfunctions.NewSucceed(b.Construct, jsii.String("RedriveSkip"), &awsstepfunctions.SucceedProps{}),
+		nil,
+	).Otherwise(
+		attempt.Next(choice),
+	)
+
+	redrive := awsstepfunctions.NewStateMachine(b.Construct, jsii.String("Redrive"),
+		&awsstepfunctions.StateMachineProps{
+			DefinitionBody: awsstepfunctions.ChainDefinitionBody_FromChainable(
+				awsstepfunctions.Chain_Start(guard),
+			),
+		},
+	)
+	redrive.AddToRolePolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Actions:   jsii.Strings("states:StartExecution"),
+		Resources: &machines,
+	}))
+	redrive.AddToRolePolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Actions:   jsii.Strings("states:DescribeExecution", "states:StopExecution"),
+		Resources: &executions,
+	}))
+	redrive.AddToRolePolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Actions: jsii.Strings("events:PutTargets", "events:PutRule", "events:DescribeRule"),
+		Resources: jsii.Strings(*stack.FormatArn(&awscdk.ArnComponents{
+			Service:      jsii.String("events"),
+			Resource:     jsii.String("rule"),
+			ResourceName: jsii.String("StepFunctionsGetEventsForStepFunctionsExecutionRule"),
+		})),
+	}))
+
+	role := awsiam.NewRole(b.Construct, jsii.String("RedriveRole"),
+		&awsiam.RoleProps{
+			AssumedBy: awsiam.NewServicePrincipal(jsii.String("pipes.amazonaws.com"), nil),
+		},
+	)
+	b.DeadLetterQueue.GrantConsumeMessages(role)
+	redrive.GrantStartExecution(role)
+	parking.GrantSendMessages(redrive)
+	b.DeadLetterQueue.GrantSendMessages(redrive)
+
+	awspipes.NewCfnPipe(b.Construct, jsii.String("RedrivePipe"),
+		&awspipes.CfnPipeProps{
+			RoleArn: role.RoleArn(),
+			Source:  b.DeadLetterQueue.QueueArn(),
+			Target:  redrive.StateMachineArn(),
+			SourceParameters: &awspipes.CfnPipe_PipeSourceParametersProperty{
+				SqsQueueParameters: &awspipes.CfnPipe_PipeSourceSqsQueueParametersProperty{
+					BatchSize: jsii.Number(1),
+				},
+			},
+			TargetParameters: &awspipes.CfnPipe_PipeTargetParametersProperty{
+				StepFunctionStateMachineParameters: &awspipes.CfnPipe_PipeTargetStateMachineParametersProperty{
+					InvocationType: jsii.String("FIRE_AND_FORGET"),
+				},
+			},
+		},
+	)
+
+	return redrive
+}
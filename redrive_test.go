@@ -0,0 +1,190 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestRedrive(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	dlq := awssqs.NewQueue(stack, jsii.String("DLQ"), nil)
+
+	a := typestep.Function_FromFunctionArn[User, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: dlq,
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	typestep.NewRedrive(ts, &typestep.RedriveProps{MaxAttempts: 5, Backoff: 30 * time.Second})
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(2))
+	template.ResourceCountIs(jsii.String("AWS::SQS::Queue"), jsii.Number(2))
+	template.HasResourceProperties(jsii.String("AWS::Pipes::Pipe"),
+		map[string]any{
+			"SourceParameters": map[string]any{
+				"SqsQueueParameters": map[string]any{"BatchSize": 1},
+			},
+			"TargetParameters": map[string]any{
+				"StepFunctionStateMachineParameters": map[string]any{"InvocationType": "FIRE_AND_FORGET"},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"typestep-execution":{"DataType":"String","StringValue.$":"$$.Execution.Id"}`,
+		`{% $attempt >= 5 %}`,
+		`"Seconds":"{% 30 * $power(2, $attempt) %}"`,
+		`"attempt":"{% $exists($states.input[0].messageAttributes.` + "`typestep-attempt`" + `.stringValue) ? $number($states.input[0].messageAttributes.` + "`typestep-attempt`" + `.stringValue) : 0 %}"`,
+		`:states:::aws-sdk:sfn:describeExecution"`,
+		`:states:::states:startExecution.sync:2"`,
+		`"Name":"{% 'typestep-redrive-' & $uuid() %}"`,
+		`{% $substring($split($states.input[0].messageAttributes.` + "`typestep-execution`" + `.stringValue, ':')[7], 0, 17) = 'typestep-redrive-' %}`,
+		`"typestep-attempt":{"DataType":"Number","StringValue":"{% $string($attempt + 1) %}"}`,
+		`"ErrorEquals":["States.ALL"],"Next":"RedriveRequeue"`,
+		`:states:::aws-sdk:sqs:sendMessage"`,
+		`"typestep-error":{"DataType":"String","StringValue":"typestep.RedriveExhausted"}`,
+		`"typestep-error":{"DataType":"String","StringValue":"typestep.RedriveMalformed"}`,
+		`"ErrorEquals":["Sfn.ExecutionDoesNotExistException","Sfn.InvalidArnException"],"Next":"RedriveMalformed"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
+
+func TestRedriveRequiresDeadLetterQueue(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("redrive without dead-letter queue must panic")
+		}
+	}()
+
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.ToQueue(queue, p1)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p2)
+
+	// WHEN
+	typestep.NewRedrive(ts)
+}
+
+func TestRedriveBackpressure(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("redrive with backpressure on dead-letter depth must panic")
+		}
+	}()
+
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	dlq := awssqs.NewQueue(stack, jsii.String("DLQ"), nil)
+
+	p1 := typestep.From[User](event)
+	p2 := typestep.ToQueue(queue, p1)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: dlq,
+			Backpressure:    &typestep.Backpressure{DeadLetterDepth: 10},
+		},
+	)
+	typestep.StateMachine(ts, p2)
+
+	// WHEN
+	typestep.NewRedrive(ts)
+}
+
+func TestRedriveNested(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	dlq := awssqs.NewQueue(stack, jsii.String("DLQ"), nil)
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+	b := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+	c := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("C"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.Lift(b, p2)
+	p4 := typestep.Join(c, p3)
+	p5 := typestep.Unit(p4)
+	p6 := typestep.ToQueue(queue, p5)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: dlq,
+			InlineStates:    1,
+		},
+	)
+	typestep.StateMachine(ts, p6)
+
+	// WHEN
+	typestep.NewRedrive(ts)
+
+	// THEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::IAM::Policy"),
+		map[string]any{
+			"PolicyDocument": map[string]any{
+				"Statement": assertions.Match_ArrayWith(&[]any{
+					map[string]any{
+						"Action": "states:StartExecution",
+						"Effect": "Allow",
+						"Resource": []any{
+							map[string]any{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PipeNested"))},
+							map[string]any{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PipeStateMachine"))},
+						},
+					},
+				}),
+			},
+		},
+	)
+}
@@ -0,0 +1,182 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// TagColumn is the struct tag `redshift:"name"`, which maps the field into
+// the column of the table written by [ToRedshift].
+const TagColumn = "redshift"
+
+// RedshiftTable is the table of Amazon Redshift, either within provisioned
+// cluster or serverless workgroup. The Data API authenticates with the secret
+// or temporary credentials of database user (provisioned cluster only).
+type RedshiftTable struct {
+	Table             string
+	Database          string
+	ClusterIdentifier string
+	WorkgroupName     string
+	Secret            awssecretsmanager.ISecret
+	DbUser            string
+}
+
+// Yield results of 𝑚: A ⟼ B binding it with Amazon Redshift using Data API.
+// Each result is inserted as a row of the table, fields annotated with
+// `redshift:"column"` are columns of the row. The statement is parametrized,
+// values are never interpolated into SQL.
+//
+//	type Order struct {
+//	  ID    string  `json:"id" redshift:"order_id"`
+//	  Price float64 `json:"price" redshift:"price"`
+//	}
+//
+//	typestep.ToRedshift(&typestep.RedshiftTable{Table: "orders", ...}, m)
+func ToRedshift[A, B any](table *RedshiftTable, m duct.Morphism[A, B]) duct.Morphism[A, duct.Void] {
+	kind := reflect.TypeOf(new(B)).Elem()
+	if len(columnsOf(nil, "", kind)) == 0 {
+		panic(fmt.Errorf("type %s has no fields annotated with `%s:\"column\"`", kind, TagColumn))
+	}
+	if (table.ClusterIdentifier == "") == (table.WorkgroupName == "") {
+		panic(fmt.Errorf("redshift table %s requires either cluster or workgroup", table.Table))
+	}
+
+	return duct.Yield(duct.L1[B](redshift{table: table, kind: kind}), m)
+}
+
+// sink of category B into Amazon Redshift
+type redshift struct {
+	table *RedshiftTable
+	kind  reflect.Type
+}
+
+// columnsOf returns fields of the type mapped into columns
+func columnsOf(n runtime.FieldNamer, path string, t reflect.Type) []field {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	seq := []field{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		column := f.Tag.Get(TagColumn)
+		if !f.IsExported() || column == "" || column == "-" {
+			continue
+		}
+
+		name := runtime.FieldName(n, f)
+		if name == "" {
+			name = f.Name
+		}
+		seq = append(seq, field{name: column, path: path + "." + name, kind: f.Type.Kind()})
+	}
+
+	return seq
+}
+
+// insert creates the task for inserting the value at the path into the table
+func (ts *typeStep) insert(f redshift) error {
+	if f.kind.Kind() == reflect.Slice {
+		return fmt.Errorf("redshift sink does not support sequence %s, use Lift", f.kind)
+	}
+
+	columns := columnsOf(ts.namer, ts.args, f.kind)
+	names := make([]string, len(columns))
+	values := make([]string, len(columns))
+	params := make([]any, len(columns))
+	for i, c := range columns {
+		names[i] = `"` + c.name + `"`
+		values[i] = ":" + c.name
+		params[i] = map[string]any{
+			"Name":    c.name,
+			"Value.$": c.value(),
+		}
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		f.table.Table, strings.Join(names, ", "), strings.Join(values, ", "))
+
+	args := map[string]any{
+		"Database":   f.table.Database,
+		"Sql":        sql,
+		"Parameters": params,
+	}
+
+	stack := awscdk.Stack_Of(ts.scope)
+	resource := "*"
+	auth := []awsiam.PolicyStatement{}
+
+	if f.table.ClusterIdentifier != "" {
+		args["ClusterIdentifier"] = f.table.ClusterIdentifier
+		resource = *stack.FormatArn(&awscdk.ArnComponents{
+			Service:      jsii.String("redshift"),
+			Resource:     jsii.String("cluster"),
+			ResourceName: jsii.String(f.table.ClusterIdentifier),
+			ArnFormat:    awscdk.ArnFormat_COLON_RESOURCE_NAME,
+		})
+	}
+	if f.table.WorkgroupName != "" {
+		args["WorkgroupName"] = f.table.WorkgroupName
+	}
+
+	switch {
+	case f.table.Secret != nil:
+		args["SecretArn"] = f.table.Secret.SecretArn()
+		auth = append(auth, awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+			Actions:   jsii.Strings("secretsmanager:GetSecretValue"),
+			Resources: &[]*string{f.table.Secret.SecretArn()},
+		}))
+	case f.table.DbUser != "":
+		args["DbUser"] = f.table.DbUser
+		auth = append(auth, awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+			Actions: jsii.Strings("redshift:GetClusterCredentials"),
+			Resources: jsii.Strings(*stack.FormatArn(&awscdk.ArnComponents{
+				Service:      jsii.String("redshift"),
+				Resource:     jsii.String("dbuser"),
+				ResourceName: jsii.String(f.table.ClusterIdentifier + "/" + f.table.DbUser),
+				ArnFormat:    awscdk.ArnFormat_COLON_RESOURCE_NAME,
+			})),
+		}))
+	default:
+		// Note: serverless workgroup issues temporary credentials of the role
+		auth = append(auth, awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+			Actions:   jsii.Strings("redshift-serverless:GetCredentials"),
+			Resources: jsii.Strings("*"),
+		}))
+	}
+
+	sink := awsstepfunctionstasks.NewCallAwsService(ts.scope, jsii.String(ts.unique("Sink")),
+		&awsstepfunctionstasks.CallAwsServiceProps{
+			Comment:                 ts.comment(),
+			Service:                 jsii.String("redshiftdata"),
+			Action:                  jsii.String("executeStatement"),
+			Parameters:              &args,
+			IamResources:            jsii.Strings(resource),
+			IamAction:               jsii.String("redshift-data:ExecuteStatement"),
+			AdditionalIamStatements: &auth,
+		},
+	)
+	ts.append(sink)
+
+	return nil
+}
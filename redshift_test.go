@@ -0,0 +1,80 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type Sale struct {
+	ID     string  `json:"id" redshift:"sale_id"`
+	Amount float64 `json:"amount" redshift:"amount"`
+	Note   string  `json:"note"`
+}
+
+func TestToRedshift(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+
+	a := typestep.Function_FromFunctionArn[string, Sale](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToRedshift(
+		&typestep.RedshiftTable{Table: "sales", Database: "dev", WorkgroupName: "analytics"},
+		p2,
+	)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Resource":"arn::states:::aws-sdk:redshiftdata:executeStatement"`,
+		`"Sql":"INSERT INTO sales (\"sale_id\", \"amount\") VALUES (:sale_id, :amount)"`,
+		`{"Name":"sale_id","Value.$":"$.Payload.id"}`,
+		`{"Name":"amount","Value.$":"States.Format('{}', $.Payload.amount)"}`,
+		`"WorkgroupName":"analytics"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestToRedshiftNoColumns(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+
+	// THEN
+	defer func() {
+		if recover() == nil {
+			t.Errorf("type without columns is inserted")
+		}
+	}()
+	typestep.ToRedshift(
+		&typestep.RedshiftTable{Table: "sales", Database: "dev", WorkgroupName: "analytics"},
+		typestep.From[string](event),
+	)
+}
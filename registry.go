@@ -0,0 +1,186 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventschemas"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// TypeInfo is the ownership metadata of the type registered by [RegisterType]
+type TypeInfo struct {
+	// Owner of the type (e.g. team).
+	Owner string
+
+	// Description of the type.
+	Description string
+
+	// Deprecated is the reason of deprecation (e.g. the replacement type),
+	// the type is deprecated if it is defined.
+	Deprecated string
+}
+
+// TypeRegistryProps of the registry
+type TypeRegistryProps struct {
+	// RegistryName of the existing EventBridge Schema Registry shared by
+	// stacks, the registry is defined by the construct unless it is given.
+	// The shared registry is referenced only, schemas are published by
+	// the stack owning the registry, [RegisterType] declares the metadata
+	// of types consumed by pipelines of the stack.
+	RegistryName string
+
+	// Encoding of types, schemas respect the field naming policy of the codec
+	// (see [Encoding]), JSON is used by default.
+	Encoding Encoding
+}
+
+// TypeRegistry is the central registry of types used at boundaries of
+// pipelines, it is backed by EventBridge Schema Registry. Types are recorded
+// as JSON Schema with the owner, the description and the deprecation status.
+// Pipelines declaring the registry (see TypeStepProps.Registry) warn at synth
+// if they consume deprecated types. Warnings are local to the app, the status
+// is the metadata declared by [RegisterType] and not read from the registry.
+type TypeRegistry struct {
+	constructs.Construct
+	RegistryName *string
+	types        map[string]TypeInfo
+	namer        runtime.FieldNamer
+	shared       bool
+}
+
+// NewTypeRegistry defines the registry of types
+func NewTypeRegistry(scope constructs.Construct, id *string, props *TypeRegistryProps) *TypeRegistry {
+	r := &TypeRegistry{
+		Construct: constructs.NewConstruct(scope, id),
+		types:     map[string]TypeInfo{},
+		namer:     runtime.FieldNamerOf(string(props.Encoding)),
+	}
+
+	if props.RegistryName != "" {
+		r.RegistryName = jsii.String(props.RegistryName)
+		r.shared = true
+		return r
+	}
+
+	registry := awseventschemas.NewCfnRegistry(r.Construct, jsii.String("Registry"),
+		&awseventschemas.CfnRegistryProps{
+			Description: jsii.String("typestep types"),
+		},
+	)
+	r.RegistryName = registry.AttrRegistryName()
+	return r
+}
+
+// RegisterType records the type T with its ownership metadata into the registry.
+// The schema is not published into the shared registry (see RegistryName).
+//
+//	typestep.RegisterType[Order](registry, typestep.TypeInfo{Owner: "billing"})
+func RegisterType[T any](r *TypeRegistry, info TypeInfo) {
+	name := baseTypeOf(duct.TypeOf[T]())
+	if _, has := r.types[name]; has {
+		panic(fmt.Errorf("type %s is registered twice", name))
+	}
+	r.types[name] = info
+
+	if r.shared {
+		return
+	}
+
+	schema := schemaOf(r.namer, reflect.TypeOf(new(T)).Elem(), map[reflect.Type]bool{})
+	schema["$schema"] = "http://json-schema.org/draft-04/schema#"
+	schema["title"] = name
+	if info.Description != "" {
+		schema["description"] = info.Description
+	}
+	content, err := json.Marshal(schema)
+	if err != nil {
+		panic(err)
+	}
+
+	tags := []*awseventschemas.CfnSchema_TagsEntryProperty{}
+	if info.Owner != "" {
+		tags = append(tags, &awseventschemas.CfnSchema_TagsEntryProperty{Key: jsii.String("typestep:owner"), Value: jsii.String(info.Owner)})
+	}
+	if info.Deprecated != "" {
+		tags = append(tags, &awseventschemas.CfnSchema_TagsEntryProperty{Key: jsii.String("typestep:deprecated"), Value: jsii.String(info.Deprecated)})
+	}
+
+	var description *string
+	if info.Description != "" {
+		description = jsii.String(info.Description)
+	}
+
+	awseventschemas.NewCfnSchema(r.Construct, jsii.String(schemaNameOf(name)),
+		&awseventschemas.CfnSchemaProps{
+			RegistryName: r.RegistryName,
+			SchemaName:   jsii.String(schemaNameOf(name)),
+			Type:         jsii.String("JSONSchemaDraft4"),
+			Content:      jsii.String(string(content)),
+			Description:  description,
+			Tags:         &tags,
+		},
+	)
+}
+
+// baseTypeOf strips pointers and sequences of the type name
+func baseTypeOf(name string) string {
+	return strings.TrimLeft(name, "*[]")
+}
+
+// schemaNameOf the type, generic types are named with brackets and commas,
+// which are not permitted by names of schemas
+func schemaNameOf(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		default:
+			return '.'
+		}
+	}, name)
+}
+
+// deprecations warns about deprecated types consumed by the pipeline, either
+// by the source or by steps.
+func (ts *typeStep) deprecations(catalog *Catalog) {
+	if ts.registry == nil {
+		return
+	}
+
+	consumed := []string{}
+	for _, source := range catalog.Sources {
+		consumed = append(consumed, source.Type)
+	}
+	for _, step := range catalog.Steps {
+		consumed = append(consumed, step.Input)
+	}
+
+	seen := map[string]bool{}
+	for _, t := range consumed {
+		name := baseTypeOf(t)
+		info, has := ts.registry.types[name]
+		if !has || info.Deprecated == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		awscdk.Annotations_Of(ts.scope).AddWarning(
+			jsii.String(fmt.Sprintf("typestep pipeline consumes deprecated type %s: %s", name, info.Deprecated)),
+		)
+	}
+}
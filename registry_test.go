@@ -0,0 +1,101 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestTypeRegistry(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	registry := typestep.NewTypeRegistry(stack, jsii.String("Types"), &typestep.TypeRegistryProps{})
+	typestep.RegisterType[User](registry, typestep.TypeInfo{Owner: "identity", Deprecated: "use Contact"})
+	typestep.RegisterType[Contact](registry, typestep.TypeInfo{Owner: "identity", Description: "contact of user"})
+
+	a := typestep.Function_FromFunctionArn[User, Contact](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{Registry: registry},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(a,
+				typestep.From[User](event),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::EventSchemas::Registry"), jsii.Number(1))
+	template.HasResourceProperties(jsii.String("AWS::EventSchemas::Schema"),
+		map[string]any{
+			"SchemaName":  "Contact",
+			"Type":        "JSONSchemaDraft4",
+			"Description": "contact of user",
+			"Tags": []any{
+				map[string]any{"Key": "typestep:owner", "Value": "identity"},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::EventSchemas::Schema"),
+		map[string]any{
+			"SchemaName": "User",
+			"Tags": assertions.Match_ArrayWith(&[]any{
+				map[string]any{"Key": "typestep:deprecated", "Value": "use Contact"},
+			}),
+		},
+	)
+
+	annotations := assertions.Annotations_FromStack(stack)
+	annotations.HasWarning(jsii.String("*"), assertions.Match_StringLikeRegexp(jsii.String("typestep pipeline consumes deprecated type User: use Contact")))
+}
+
+func TestTypeRegistryShared(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	registry := typestep.NewTypeRegistry(stack, jsii.String("Types"), &typestep.TypeRegistryProps{RegistryName: "types"})
+	typestep.RegisterType[User](registry, typestep.TypeInfo{Owner: "identity", Deprecated: "use Contact"})
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{Registry: registry},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.From[User](event),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::EventSchemas::Registry"), jsii.Number(0))
+	template.ResourceCountIs(jsii.String("AWS::EventSchemas::Schema"), jsii.Number(0))
+
+	annotations := assertions.Annotations_FromStack(stack)
+	annotations.HasWarning(jsii.String("*"), assertions.Match_StringLikeRegexp(jsii.String("typestep pipeline consumes deprecated type User: use Contact")))
+}
@@ -0,0 +1,174 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// Package replay re-runs executions of typestep pipelines from the audit
+// archive (see TypeStepProps.Audit). The execution resumes from the archived
+// value of the chosen step with the original typed data, the pipeline runs
+// the suffix following the step. Use it after fixing a buggy step.
+//
+//	r := replay.New(cfg, "my-bucket", "Pipe", "arn:aws:states:...")
+//	steps, err := r.Steps(ctx, "execution-name")
+//	arn, err := r.Resume(ctx, "execution-name", "Source")
+//
+// Only top level steps are resumable, values of nested computations are not.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+)
+
+// Source is the step of the pipeline's input
+const Source = "Source"
+
+type storage interface {
+	ListObjectsV2(context.Context, *s3.ListObjectsV2Input, ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+type executor interface {
+	StartExecution(context.Context, *sfn.StartExecutionInput, ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error)
+}
+
+// Replay of pipeline executions
+type Replay struct {
+	bucket   string
+	pipeline string
+	machine  string
+	storage  storage
+	executor executor
+}
+
+// New creates replay of the pipeline, which is archived into the bucket.
+// The pipeline is the name of the pipeline (id of TypeStep construct) and
+// the state machine is ARN of the pipeline.
+func New(cfg aws.Config, bucket, pipeline, stateMachine string) *Replay {
+	return &Replay{
+		bucket:   bucket,
+		pipeline: pipeline,
+		machine:  stateMachine,
+		storage:  s3.NewFromConfig(cfg),
+		executor: sfn.NewFromConfig(cfg),
+	}
+}
+
+func (r *Replay) prefix(execution string) string {
+	return "typestep/" + r.pipeline + "/" + execution + "/"
+}
+
+// Steps lists archived steps of the execution
+func (r *Replay) Steps(ctx context.Context, execution string) ([]string, error) {
+	keys, err := r.keys(ctx, r.prefix(execution))
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[string]struct{}{}
+	for _, key := range keys {
+		step, _, _ := strings.Cut(strings.TrimPrefix(key, r.prefix(execution)), "/")
+		set[step] = struct{}{}
+	}
+
+	steps := make([]string, 0, len(set))
+	for step := range set {
+		steps = append(steps, step)
+	}
+	sort.Strings(steps)
+
+	return steps, nil
+}
+
+// Resume starts new execution of the pipeline from the archived value of
+// the step, it returns ARN of the execution.
+func (r *Replay) Resume(ctx context.Context, execution, step string) (string, error) {
+	keys, err := r.keys(ctx, r.prefix(execution)+step+"/")
+	if err != nil {
+		return "", err
+	}
+
+	if len(keys) != 1 {
+		return "", fmt.Errorf("step %s of %s has %d archived values, only top level steps are resumable", step, execution, len(keys))
+	}
+
+	obj, err := r.storage.GetObject(ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(r.bucket),
+			Key:    aws.String(keys[0]),
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("typestep failed to read archive: %w", err)
+	}
+	defer obj.Body.Close()
+
+	value, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return "", fmt.Errorf("typestep failed to read archive: %w", err)
+	}
+
+	// Note: the input of source is the detail of event, the output of the
+	// lambda function is packed into Payload.
+	key := "Payload"
+	if step == Source {
+		key = "detail"
+	}
+
+	input, err := json.Marshal(map[string]any{
+		"replay": map[string]string{"step": step, "execution": execution},
+		key:      json.RawMessage(value),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	out, err := r.executor.StartExecution(ctx,
+		&sfn.StartExecutionInput{
+			StateMachineArn: aws.String(r.machine),
+			Input:           aws.String(string(input)),
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("typestep failed to start execution: %w", err)
+	}
+
+	return aws.ToString(out.ExecutionArn), nil
+}
+
+func (r *Replay) keys(ctx context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	var token *string
+	for {
+		out, err := r.storage.ListObjectsV2(ctx,
+			&s3.ListObjectsV2Input{
+				Bucket:            aws.String(r.bucket),
+				Prefix:            aws.String(prefix),
+				ContinuationToken: token,
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("typestep failed to list archive: %w", err)
+		}
+
+		for _, obj := range out.Contents {
+			keys = append(keys, aws.ToString(obj.Key))
+		}
+
+		if !aws.ToBool(out.IsTruncated) {
+			return keys, nil
+		}
+		token = out.NextContinuationToken
+	}
+}
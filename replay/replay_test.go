@@ -0,0 +1,98 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package replay
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+)
+
+type mockStorage map[string]string
+
+func (m mockStorage) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, opts ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	seq := []types.Object{}
+	for key := range m {
+		if strings.HasPrefix(key, aws.ToString(in.Prefix)) {
+			seq = append(seq, types.Object{Key: aws.String(key)})
+		}
+	}
+	return &s3.ListObjectsV2Output{Contents: seq}, nil
+}
+
+func (m mockStorage) GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewBufferString(m[aws.ToString(in.Key)]))}, nil
+}
+
+type mockExecutor struct{ input string }
+
+func (m *mockExecutor) StartExecution(ctx context.Context, in *sfn.StartExecutionInput, opts ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error) {
+	m.input = aws.ToString(in.Input)
+	return &sfn.StartExecutionOutput{ExecutionArn: aws.String("arn")}, nil
+}
+
+func TestReplay(t *testing.T) {
+	// GIVEN
+	executor := &mockExecutor{}
+	r := &Replay{
+		bucket:   "bucket",
+		pipeline: "Pipe",
+		machine:  "arn:aws:states:eu-west-1:000000000000:stateMachine:my",
+		storage: mockStorage{
+			"typestep/Pipe/ex/Source/1.json": `"abc"`,
+			"typestep/Pipe/ex/A/2.json":      `{"id":"abc"}`,
+			"typestep/Pipe/ex/B/3.json":      `"x"`,
+			"typestep/Pipe/ex/B/4.json":      `"y"`,
+		},
+		executor: executor,
+	}
+
+	// WHEN
+	steps, err := r.Steps(context.Background(), "ex")
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(steps, ",") != "A,B,Source" {
+		t.Errorf("unexpected steps %v", steps)
+	}
+
+	// WHEN
+	if _, err := r.Resume(context.Background(), "ex", "A"); err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	if executor.input != `{"Payload":{"id":"abc"},"replay":{"execution":"ex","step":"A"}}` {
+		t.Errorf("unexpected input %s", executor.input)
+	}
+
+	// WHEN
+	if _, err := r.Resume(context.Background(), "ex", "Source"); err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	if executor.input != `{"detail":"abc","replay":{"execution":"ex","step":"Source"}}` {
+		t.Errorf("unexpected input %s", executor.input)
+	}
+
+	// WHEN
+	if _, err := r.Resume(context.Background(), "ex", "B"); err == nil {
+		t.Errorf("nested step is resumed")
+	}
+}
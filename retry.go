@@ -0,0 +1,63 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// errors retried by default
+var retryErrors = []string{
+	"Lambda.ServiceException",
+	"Lambda.TooManyRequestsException",
+	"States.TaskFailed",
+}
+
+// WithRetry overrides the retry policy of the function 𝑓: A ⟼ B declared by
+// TypeStepProps.Retry. The policy retries `Lambda.ServiceException`,
+// `Lambda.TooManyRequestsException` and `States.TaskFailed` unless errors
+// are explicitly defined.
+//
+//	typestep.Join(typestep.WithRetry(f, &awsstepfunctions.RetryProps{MaxAttempts: jsii.Number(5)}), m)
+func WithRetry[A, B any](f F[A, B], retry *awsstepfunctions.RetryProps) F[A, B] {
+	return withRetry[A, B]{f: f, retry: retry}
+}
+
+type withRetry[A, B any] struct {
+	f     F[A, B]
+	retry *awsstepfunctions.RetryProps
+}
+
+func (c withRetry[A, B]) HKT1(func(A) B)         {}
+func (c withRetry[A, B]) F() awslambda.IFunction { return c.f.F() }
+
+func (c withRetry[A, B]) decorate(fn *lambda) {
+	fn.retry = c.retry
+	decorate(c.f, fn)
+}
+
+// retryOf returns the retry policy of the function, nil if it is not defined
+func (ts *typeStep) retryOf(f lambda) *awsstepfunctions.RetryProps {
+	retry := ts.retry
+	if f.retry != nil {
+		retry = f.retry
+	}
+	if retry == nil {
+		return nil
+	}
+
+	if retry.Errors == nil {
+		policy := *retry
+		policy.Errors = jsii.Strings(retryErrors...)
+		retry = &policy
+	}
+	return retry
+}
@@ -0,0 +1,75 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestRetry(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Retry: &awsstepfunctions.RetryProps{
+				MaxAttempts: jsii.Number(3),
+				Interval:    awscdk.Duration_Seconds(jsii.Number(2)),
+				BackoffRate: jsii.Number(2),
+			},
+		},
+	)
+	typestep.StateMachine(ts,
+		typestep.ToQueue(queue,
+			typestep.Join(
+				typestep.WithRetry(b,
+					&awsstepfunctions.RetryProps{
+						Errors:      jsii.Strings("States.Timeout"),
+						MaxAttempts: jsii.Number(5),
+					},
+				),
+				typestep.Join(a,
+					typestep.From[string](event),
+				),
+			),
+		),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"MapA":{"Next":"MapB","Retry":[{"ErrorEquals":["Lambda.ServiceException","Lambda.TooManyRequestsException","States.TaskFailed"],"IntervalSeconds":2,"MaxAttempts":3,"BackoffRate":2}]`,
+		`"MapB":{"Next":"Sink","Retry":[{"ErrorEquals":["States.Timeout"],"MaxAttempts":5}]`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
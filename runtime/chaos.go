@@ -0,0 +1,89 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"math/rand"
+	"time"
+)
+
+// EnvChaos enables failure injection, it is JSON of [Chaos]. The runtime
+// wrapper does not inject failures unless the variable is defined.
+const EnvChaos = "TYPESTEP_CHAOS"
+
+// Chaos is the failure injected into the step for the percentage of
+// executions, it verifies retry, dead-letter and alarm behavior of
+// the pipeline under controlled failure.
+type Chaos struct {
+	// Percent of executions (0 - 100) affected by the failure. Executions are
+	// sampled by ARN when the execution metadata is injected (see
+	// TypeStepProps.Metadata), invocations are sampled otherwise.
+	Percent float64 `json:"percent"`
+
+	// Latency injected before the function is executed.
+	Latency time.Duration `json:"latency,omitempty"`
+
+	// Error injected instead of executing the function, it is failed with
+	// [ChaosError]. Latency only is injected if the error is not defined.
+	Error string `json:"error,omitempty"`
+}
+
+// ChaosError is the failure injected by the runtime wrapper, the error type
+// observed by the state machine is `ChaosError`.
+type ChaosError struct{ Message string }
+
+func (e *ChaosError) Error() string { return e.Message }
+
+func newChaos(spec string) *Chaos {
+	if spec == "" {
+		return nil
+	}
+
+	var chaos Chaos
+	if err := json.Unmarshal([]byte(spec), &chaos); err != nil || chaos.Percent <= 0 {
+		return nil
+	}
+
+	return &chaos
+}
+
+// inject the failure if the execution is sampled
+func (c *Chaos) inject(ctx context.Context) error {
+	if !c.sampled(ctx) {
+		return nil
+	}
+
+	if c.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.Latency):
+		}
+	}
+
+	if c.Error != "" {
+		return &ChaosError{Message: c.Error}
+	}
+
+	return nil
+}
+
+func (c *Chaos) sampled(ctx context.Context) bool {
+	execution := MetaOf(ctx).Execution
+	if execution == "" {
+		return rand.Float64()*100 < c.Percent
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(execution))
+	return float64(h.Sum32()%10000) < c.Percent*100
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestChaos(t *testing.T) {
+	// GIVEN
+	h := &handler[string, string]{
+		codec: codecOf(CodecJSON),
+		chaos: newChaos(`{"percent":100,"latency":1000000,"error":"injected"}`),
+		f: func(ctx context.Context, s string) (string, error) {
+			return s, nil
+		},
+	}
+
+	// WHEN
+	t0 := time.Now()
+	_, err := h.Invoke(context.Background(), []byte(`"abc"`))
+
+	// THEN
+	var chaos *ChaosError
+	if !errors.As(err, &chaos) || chaos.Message != "injected" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if time.Since(t0) < time.Millisecond {
+		t.Errorf("latency is not injected")
+	}
+}
+
+func TestChaosDisabled(t *testing.T) {
+	for _, spec := range []string{"", `{"percent":0,"error":"injected"}`, `invalid`} {
+		if newChaos(spec) != nil {
+			t.Errorf("chaos is enabled by %q", spec)
+		}
+	}
+}
+
+func TestChaosSampled(t *testing.T) {
+	chaos := newChaos(`{"percent":10,"error":"injected"}`)
+
+	n := 0
+	for i := 0; i < 10000; i++ {
+		ctx := context.WithValue(context.Background(), metaKey{}, Meta{Execution: fmt.Sprintf("arn:aws:states:eu-west-1:000000000000:execution:Pipe:%d", i)})
+		if chaos.sampled(ctx) {
+			n++
+		}
+
+		// Note: the execution is sampled consistently by all steps
+		if chaos.sampled(ctx) != chaos.sampled(ctx) {
+			t.Errorf("execution is not sampled consistently")
+		}
+	}
+
+	if n < 800 || n > 1200 {
+		t.Errorf("unexpected sampling %d of 10000", n)
+	}
+}
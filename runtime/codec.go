@@ -0,0 +1,206 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Supported wire formats of payloads.
+const (
+	// Payload is JSON object (default)
+	CodecJSON = "json"
+
+	// Payload is protobuf message, wrapped into base64 string. The sequence
+	// of messages is JSON array of base64 strings, making it compatible
+	// with Map state.
+	CodecProtobuf = "protobuf"
+
+	// Payload is protobuf message encoded as canonical JSON object.
+	// Used by steps that emits payload to the services requiring JSON.
+	CodecProtoJSON = "protojson"
+)
+
+// Codec is the wire format of payloads. Custom codecs (e.g. jsoniter, time
+// formats) are registered by id with [RegisterCodec] and selected by
+// the pipeline (see typestep.Encoding).
+type Codec interface {
+	Encode(v any) ([]byte, error)
+	Decode(data []byte, v any) error
+}
+
+// FieldNamer is the optional interface of the codec, which defines names of
+// struct fields on the wire (e.g. snake_case policy) unless the json tag
+// names the field explicitly. The pipeline builder respects the policy of
+// the codec selected by the pipeline when it generates paths of fields.
+type FieldNamer interface {
+	FieldName(f reflect.StructField) string
+}
+
+var (
+	codecs = map[string]Codec{}
+	mutex  sync.RWMutex
+)
+
+// RegisterCodec registers the custom codec with the id. The codec must be
+// registered both by functions and by the stack (e.g. within init of
+// the package shared by them), the field naming policy is applied to
+// pipelines using the codec (see typestep.Encoding).
+func RegisterCodec(id string, c Codec) {
+	switch id {
+	case CodecJSON, CodecProtobuf, CodecProtoJSON:
+		panic(fmt.Errorf("codec %s is built-in", id))
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	codecs[id] = c
+}
+
+// FieldNamerOf returns the field naming policy of the codec registered with
+// the id, it returns nil if the codec does not define the policy.
+func FieldNamerOf(id string) FieldNamer {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if n, ok := codecs[id].(FieldNamer); ok {
+		return n
+	}
+	return nil
+}
+
+// FieldName returns the name of struct field on the wire, either the name
+// of json tag or the name defined by the policy (see [FieldNamer]), the policy
+// is optional. It returns empty string if neither defines it.
+func FieldName(n FieldNamer, f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name != "" || f.Anonymous || n == nil {
+		return name
+	}
+	return n.FieldName(f)
+}
+
+func codecOf(id string) Codec {
+	switch id {
+	case CodecProtobuf:
+		return protobuf{binary: true}
+	case CodecProtoJSON:
+		return protobuf{binary: false}
+	}
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if c, has := codecs[id]; has {
+		return c
+	}
+	return jsonCodec{}
+}
+
+//------------------------------------------------------------------------------
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(v any) ([]byte, error)    { return json.Marshal(v) }
+func (jsonCodec) Decode(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+//------------------------------------------------------------------------------
+
+var typeProtoMessage = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// protobuf codec handles messages and sequences of messages, other types
+// fallback to JSON. Decoder accepts both binary (base64) and JSON forms.
+type protobuf struct{ binary bool }
+
+func (c protobuf) Encode(v any) ([]byte, error) {
+	if msg, ok := v.(proto.Message); ok {
+		return c.marshal(msg)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Implements(typeProtoMessage) {
+		seq := make([]json.RawMessage, rv.Len())
+		for i := range seq {
+			b, err := c.marshal(rv.Index(i).Interface().(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			seq[i] = b
+		}
+		return json.Marshal(seq)
+	}
+
+	return json.Marshal(v)
+}
+
+func (c protobuf) marshal(msg proto.Message) ([]byte, error) {
+	if !c.binary {
+		return protojson.Marshal(msg)
+	}
+
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(b)
+}
+
+func (c protobuf) Decode(data []byte, v any) error {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+
+	switch {
+	case rt.Kind() == reflect.Ptr && rt.Implements(typeProtoMessage):
+		msg := reflect.New(rt.Elem())
+		if err := c.unmarshal(data, msg.Interface().(proto.Message)); err != nil {
+			return err
+		}
+		rv.Set(msg)
+		return nil
+
+	case rt.Kind() == reflect.Slice && rt.Elem().Kind() == reflect.Ptr && rt.Elem().Implements(typeProtoMessage):
+		var seq []json.RawMessage
+		if err := json.Unmarshal(data, &seq); err != nil {
+			return err
+		}
+		out := reflect.MakeSlice(rt, len(seq), len(seq))
+		for i, x := range seq {
+			msg := reflect.New(rt.Elem().Elem())
+			if err := c.unmarshal(x, msg.Interface().(proto.Message)); err != nil {
+				return err
+			}
+			out.Index(i).Set(msg)
+		}
+		rv.Set(out)
+		return nil
+
+	default:
+		return json.Unmarshal(data, v)
+	}
+}
+
+func (protobuf) unmarshal(data []byte, msg proto.Message) error {
+	if len(data) > 0 && data[0] == '"' {
+		var b []byte
+		if err := json.Unmarshal(data, &b); err != nil {
+			return err
+		}
+		return proto.Unmarshal(b, msg)
+	}
+
+	return protojson.Unmarshal(data, msg)
+}
@@ -0,0 +1,154 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodecProtobuf(t *testing.T) {
+	// GIVEN
+	h := &handler[*wrapperspb.StringValue, []*wrapperspb.StringValue]{
+		codec: codecOf(CodecProtobuf),
+		f: func(ctx context.Context, s *wrapperspb.StringValue) ([]*wrapperspb.StringValue, error) {
+			return []*wrapperspb.StringValue{s, s}, nil
+		},
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(), []byte(`"CgNhYmM="`))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `["CgNhYmM=","CgNhYmM="]` {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
+
+func TestCodecProtoJSON(t *testing.T) {
+	// GIVEN
+	h := &handler[*wrapperspb.StringValue, *wrapperspb.StringValue]{
+		codec: codecOf(CodecProtoJSON),
+		f: func(ctx context.Context, s *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+			return s, nil
+		},
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(), []byte(`"CgNhYmM="`))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"abc"` {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
+
+func TestCodecJSON(t *testing.T) {
+	// GIVEN
+	h := &handler[string, []string]{
+		codec: codecOf(""),
+		f: func(ctx context.Context, s string) ([]string, error) {
+			return []string{s}, nil
+		},
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(), []byte(`"abc"`))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `["abc"]` {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
+
+// snake codec is JSON codec with snake_case names of fields
+type snake struct{ jsonCodec }
+
+func (snake) FieldName(f reflect.StructField) string {
+	var sb strings.Builder
+	for i, r := range f.Name {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(f.Name[i-1])) {
+				sb.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func (snake) Encode(v any) ([]byte, error) { return []byte(`"snake"`), nil }
+
+func TestCodecCustom(t *testing.T) {
+	defer delete(codecs, "snake")
+
+	// GIVEN
+	RegisterCodec("snake", snake{})
+	h := &handler[string, string]{
+		codec: codecOf("snake"),
+		f:     func(ctx context.Context, s string) (string, error) { return s, nil },
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(), []byte(`"abc"`))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"snake"` {
+		t.Errorf("unexpected reply %s", out)
+	}
+
+	type T struct {
+		Name      string
+		CreatedAt string
+		UserID    string `json:"id"`
+	}
+	rt := reflect.TypeOf(T{})
+	namer := FieldNamerOf("snake")
+	for i, expect := range []string{"name", "created_at", "id"} {
+		if name := FieldName(namer, rt.Field(i)); name != expect {
+			t.Errorf("unexpected name of field %s, expected %s", name, expect)
+		}
+	}
+
+	// the policy is not applied to other codecs
+	if FieldNamerOf(CodecJSON) != nil {
+		t.Errorf("field naming policy is applied to JSON codec")
+	}
+	if name := FieldName(FieldNamerOf(CodecJSON), rt.Field(1)); name != "" {
+		t.Errorf("unexpected name of field %s", name)
+	}
+}
+
+func TestCodecBuiltIn(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("registration of built-in codec must panic")
+		}
+	}()
+
+	RegisterCodec(CodecJSON, snake{})
+}
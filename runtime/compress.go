@@ -0,0 +1,113 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+)
+
+// Supported compression of payloads.
+const (
+	// Payloads are not compressed (default)
+	CompressionNone = "none"
+
+	// Payloads are compressed with gzip and wrapped into base64 string.
+	// The sequence is compressed element-wise, making it compatible with
+	// Map state.
+	CompressionGzip = "gzip"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// deflate payload, the sequence is compressed element-wise
+func deflate(data []byte) ([]byte, error) {
+	if len(data) > 0 && data[0] == '[' {
+		var seq []json.RawMessage
+		if err := json.Unmarshal(data, &seq); err == nil {
+			out := make([][]byte, len(seq))
+			for i, x := range seq {
+				b, err := gzipBytes(x)
+				if err != nil {
+					return nil, err
+				}
+				out[i] = b
+			}
+			return json.Marshal(out)
+		}
+	}
+
+	b, err := gzipBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(b)
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// inflate payload, uncompressed payloads are passed as-is, so that
+// function is able to consume input from any source.
+func inflate(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	switch data[0] {
+	case '"':
+		var b []byte
+		if err := json.Unmarshal(data, &b); err != nil || !bytes.HasPrefix(b, gzipMagic) {
+			return data, nil
+		}
+		val, err := gunzip(b)
+		if err != nil {
+			// the string accidentally looks like compressed payload
+			return data, nil
+		}
+		return val, nil
+
+	case '[':
+		var seq []json.RawMessage
+		if err := json.Unmarshal(data, &seq); err != nil {
+			return data, nil
+		}
+		for i, x := range seq {
+			b, err := inflate(x)
+			if err != nil {
+				return nil, err
+			}
+			seq[i] = b
+		}
+		return json.Marshal(seq)
+	}
+
+	return data, nil
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
@@ -0,0 +1,54 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	// GIVEN
+	produce := &handler[string, []string]{
+		codec:    codecOf(CodecJSON),
+		compress: true,
+		f: func(ctx context.Context, s string) ([]string, error) {
+			return []string{s, s}, nil
+		},
+	}
+
+	consume := &handler[string, string]{
+		codec: codecOf(CodecJSON),
+		f: func(ctx context.Context, s string) (string, error) {
+			return strings.ToUpper(s), nil
+		},
+	}
+
+	// WHEN
+	seq, err := produce.Invoke(context.Background(), []byte(`"abc"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(seq), `["H4sI`) {
+		t.Errorf("sequence is not compressed element-wise %s", seq)
+	}
+
+	in := strings.Split(strings.Trim(string(seq), "[]"), ",")[0]
+	out, err := consume.Invoke(context.Background(), []byte(in))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"ABC"` {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
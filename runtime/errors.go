@@ -0,0 +1,89 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/lambda/messages"
+)
+
+// EnvErrors declares domain errors of the function, it is comma separated
+// list of error types (see [ErrorTypeOf]). The runtime wrapper finds the first
+// declared error within the chain of the failure and reports it as the error
+// of the function, so that the state machine matches it by the type.
+const EnvErrors = "TYPESTEP_ERRORS"
+
+// ErrorTypeOf returns the type of error `E` observed by the state machine,
+// it is the name of the Go type (e.g. `NotFound` for *NotFound).
+func ErrorTypeOf[E error]() string {
+	return errorTypeOf(reflect.TypeOf(new(E)).Elem())
+}
+
+func errorTypeOf(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem().Name()
+	}
+	return t.Name()
+}
+
+// domain errors declared by the function
+type domain map[string]bool
+
+func newDomain(spec string) domain {
+	if spec == "" {
+		return nil
+	}
+
+	d := domain{}
+	for _, kind := range strings.Split(spec, ",") {
+		d[kind] = true
+	}
+	return d
+}
+
+// typed reports the declared error of the chain, the message is JSON of
+// the error if it has exported fields or the text of error otherwise.
+func (d domain) typed(err error) error {
+	for _, e := range unwrapAll(err) {
+		kind := errorTypeOf(reflect.TypeOf(e))
+		if !d[kind] {
+			continue
+		}
+
+		msg := e.Error()
+		if raw, jerr := json.Marshal(e); jerr == nil && string(raw) != "{}" && string(raw) != "null" {
+			msg = string(raw)
+		}
+
+		return messages.InvokeResponse_Error{Type: kind, Message: msg}
+	}
+
+	return err
+}
+
+// unwrapAll the error, depth-first over wrapped and joined errors
+func unwrapAll(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	seq := []error{err}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		seq = append(seq, unwrapAll(e.Unwrap())...)
+	case interface{ Unwrap() []error }:
+		for _, x := range e.Unwrap() {
+			seq = append(seq, unwrapAll(x)...)
+		}
+	}
+	return seq
+}
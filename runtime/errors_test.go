@@ -0,0 +1,77 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambda/messages"
+)
+
+type NotFound struct {
+	ID string `json:"id"`
+}
+
+func (e *NotFound) Error() string { return "not found " + e.ID }
+
+type RateLimited struct{}
+
+func (e RateLimited) Error() string { return "rate limited" }
+
+func TestErrorTypeOf(t *testing.T) {
+	if kind := ErrorTypeOf[*NotFound](); kind != "NotFound" {
+		t.Errorf("unexpected error type %s", kind)
+	}
+	if kind := ErrorTypeOf[RateLimited](); kind != "RateLimited" {
+		t.Errorf("unexpected error type %s", kind)
+	}
+}
+
+func TestDomainErrors(t *testing.T) {
+	for input, expect := range map[error]messages.InvokeResponse_Error{
+		fmt.Errorf("lookup: %w", &NotFound{ID: "a"}):                     {Type: "NotFound", Message: `{"id":"a"}`},
+		errors.Join(errors.New("fail"), fmt.Errorf("%w", RateLimited{})): {Type: "RateLimited", Message: "rate limited"},
+	} {
+		// GIVEN
+		h := &handler[string, string]{
+			codec:  codecOf(CodecJSON),
+			domain: newDomain("NotFound,RateLimited"),
+			f: func(ctx context.Context, s string) (string, error) {
+				return "", input
+			},
+		}
+
+		// WHEN
+		_, err := h.Invoke(context.Background(), []byte(`"abc"`))
+
+		// THEN
+		var reply messages.InvokeResponse_Error
+		if !errors.As(err, &reply) || reply.Type != expect.Type || reply.Message != expect.Message {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+}
+
+func TestDomainErrorsUndeclared(t *testing.T) {
+	failure := errors.New("fail")
+	h := &handler[string, string]{
+		codec:  codecOf(CodecJSON),
+		domain: newDomain("NotFound"),
+		f: func(ctx context.Context, s string) (string, error) {
+			return "", fmt.Errorf("%w", failure)
+		},
+	}
+
+	if _, err := h.Invoke(context.Background(), []byte(`"abc"`)); !errors.Is(err, failure) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// EnvLineage enables emission of OpenLineage run events, it is the namespace
+// of jobs and datasets. Events are written into the log of the function as
+// JSON lines (console transport), the log subscription ships them to
+// the lineage backend.
+const EnvLineage = "TYPESTEP_LINEAGE"
+
+const (
+	lineageProducer  = "https://github.com/fogfish/typestep"
+	lineageSchemaURL = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/definitions/RunEvent"
+)
+
+// lineage emits OpenLineage run events of the function, the dataset is
+// the type of payload, the job is the state invoking the function and
+// the run is the execution of the state machine.
+type lineage struct {
+	namespace string
+	input     string
+	output    string
+	w         io.Writer
+}
+
+func newLineage(namespace, input, output string) *lineage {
+	if namespace == "" {
+		return nil
+	}
+
+	return &lineage{
+		namespace: namespace,
+		input:     input,
+		output:    output,
+		w:         os.Stdout,
+	}
+}
+
+type lineageEvent struct {
+	EventType string           `json:"eventType"`
+	EventTime string           `json:"eventTime"`
+	Run       lineageRun       `json:"run"`
+	Job       lineageJob       `json:"job"`
+	Inputs    []lineageDataset `json:"inputs"`
+	Outputs   []lineageDataset `json:"outputs"`
+	Producer  string           `json:"producer"`
+	SchemaURL string           `json:"schemaURL"`
+}
+
+type lineageRun struct {
+	RunID string `json:"runId"`
+}
+
+type lineageJob struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type lineageDataset struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// emit the run event, the event is either START, COMPLETE or FAIL. Events of
+// functions invoked outside of the state machine are not emitted.
+func (l *lineage) emit(ctx context.Context, eventType string) error {
+	meta := MetaOf(ctx)
+	if meta.Execution == "" || meta.State == "" {
+		return nil
+	}
+
+	event := lineageEvent{
+		EventType: eventType,
+		EventTime: time.Now().UTC().Format(time.RFC3339Nano),
+		Run:       lineageRun{RunID: uuidOf(meta.Execution)},
+		Job:       lineageJob{Namespace: l.namespace, Name: meta.State},
+		Inputs:    []lineageDataset{{Namespace: l.namespace, Name: l.input}},
+		Outputs:   []lineageDataset{},
+		Producer:  lineageProducer,
+		SchemaURL: lineageSchemaURL,
+	}
+	if eventType == "COMPLETE" {
+		event.Outputs = append(event.Outputs, lineageDataset{Namespace: l.namespace, Name: l.output})
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(l.w, string(line))
+	return err
+}
+
+// uuidOf the execution, OpenLineage requires UUID as id of the run. It is
+// the name-based UUID derived from SHA-1 of the execution ARN, so that every
+// step of the execution reports the same run.
+func uuidOf(name string) string {
+	h := sha1.Sum([]byte(name))
+	h[6] = (h[6] & 0x0f) | 0x50
+	h[8] = (h[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
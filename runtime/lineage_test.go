@@ -0,0 +1,120 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLineage(t *testing.T) {
+	for name, spec := range map[string]struct {
+		err    error
+		events []string
+	}{
+		"complete": {events: []string{`"eventType":"START"`, `"eventType":"COMPLETE"`, `"outputs":[{"namespace":"orders","name":"int"}]`}},
+		"fail":     {err: errors.New("failed"), events: []string{`"eventType":"START"`, `"eventType":"FAIL"`}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN
+			buf := &bytes.Buffer{}
+			l := newLineage("orders", "string", "int")
+			l.w = buf
+
+			h := &handler[string, int]{
+				codec:   codecOf(CodecJSON),
+				lineage: l,
+				f: func(ctx context.Context, s string) (int, error) {
+					return len(s), spec.err
+				},
+			}
+
+			// WHEN
+			in := `{"typestep:meta":{"execution":"arn:aws:states:eu-west-1:000000000000:execution:pipe:abc","state":"MapA"},"typestep:payload":"abc"}`
+			h.Invoke(context.Background(), []byte(in))
+
+			// THEN
+			events := buf.String()
+			for _, expect := range append(spec.events,
+				`"run":{"runId":"`+uuidOf("arn:aws:states:eu-west-1:000000000000:execution:pipe:abc")+`"}`,
+				`"job":{"namespace":"orders","name":"MapA"}`,
+				`"inputs":[{"namespace":"orders","name":"string"}]`,
+			) {
+				if !strings.Contains(events, expect) {
+					t.Errorf("lineage events do not contain %s: %s", expect, events)
+				}
+			}
+		})
+	}
+}
+
+func TestLineageOutsideOfStateMachine(t *testing.T) {
+	// GIVEN
+	buf := &bytes.Buffer{}
+	l := newLineage("orders", "string", "int")
+	l.w = buf
+
+	h := &handler[string, int]{
+		codec:   codecOf(CodecJSON),
+		lineage: l,
+		f:       func(ctx context.Context, s string) (int, error) { return len(s), nil },
+	}
+
+	// WHEN
+	if _, err := h.Invoke(context.Background(), []byte(`"abc"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	if buf.Len() != 0 {
+		t.Errorf("unexpected lineage events %s", buf.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("closed") }
+
+func TestLineageFailure(t *testing.T) {
+	// GIVEN
+	l := newLineage("orders", "string", "int")
+	l.w = failingWriter{}
+
+	calls := 0
+	h := &handler[string, int]{
+		codec:   codecOf(CodecJSON),
+		lineage: l,
+		f: func(ctx context.Context, s string) (int, error) {
+			calls++
+			return len(s), nil
+		},
+	}
+
+	// WHEN
+	in := `{"typestep:meta":{"execution":"arn:aws:states:eu-west-1:000000000000:execution:pipe:abc","state":"MapA"},"typestep:payload":"abc"}`
+	out, err := h.Invoke(context.Background(), []byte(in))
+
+	// THEN
+	if err != nil {
+		t.Fatalf("lineage failure is returned: %v", err)
+	}
+	if calls != 1 || !strings.Contains(string(out), "3") {
+		t.Errorf("unexpected result of the function %s", out)
+	}
+}
+
+func TestUUIDOf(t *testing.T) {
+	id := uuidOf("arn:aws:states:eu-west-1:000000000000:execution:pipe:abc")
+	if len(id) != 36 || id[14] != '5' || !strings.ContainsRune("89ab", rune(id[19])) {
+		t.Errorf("invalid uuid %s", id)
+	}
+}
@@ -0,0 +1,98 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Reserved attributes of the envelope, the state machine injects workflow
+// metadata alongside the payload when cross-cutting features are enabled.
+const (
+	EnvelopeMeta    = "typestep:meta"
+	EnvelopePayload = "typestep:payload"
+)
+
+// envelope of the payload
+type envelope struct {
+	Meta    Meta            `json:"typestep:meta"`
+	Payload json.RawMessage `json:"typestep:payload"`
+}
+
+// Meta is metadata of the workflow execution, the state machine injects it
+// alongside the payload so that business types are not polluted. Fields
+// are defined only if the corresponding feature of the pipeline is enabled.
+type Meta struct {
+	// Correlation id of the execution (see TypeStepProps.Correlation)
+	Correlation string `json:"correlation,omitempty"`
+
+	// Traceparent is W3C trace context (see TypeStepProps.Tracing)
+	Traceparent string `json:"traceparent,omitempty"`
+
+	// Execution is ARN of the execution (see TypeStepProps.Metadata)
+	Execution string `json:"execution,omitempty"`
+
+	// State is the name of the state invoking the function
+	State string `json:"state,omitempty"`
+
+	// Attempt is the number of retries of the state, 0 is the first attempt
+	Attempt int `json:"attempt,omitempty"`
+
+	// Version of the pipeline definition (see typestep.VersionAttribute)
+	Version string `json:"version,omitempty"`
+}
+
+type metaKey struct{}
+
+// unwrap the envelope, the metadata is stored into the context.
+func unwrap(ctx context.Context, in []byte) (context.Context, []byte, error) {
+	if len(in) == 0 || in[0] != '{' || !bytes.Contains(in, []byte(`"`+EnvelopePayload+`"`)) {
+		return ctx, in, nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(in, &env); err != nil {
+		return ctx, nil, err
+	}
+
+	ctx = context.WithValue(ctx, metaKey{}, env.Meta)
+	if env.Meta.Traceparent != "" {
+		carrier := propagation.MapCarrier{"traceparent": env.Meta.Traceparent}
+		ctx = propagation.TraceContext{}.Extract(ctx, carrier)
+	}
+
+	return ctx, env.Payload, nil
+}
+
+// MetaOf returns metadata of the workflow execution, which invokes the handler.
+func MetaOf(ctx context.Context) Meta {
+	if m, ok := ctx.Value(metaKey{}).(Meta); ok {
+		return m
+	}
+	return Meta{}
+}
+
+// CorrelationID returns the correlation id of the workflow execution. The id
+// is carried through every step when correlation is enabled for the pipeline,
+// it is either id of the source event or declared field of the input.
+func CorrelationID(ctx context.Context) string {
+	return MetaOf(ctx).Correlation
+}
+
+// Traceparent returns W3C trace context of the workflow execution, when
+// tracing is enabled for the pipeline. The context is also restored as
+// the remote span of the context (see trace.SpanContextFromContext),
+// so that OpenTelemetry instrumentation of the handler continues the trace.
+func Traceparent(ctx context.Context) string {
+	return MetaOf(ctx).Traceparent
+}
@@ -0,0 +1,87 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCorrelationID(t *testing.T) {
+	// GIVEN
+	h := &handler[string, string]{
+		codec: codecOf(CodecJSON),
+		f: func(ctx context.Context, s string) (string, error) {
+			return CorrelationID(ctx) + ":" + s, nil
+		},
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(),
+		[]byte(`{"typestep:meta":{"correlation":"id"},"typestep:payload":"abc"}`),
+	)
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"id:abc"` {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
+
+func TestTraceparent(t *testing.T) {
+	// GIVEN
+	h := &handler[string, string]{
+		codec: codecOf(CodecJSON),
+		f: func(ctx context.Context, s string) (string, error) {
+			return trace.SpanContextFromContext(ctx).TraceID().String(), nil
+		},
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(),
+		[]byte(`{"typestep:meta":{"traceparent":"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},"typestep:payload":"abc"}`),
+	)
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"0af7651916cd43dd8448eb211c80319c"` {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
+
+func TestMetaOf(t *testing.T) {
+	// GIVEN
+	h := &handler[string, string]{
+		codec: codecOf(CodecJSON),
+		f: func(ctx context.Context, s string) (string, error) {
+			m := MetaOf(ctx)
+			return fmt.Sprintf("%s:%s:%d:%s", m.Execution, m.State, m.Attempt, m.Version), nil
+		},
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(),
+		[]byte(`{"typestep:meta":{"execution":"arn","state":"MapA","attempt":2,"version":"v1"},"typestep:payload":"abc"}`),
+	)
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"arn:MapA:2:v1"` {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
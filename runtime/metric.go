@@ -0,0 +1,154 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metric is business metric declared at composition time, the runtime wrapper
+// emits it as CloudWatch Embedded Metric Format (EMF) from every invocation.
+type Metric struct {
+	// Name of the metric
+	Name string `json:"name"`
+
+	// Path is JSONPath within the reply (e.g. `$.items`). The number is
+	// emitted as-is, the length is emitted for arrays, objects and other
+	// values are counted as 1.
+	Path string `json:"path"`
+}
+
+// Environment variables used by metrics
+const (
+	// EnvMetrics is JSON array of declared metrics ([]Metric)
+	EnvMetrics = "TYPESTEP_METRICS"
+
+	// EnvMetricsNamespace is CloudWatch namespace of metrics
+	EnvMetricsNamespace = "TYPESTEP_METRICS_NAMESPACE"
+
+	// EnvPipeline is the name of the pipeline, used as metrics dimension
+	EnvPipeline = "TYPESTEP_PIPELINE"
+)
+
+type metrics struct {
+	namespace string
+	pipeline  string
+	spec      []Metric
+	w         io.Writer
+}
+
+func newMetrics(spec, namespace, pipeline string) *metrics {
+	if spec == "" {
+		return nil
+	}
+
+	var seq []Metric
+	if err := json.Unmarshal([]byte(spec), &seq); err != nil || len(seq) == 0 {
+		return nil
+	}
+
+	if namespace == "" {
+		namespace = "typestep"
+	}
+
+	return &metrics{
+		namespace: namespace,
+		pipeline:  pipeline,
+		spec:      seq,
+		w:         os.Stdout,
+	}
+}
+
+// emit metrics from the reply
+func (m *metrics) emit(reply any) error {
+	b, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	names := make([]map[string]string, len(m.spec))
+	record := map[string]any{"Pipeline": m.pipeline}
+	for i, metric := range m.spec {
+		names[i] = map[string]string{"Name": metric.Name, "Unit": "Count"}
+		record[metric.Name] = measure(lookup(doc, metric.Path))
+	}
+
+	record["_aws"] = map[string]any{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []any{
+			map[string]any{
+				"Namespace":  m.namespace,
+				"Dimensions": [][]string{{"Pipeline"}},
+				"Metrics":    names,
+			},
+		},
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(m.w, string(line))
+	return err
+}
+
+func measure(val any) float64 {
+	switch v := val.(type) {
+	case float64:
+		return v
+	case []any:
+		return float64(len(v))
+	case nil:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// lookup the value at dotted JSONPath (e.g. `$.a.b[0]`)
+func lookup(doc any, path string) any {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return doc
+	}
+
+	for _, seg := range strings.Split(path, ".") {
+		key, idx, _ := strings.Cut(seg, "[")
+		if key != "" {
+			obj, ok := doc.(map[string]any)
+			if !ok {
+				return nil
+			}
+			doc = obj[key]
+		}
+
+		if idx != "" {
+			i, err := strconv.Atoi(strings.TrimSuffix(idx, "]"))
+			seq, ok := doc.([]any)
+			if err != nil || !ok || i < 0 || i >= len(seq) {
+				return nil
+			}
+			doc = seq[i]
+		}
+	}
+
+	return doc
+}
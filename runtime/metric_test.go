@@ -0,0 +1,79 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	// GIVEN
+	buf := &bytes.Buffer{}
+	m := newMetrics(`[{"name":"Picked","path":"$.items"},{"name":"Score","path":"$.score"}]`, "", "Pipe")
+	m.w = buf
+
+	type reply struct {
+		Items []string `json:"items"`
+		Score int      `json:"score"`
+	}
+
+	h := &handler[string, reply]{
+		codec:   codecOf(CodecJSON),
+		metrics: m,
+		f: func(ctx context.Context, s string) (reply, error) {
+			return reply{Items: []string{s, s, s}, Score: 10}, nil
+		},
+	}
+
+	// WHEN
+	_, err := h.Invoke(context.Background(), []byte(`"abc"`))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emf := buf.String()
+	for _, expect := range []string{
+		`"Picked":3`,
+		`"Score":10`,
+		`"Pipeline":"Pipe"`,
+		`"Namespace":"typestep"`,
+	} {
+		if !strings.Contains(emf, expect) {
+			t.Errorf("EMF record does not contain %s: %s", expect, emf)
+		}
+	}
+}
+
+func TestMetricsFailure(t *testing.T) {
+	// GIVEN
+	m := newMetrics(`[{"name":"Length","path":"$"}]`, "", "Pipe")
+	m.w = failingWriter{}
+
+	h := &handler[string, int]{
+		codec:   codecOf(CodecJSON),
+		metrics: m,
+		f:       func(ctx context.Context, s string) (int, error) { return len(s), nil },
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(), []byte(`"abc"`))
+
+	// THEN
+	if err != nil {
+		t.Fatalf("metrics failure is returned: %v", err)
+	}
+	if !strings.Contains(string(out), "3") {
+		t.Errorf("unexpected result of the function %s", out)
+	}
+}
@@ -0,0 +1,181 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// EnvelopeSchema is the reserved attribute of JSON payload, which holds
+// the schema version of the type (see [Versioned]).
+const EnvelopeSchema = "typestep:schema"
+
+// Versioned is the type aware of its schema version. The runtime wrapper
+// stamps the version into the reply, the input of older version is migrated
+// to the expected type with functions registered by [Migrate]. Types, which
+// are not versioned, have the version 0.
+//
+//	func (Order) SchemaVersion() int { return 2 }
+type Versioned interface {
+	SchemaVersion() int
+}
+
+type migration struct {
+	from reflect.Type
+	f    func(any) (any, error)
+}
+
+var (
+	migrations      = map[reflect.Type]migration{}
+	migrationsMutex sync.RWMutex
+)
+
+// Migrate registers the migration of payload from type V1 to V2, so that
+// in-flight executions started with the old type survive the deployment,
+// which changes the type. Migrations are chained (e.g. V1 ⟼ V2 ⟼ V3),
+// the schema version of V2 must be greater than V1.
+//
+//	func init() { runtime.Migrate(func(v OrderV1) (Order, error) { /* ... */ }) }
+func Migrate[V1, V2 any](f func(V1) (V2, error)) {
+	from := reflect.TypeOf(new(V1)).Elem()
+	to := reflect.TypeOf(new(V2)).Elem()
+
+	if versionOf(to) <= versionOf(from) {
+		panic(fmt.Errorf("migration %s ⟼ %s requires greater schema version of %s", from, to, to))
+	}
+
+	migrationsMutex.Lock()
+	defer migrationsMutex.Unlock()
+
+	if _, has := migrations[to]; has {
+		panic(fmt.Errorf("migration to %s is already registered", to))
+	}
+
+	migrations[to] = migration{
+		from: from,
+		f: func(v any) (any, error) {
+			return f(v.(V1))
+		},
+	}
+}
+
+// versionOf the type, zero value defines the version
+func versionOf(t reflect.Type) int {
+	if !t.Implements(reflect.TypeOf((*Versioned)(nil)).Elem()) {
+		return 0
+	}
+
+	v := reflect.New(t).Elem()
+	if t.Kind() == reflect.Pointer {
+		v = reflect.New(t.Elem())
+	}
+	return v.Interface().(Versioned).SchemaVersion()
+}
+
+// schemaOf the payload, the attribute is removed from the payload
+func schemaOf(in []byte) (int, []byte, error) {
+	if len(in) == 0 || in[0] != '{' || !bytes.Contains(in, []byte(`"`+EnvelopeSchema+`"`)) {
+		return 0, in, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(in, &obj); err != nil {
+		return 0, nil, err
+	}
+
+	var version int
+	if err := json.Unmarshal(obj[EnvelopeSchema], &version); err != nil {
+		return 0, nil, err
+	}
+	delete(obj, EnvelopeSchema)
+
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return 0, nil, err
+	}
+	return version, out, nil
+}
+
+// stamp the schema version into the JSON object
+func stamp(version int, out []byte) []byte {
+	if version == 0 || len(out) < 2 || out[0] != '{' {
+		return out
+	}
+
+	attr := fmt.Sprintf(`"%s":%d`, EnvelopeSchema, version)
+	if string(out) == "{}" {
+		return []byte("{" + attr + "}")
+	}
+	return append([]byte("{"+attr+","), out[1:]...)
+}
+
+// decode the payload into type A, the payload of older schema version is
+// migrated through the chain of registered migrations.
+func decode[A any](codec Codec, in []byte) (A, error) {
+	var a A
+
+	version, in, err := schemaOf(in)
+	if err != nil {
+		return a, err
+	}
+
+	t := reflect.TypeOf(new(A)).Elem()
+	if versionOf(t) == version {
+		err := codec.Decode(in, &a)
+		return a, err
+	}
+
+	chain, err := chainOf(t, version)
+	if err != nil {
+		return a, err
+	}
+
+	ptr := reflect.New(chain[0].from)
+	if err := codec.Decode(in, ptr.Interface()); err != nil {
+		return a, err
+	}
+
+	v := ptr.Elem().Interface()
+	for _, m := range chain {
+		v, err = m.f(v)
+		if err != nil {
+			return a, fmt.Errorf("migration %s failed: %w", m.from, err)
+		}
+	}
+
+	return v.(A), nil
+}
+
+// chainOf migrations from the schema version to type t, the first element
+// decodes the payload.
+func chainOf(t reflect.Type, version int) ([]migration, error) {
+	migrationsMutex.RLock()
+	defer migrationsMutex.RUnlock()
+
+	chain := []migration{}
+	for to := t; ; {
+		m, has := migrations[to]
+		if !has {
+			return nil, fmt.Errorf("no migration of schema version %d to %s", version, t)
+		}
+		chain = append([]migration{m}, chain...)
+
+		switch v := versionOf(m.from); {
+		case v == version:
+			return chain, nil
+		case v < version:
+			return nil, fmt.Errorf("no migration of schema version %d to %s", version, t)
+		}
+		to = m.from
+	}
+}
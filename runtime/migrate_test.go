@@ -0,0 +1,100 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type orderV0 struct {
+	Name string `json:"name"`
+}
+
+type orderV1 struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+}
+
+func (orderV1) SchemaVersion() int { return 1 }
+
+type orderV2 struct {
+	Names []string `json:"names"`
+}
+
+func (orderV2) SchemaVersion() int { return 2 }
+
+func TestMigrate(t *testing.T) {
+	// GIVEN
+	Migrate(func(v orderV0) (orderV1, error) {
+		first, last, _ := strings.Cut(v.Name, " ")
+		return orderV1{First: first, Last: last}, nil
+	})
+	Migrate(func(v orderV1) (orderV2, error) {
+		return orderV2{Names: []string{v.First, v.Last}}, nil
+	})
+	defer func() {
+		delete(migrations, reflect.TypeOf(orderV1{}))
+		delete(migrations, reflect.TypeOf(orderV2{}))
+	}()
+
+	h := &handler[orderV2, orderV2]{
+		codec: codecOf(CodecJSON),
+		f: func(ctx context.Context, order orderV2) (orderV2, error) {
+			return order, nil
+		},
+	}
+
+	for input, expected := range map[string]string{
+		`{"name":"a b"}`: `{"typestep:schema":2,"names":["a","b"]}`,
+		`{"typestep:schema":1,"first":"a","last":"b"}`: `{"typestep:schema":2,"names":["a","b"]}`,
+		`{"typestep:schema":2,"names":["a","b"]}`:      `{"typestep:schema":2,"names":["a","b"]}`,
+	} {
+		// WHEN
+		out, err := h.Invoke(context.Background(), []byte(input))
+
+		// THEN
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != expected {
+			t.Errorf("unexpected reply %s for %s", out, input)
+		}
+	}
+}
+
+func TestMigrateUnknownVersion(t *testing.T) {
+	// GIVEN
+	h := &handler[orderV2, orderV2]{
+		codec: codecOf(CodecJSON),
+		f: func(ctx context.Context, order orderV2) (orderV2, error) {
+			return order, nil
+		},
+	}
+
+	// WHEN
+	_, err := h.Invoke(context.Background(), []byte(`{"typestep:schema":1,"first":"a"}`))
+
+	// THEN
+	if err == nil || !strings.Contains(err.Error(), "no migration of schema version 1") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMigrateVersion(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("migration must require greater schema version")
+		}
+	}()
+
+	Migrate(func(v orderV2) (orderV1, error) { return orderV1{}, nil })
+}
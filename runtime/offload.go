@@ -0,0 +1,212 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Default size of payload (bytes) after which it is offloaded to S3. It leaves
+// the headroom for Step Functions metadata within 256KB limit.
+const OffloadThreshold = 200 * 1024
+
+// Ref is the claim-check of the payload offloaded to S3. It is passed through
+// the state machine instead of the payload, the runtime wrapper of the next
+// function rehydrates it transparently.
+type Ref struct {
+	URL string `json:"$ref"`
+}
+
+// Storage of offloaded payloads
+type storage interface {
+	Put(ctx context.Context, key string, val []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// offload implements claim-check pattern
+type offload struct {
+	bucket    string
+	threshold int
+	storage   storage
+}
+
+func newOffload(bucket, threshold string) *offload {
+	if bucket == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(threshold)
+	if err != nil || n <= 0 {
+		n = OffloadThreshold
+	}
+
+	return &offload{
+		bucket:    bucket,
+		threshold: n,
+		storage:   &s3storage{bucket: bucket},
+	}
+}
+
+// store payload into S3 if it exceeds the threshold. The sequence of elements
+// is offloaded element-wise, so that Map state iterates over claim-checks.
+func (o *offload) store(ctx context.Context, data []byte) ([]byte, error) {
+	if len(data) <= o.threshold {
+		return data, nil
+	}
+
+	key := o.key(ctx)
+	if len(data) > 0 && data[0] == '[' {
+		var seq []json.RawMessage
+		if err := json.Unmarshal(data, &seq); err == nil {
+			refs := make([]Ref, len(seq))
+			for i, x := range seq {
+				ref, err := o.put(ctx, key+"/"+strconv.Itoa(i), x)
+				if err != nil {
+					return nil, err
+				}
+				refs[i] = ref
+			}
+			return json.Marshal(refs)
+		}
+	}
+
+	ref, err := o.put(ctx, key, data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ref)
+}
+
+func (o *offload) put(ctx context.Context, key string, data []byte) (Ref, error) {
+	if err := o.storage.Put(ctx, key, data); err != nil {
+		return Ref{}, fmt.Errorf("offload of payload failed: %w", err)
+	}
+
+	return Ref{URL: "s3://" + o.bucket + "/" + key}, nil
+}
+
+func (o *offload) key(ctx context.Context) string {
+	id := "undefined"
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		id = lc.AwsRequestID
+	}
+
+	return "typestep/" + lambdacontext.FunctionName + "/" + id
+}
+
+// resolve claim-checks of the payload, including elements of the sequence.
+func (o *offload) resolve(ctx context.Context, data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(`"$ref"`)) {
+		return data, nil
+	}
+
+	switch {
+	case data[0] == '{':
+		var ref Ref
+		if err := json.Unmarshal(data, &ref); err != nil || ref.URL == "" {
+			return data, nil
+		}
+		return o.get(ctx, ref)
+
+	case data[0] == '[':
+		var seq []json.RawMessage
+		if err := json.Unmarshal(data, &seq); err != nil {
+			return data, nil
+		}
+		for i, x := range seq {
+			val, err := o.resolve(ctx, x)
+			if err != nil {
+				return nil, err
+			}
+			seq[i] = val
+		}
+		return json.Marshal(seq)
+	}
+
+	return data, nil
+}
+
+func (o *offload) get(ctx context.Context, ref Ref) ([]byte, error) {
+	path := strings.TrimPrefix(ref.URL, "s3://")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != o.bucket {
+		return nil, fmt.Errorf("claim-check %s refers unknown bucket", ref.URL)
+	}
+
+	val, err := o.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("rehydration of payload %s failed: %w", ref.URL, err)
+	}
+	return val, nil
+}
+
+//------------------------------------------------------------------------------
+
+// AWS S3 storage, the client is created on first use
+type s3storage struct {
+	sync.Once
+	bucket string
+	client *s3.Client
+	err    error
+}
+
+func (s *s3storage) init(ctx context.Context) error {
+	s.Do(func() {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.client = s3.NewFromConfig(cfg)
+	})
+	return s.err
+}
+
+func (s *s3storage) Put(ctx context.Context, key string, val []byte) error {
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(val),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+func (s *s3storage) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := s.init(ctx); err != nil {
+		return nil, err
+	}
+
+	val, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer val.Body.Close()
+
+	return io.ReadAll(val.Body)
+}
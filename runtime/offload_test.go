@@ -0,0 +1,98 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type mockStorage map[string][]byte
+
+func (m mockStorage) Put(ctx context.Context, key string, val []byte) error {
+	m[key] = val
+	return nil
+}
+
+func (m mockStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	val, has := m[key]
+	if !has {
+		return nil, fmt.Errorf("not found %s", key)
+	}
+	return val, nil
+}
+
+func TestOffload(t *testing.T) {
+	// GIVEN
+	store := mockStorage{}
+	o := &offload{bucket: "test", threshold: 16, storage: store}
+
+	produce := &handler[string, []string]{
+		codec:   codecOf(CodecJSON),
+		offload: o,
+		f: func(ctx context.Context, s string) ([]string, error) {
+			return []string{strings.Repeat(s, 4), strings.Repeat(s, 4)}, nil
+		},
+	}
+
+	consume := &handler[[]string, int]{
+		codec:   codecOf(CodecJSON),
+		offload: o,
+		f: func(ctx context.Context, s []string) (int, error) {
+			return len(s[0]) + len(s[1]), nil
+		},
+	}
+
+	// WHEN
+	refs, err := produce.Invoke(context.Background(), []byte(`"abcdef"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := consume.Invoke(context.Background(), refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	if len(store) != 2 {
+		t.Errorf("sequence is not offloaded element-wise %v", store)
+	}
+	if !strings.Contains(string(refs), `"$ref":"s3://test/typestep/`) {
+		t.Errorf("unexpected claim-check %s", refs)
+	}
+	if string(out) != "48" {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
+
+func TestOffloadSmallPayload(t *testing.T) {
+	// GIVEN
+	store := mockStorage{}
+	h := &handler[string, string]{
+		codec:   codecOf(CodecJSON),
+		offload: &offload{bucket: "test", threshold: 16, storage: store},
+		f: func(ctx context.Context, s string) (string, error) {
+			return s, nil
+		},
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(), []byte(`"abc"`))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"abc"` || len(store) != 0 {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
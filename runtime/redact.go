@@ -0,0 +1,121 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Redacted is the mask of sensitive fields
+const Redacted = "***"
+
+// TagPII is the value of struct tag `typestep:"pii"`, which annotates fields
+// containing personal data. The fields are masked in logs and DLQ messages.
+const TagPII = "pii"
+
+// Sensitive returns paths to fields annotated with `typestep:"pii"`. The path
+// is a sequence of names on the wire (see [FieldName]), "[]" denotes elements
+// of the sequence.
+func Sensitive(n FieldNamer, t reflect.Type) [][]string {
+	if t == nil {
+		return nil
+	}
+	return sensitive(n, t, nil, map[reflect.Type]bool{})
+}
+
+func sensitive(n FieldNamer, t reflect.Type, path []string, visited map[reflect.Type]bool) [][]string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return sensitive(n, t.Elem(), append(append([]string{}, path...), "[]"), visited)
+	case reflect.Struct:
+		if visited[t] {
+			return nil
+		}
+		visited[t] = true
+		defer delete(visited, t)
+
+		seq := [][]string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			name := FieldName(n, f)
+			if name == "-" {
+				continue
+			}
+
+			at := path
+			if !(f.Anonymous && name == "") {
+				if name == "" {
+					name = f.Name
+				}
+				at = append(append([]string{}, path...), name)
+			}
+
+			if HasTag(f, TagPII) {
+				seq = append(seq, at)
+				continue
+			}
+			seq = append(seq, sensitive(n, f.Type, at, visited)...)
+		}
+		return seq
+	default:
+		return nil
+	}
+}
+
+// Redact masks sensitive fields of the value, it returns JSON value safe for logging.
+func Redact(v any) any {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return Redacted
+	}
+
+	var val any
+	if err := json.Unmarshal(raw, &val); err != nil {
+		return Redacted
+	}
+
+	// Note: the value is encoded by encoding/json, names of fields are
+	//       defined by json tags only
+	for _, path := range Sensitive(nil, reflect.TypeOf(v)) {
+		val = mask(val, path)
+	}
+	return val
+}
+
+func mask(val any, path []string) any {
+	if len(path) == 0 {
+		if val == nil {
+			return nil
+		}
+		return Redacted
+	}
+
+	switch v := val.(type) {
+	case []any:
+		if path[0] == "[]" {
+			for i := range v {
+				v[i] = mask(v[i], path[1:])
+			}
+		}
+	case map[string]any:
+		if x, has := v[path[0]]; has {
+			v[path[0]] = mask(x, path[1:])
+		}
+	}
+	return val
+}
@@ -0,0 +1,66 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type person struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty" typestep:"pii"`
+}
+
+type team struct {
+	ID      string   `json:"id"`
+	Lead    person   `json:"lead"`
+	Members []person `json:"members"`
+}
+
+func TestRedact(t *testing.T) {
+	// GIVEN
+	val := team{
+		ID:      "t",
+		Lead:    person{Name: "a", Email: "a@example.com"},
+		Members: []person{{Name: "b", Email: "b@example.com"}, {Name: "c"}},
+	}
+
+	// WHEN
+	raw, err := json.Marshal(Redact(val))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"id":"t","lead":{"email":"***","name":"a"},"members":[{"email":"***","name":"b"},{"name":"c"}]}`
+	if string(raw) != expect {
+		t.Errorf("unexpected value %s", raw)
+	}
+}
+
+func TestRedactWithOptions(t *testing.T) {
+	// GIVEN
+	type contact struct {
+		Name  string `json:"name"`
+		Phone string `json:"phone" typestep:"pii,required"`
+	}
+
+	// WHEN
+	raw, err := json.Marshal(Redact(contact{Name: "a", Phone: "+1"}))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"name":"a","phone":"***"}`
+	if string(raw) != expect {
+		t.Errorf("unexpected value %s", raw)
+	}
+}
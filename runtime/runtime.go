@@ -0,0 +1,207 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// Package runtime implements the wire protocol used by typestep between
+// steps of AWS Step Functions. It is the runtime wrapper of "type-safe"
+// AWS Lambda functions, the auto generated `main.go` binds the handler
+// with the protocol:
+//
+//	func main() { lambda.Start(runtime.Handler(core.Main())) }
+//
+// The wrapper is configured by the pipeline builder through environment
+// variables of the function.
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Environment variables used to configure the runtime wrapper.
+const (
+	// EnvCodec defines the wire format of the reply, the input format is
+	// detected automatically.
+	EnvCodec = "TYPESTEP_CODEC"
+
+	// EnvOffloadBucket enables claim-check pattern, payloads exceeding the
+	// threshold are stored in the bucket.
+	EnvOffloadBucket = "TYPESTEP_OFFLOAD_BUCKET"
+
+	// EnvOffloadThreshold is the size of payload in bytes after which it is
+	// offloaded, default is [OffloadThreshold].
+	EnvOffloadThreshold = "TYPESTEP_OFFLOAD_THRESHOLD"
+
+	// EnvCompression defines the compression of the reply (e.g. [CompressionGzip]),
+	// compressed input is detected automatically.
+	EnvCompression = "TYPESTEP_COMPRESSION"
+
+	// EnvStrict enables strict decoding of JSON input, unknown fields and
+	// missing fields annotated with `typestep:"required"` fail the decoding.
+	EnvStrict = "TYPESTEP_STRICT"
+
+	// EnvBatch enables batch mode of EventBridge Pipes, the input is JSON
+	// array of payloads, the reply is JSON array of results.
+	EnvBatch = "TYPESTEP_BATCH"
+)
+
+// Handler lifts the type-safe handler 𝑓: A ⟼ B into AWS Lambda handler,
+// which is aware of the typestep wire protocol.
+func Handler[A, B any](f func(context.Context, A) (B, error)) lambda.Handler {
+	// Note: secrets are fetched at cold start, errors are reported on use
+	coldstart.init(context.Background())
+
+	codec := codecOf(os.Getenv(EnvCodec))
+	if os.Getenv(EnvStrict) != "" {
+		codec = strictOf(codec)
+	}
+
+	return &handler[A, B]{
+		f:        f,
+		codec:    codec,
+		compress: os.Getenv(EnvCompression) == CompressionGzip,
+		batch:    os.Getenv(EnvBatch) != "",
+		offload:  newOffload(os.Getenv(EnvOffloadBucket), os.Getenv(EnvOffloadThreshold)),
+		metrics:  newMetrics(os.Getenv(EnvMetrics), os.Getenv(EnvMetricsNamespace), os.Getenv(EnvPipeline)),
+		chaos:    newChaos(os.Getenv(EnvChaos)),
+		domain:   newDomain(os.Getenv(EnvErrors)),
+		lineage: newLineage(os.Getenv(EnvLineage),
+			reflect.TypeOf(new(A)).Elem().String(),
+			reflect.TypeOf(new(B)).Elem().String(),
+		),
+	}
+}
+
+type handler[A, B any] struct {
+	f        func(context.Context, A) (B, error)
+	codec    Codec
+	compress bool
+	batch    bool
+	offload  *offload
+	metrics  *metrics
+	chaos    *Chaos
+	domain   domain
+	lineage  *lineage
+}
+
+func (h *handler[A, B]) Invoke(ctx context.Context, in []byte) ([]byte, error) {
+	if !h.batch {
+		return h.invoke(ctx, in)
+	}
+
+	var seq []json.RawMessage
+	if err := json.Unmarshal(in, &seq); err != nil {
+		return nil, fmt.Errorf("typestep failed to decode batch: %w", err)
+	}
+
+	replies := make([]json.RawMessage, len(seq))
+	for i, x := range seq {
+		out, err := h.invoke(ctx, x)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = out
+	}
+
+	return json.Marshal(replies)
+}
+
+// lambda input is wire payload: envelope ⟼ claim-check ⟼ compressed ⟼ encoded ⟼ schema ⟼ A
+// the reply is produced with reverse order of layers.
+func (h *handler[A, B]) invoke(ctx context.Context, in []byte) ([]byte, error) {
+	ctx, in, err := unwrap(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("typestep failed to unwrap envelope: %w", err)
+	}
+
+	if h.offload != nil {
+		val, err := h.offload.resolve(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		in = val
+	}
+
+	// Note: compressed input is inflated even if compression is disabled,
+	// the function might consume the output of other function.
+	in, err = inflate(in)
+	if err != nil {
+		return nil, fmt.Errorf("typestep failed to inflate input: %w", err)
+	}
+
+	a, err := decode[A](h.codec, in)
+	if err != nil {
+		return nil, fmt.Errorf("typestep failed to decode input: %w", err)
+	}
+
+	if h.chaos != nil {
+		if err := h.chaos.inject(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.lineage != nil {
+		if err := h.lineage.emit(ctx, "START"); err != nil {
+			log.Printf("typestep failed to emit lineage: %v", err)
+		}
+	}
+
+	b, err := h.f(ctx, a)
+	if h.lineage != nil {
+		status := "COMPLETE"
+		if err != nil {
+			status = "FAIL"
+		}
+		// Note: lineage is best effort, the function has been executed already,
+		//       failure of the run event must not cause retry of the function
+		if err := h.lineage.emit(ctx, status); err != nil {
+			log.Printf("typestep failed to emit lineage: %v", err)
+		}
+	}
+	if err != nil {
+		// Note: sensitive fields of the input are masked
+		if raw, jerr := json.Marshal(Redact(a)); jerr == nil {
+			log.Printf("typestep failed to execute function: %v, input: %s", err, raw)
+		}
+		if h.domain != nil {
+			return nil, h.domain.typed(err)
+		}
+		return nil, err
+	}
+
+	if h.metrics != nil {
+		// Note: metrics are best effort, the function has been executed already
+		if err := h.metrics.emit(b); err != nil {
+			log.Printf("typestep failed to emit metrics: %v", err)
+		}
+	}
+
+	out, err := h.codec.Encode(b)
+	if err != nil {
+		return nil, fmt.Errorf("typestep failed to encode reply: %w", err)
+	}
+	out = stamp(versionOf(reflect.TypeOf(new(B)).Elem()), out)
+
+	if h.compress {
+		out, err = deflate(out)
+		if err != nil {
+			return nil, fmt.Errorf("typestep failed to deflate reply: %w", err)
+		}
+	}
+
+	if h.offload != nil {
+		return h.offload.store(ctx, out)
+	}
+
+	return out, nil
+}
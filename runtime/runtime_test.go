@@ -0,0 +1,37 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	// GIVEN
+	h := &handler[string, string]{
+		codec: codecOf(CodecJSON),
+		batch: true,
+		f: func(ctx context.Context, s string) (string, error) {
+			return strings.ToUpper(s), nil
+		},
+	}
+
+	// WHEN
+	out, err := h.Invoke(context.Background(), []byte(`["abc","def"]`))
+
+	// THEN
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `["ABC","DEF"]` {
+		t.Errorf("unexpected reply %s", out)
+	}
+}
@@ -0,0 +1,162 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// EnvSecrets is JSON object of secrets declared by the function, the name of
+// secret ⟼ ARN of Secrets Manager secret or name of SSM parameter.
+const EnvSecrets = "TYPESTEP_SECRETS"
+
+// Vault of secrets
+type vault interface {
+	SecretValue(ctx context.Context, arn string) (string, error)
+	ParameterValue(ctx context.Context, name string) (string, error)
+}
+
+// secrets of the function, they are fetched once per cold start. Only
+// the successful fetch is cached, the failed one is retried by next lookup.
+type secrets struct {
+	sync.Mutex
+	refs   map[string]string
+	vault  vault
+	values map[string]string
+	err    error
+}
+
+var coldstart = newSecrets(os.Getenv(EnvSecrets))
+
+func newSecrets(spec string) *secrets {
+	s := &secrets{refs: map[string]string{}, vault: &awsvault{}}
+	if spec != "" {
+		if err := json.Unmarshal([]byte(spec), &s.refs); err != nil {
+			s.err = fmt.Errorf("typestep failed to decode secrets: %w", err)
+		}
+	}
+	return s
+}
+
+func (s *secrets) init(ctx context.Context) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.values != nil {
+		return nil
+	}
+
+	values := make(map[string]string, len(s.refs))
+	for name, ref := range s.refs {
+		var (
+			val string
+			err error
+		)
+		if strings.HasPrefix(ref, "arn:") {
+			val, err = s.vault.SecretValue(ctx, ref)
+		} else {
+			val, err = s.vault.ParameterValue(ctx, ref)
+		}
+		if err != nil {
+			return fmt.Errorf("typestep failed to fetch secret %s: %w", name, err)
+		}
+		values[name] = val
+	}
+
+	s.values = values
+	return nil
+}
+
+func (s *secrets) lookup(ctx context.Context, name string) (string, error) {
+	if err := s.init(ctx); err != nil {
+		return "", err
+	}
+
+	val, has := s.values[name]
+	if !has {
+		return "", fmt.Errorf("typestep secret %s is not declared by the function", name)
+	}
+	return val, nil
+}
+
+// Secret returns the value of secret declared by the function (see
+// FunctionTypedProps.Secrets). The secrets are fetched at cold start,
+// the function is safe to use within the bootstrap code of handler.
+func Secret(ctx context.Context, name string) (string, error) {
+	return coldstart.lookup(ctx, name)
+}
+
+//------------------------------------------------------------------------------
+
+// AWS Secrets Manager and SSM Parameter Store, clients are created on first use
+type awsvault struct {
+	sync.Mutex
+	secrets    *secretsmanager.Client
+	parameters *ssm.Client
+}
+
+func (v *awsvault) init(ctx context.Context) error {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.secrets != nil {
+		return nil
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	v.secrets = secretsmanager.NewFromConfig(cfg)
+	v.parameters = ssm.NewFromConfig(cfg)
+	return nil
+}
+
+func (v *awsvault) SecretValue(ctx context.Context, arn string) (string, error) {
+	if err := v.init(ctx); err != nil {
+		return "", err
+	}
+
+	val, err := v.secrets.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(arn),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(val.SecretString), nil
+}
+
+func (v *awsvault) ParameterValue(ctx context.Context, name string) (string, error) {
+	if err := v.init(ctx); err != nil {
+		return "", err
+	}
+
+	val, err := v.parameters.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(val.Parameter.Value), nil
+}
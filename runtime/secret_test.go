@@ -0,0 +1,95 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type mockVault map[string]string
+
+func (m mockVault) SecretValue(ctx context.Context, arn string) (string, error) {
+	return m.get(arn)
+}
+
+func (m mockVault) ParameterValue(ctx context.Context, name string) (string, error) {
+	return m.get(name)
+}
+
+func (m mockVault) get(key string) (string, error) {
+	val, has := m[key]
+	if !has {
+		return "", fmt.Errorf("not found %s", key)
+	}
+	return val, nil
+}
+
+func TestSecrets(t *testing.T) {
+	// GIVEN
+	s := newSecrets(`{"token":"arn:aws:secretsmanager:eu-west-1:000000000000:secret:token","endpoint":"/app/endpoint"}`)
+	s.vault = mockVault{
+		"arn:aws:secretsmanager:eu-west-1:000000000000:secret:token": "secret",
+		"/app/endpoint": "https://example.com",
+	}
+
+	// WHEN
+	token, err := s.lookup(context.Background(), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint, err := s.lookup(context.Background(), "endpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, undefined := s.lookup(context.Background(), "undefined")
+
+	// THEN
+	if token != "secret" || endpoint != "https://example.com" {
+		t.Errorf("unexpected secrets %s, %s", token, endpoint)
+	}
+	if undefined == nil {
+		t.Errorf("undeclared secret is resolved")
+	}
+}
+
+func TestSecretsFailed(t *testing.T) {
+	// GIVEN
+	s := newSecrets(`{"token":"arn:aws:secretsmanager:eu-west-1:000000000000:secret:token"}`)
+	s.vault = mockVault{}
+
+	// WHEN
+	_, err := s.lookup(context.Background(), "token")
+
+	// THEN
+	if err == nil {
+		t.Errorf("failure of vault is not reported")
+	}
+}
+
+func TestSecretsRetry(t *testing.T) {
+	// GIVEN
+	vault := mockVault{}
+	s := newSecrets(`{"token":"arn:aws:secretsmanager:eu-west-1:000000000000:secret:token"}`)
+	s.vault = vault
+
+	// WHEN
+	_, failed := s.lookup(context.Background(), "token")
+	vault["arn:aws:secretsmanager:eu-west-1:000000000000:secret:token"] = "secret"
+	token, err := s.lookup(context.Background(), "token")
+
+	// THEN
+	if failed == nil {
+		t.Errorf("failure of vault is not reported")
+	}
+	if err != nil || token != "secret" {
+		t.Errorf("failure of vault is cached: %v", err)
+	}
+}
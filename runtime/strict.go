@@ -0,0 +1,126 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TagRequired is the value of struct tag `typestep:"required"`, which
+// annotates fields required by the strict decoding (see [EnvStrict]).
+const TagRequired = "required"
+
+// strict decoding of JSON input, unknown fields and missing required fields
+// fail the decoding instead of silently zeroing them. Other codecs are used
+// as-is.
+type strict struct{ Codec }
+
+func strictOf(c Codec) Codec {
+	if _, ok := c.(jsonCodec); ok {
+		return strict{Codec: c}
+	}
+	return c
+}
+
+func (c strict) Decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	return required(data, reflect.TypeOf(v), "")
+}
+
+// required checks presence of fields annotated with [TagRequired]
+func required(data []byte, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		var seq []json.RawMessage
+		if err := json.Unmarshal(data, &seq); err != nil {
+			return nil
+		}
+		for i, x := range seq {
+			if err := required(x, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil
+		}
+		return requiredFields(obj, t, path)
+
+	default:
+		return nil
+	}
+}
+
+func requiredFields(obj map[string]json.RawMessage, t reflect.Type, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := FieldName(nil, f)
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			if err := requiredFields(obj, f.Type, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		raw, has := valueOf(obj, name)
+		if !has || string(raw) == "null" {
+			if HasTag(f, TagRequired) {
+				return fmt.Errorf("field %s.%s is required", path, name)
+			}
+			continue
+		}
+
+		if err := required(raw, f.Type, path+"."+name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// value of the field, names are matched case-insensitive as encoding/json does
+func valueOf(obj map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, has := obj[name]; has {
+		return raw, true
+	}
+	for key, raw := range obj {
+		if strings.EqualFold(key, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
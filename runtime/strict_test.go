@@ -0,0 +1,61 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type strictItem struct {
+	SKU string `json:"sku" typestep:"required,pii"`
+}
+
+type strictOrder struct {
+	ID    string       `json:"id" typestep:"required"`
+	Note  string       `json:"note,omitempty"`
+	Items []strictItem `json:"items"`
+}
+
+func TestStrict(t *testing.T) {
+	// GIVEN
+	h := &handler[strictOrder, string]{
+		codec: strictOf(codecOf(CodecJSON)),
+		f: func(ctx context.Context, order strictOrder) (string, error) {
+			return order.ID, nil
+		},
+	}
+
+	for input, expected := range map[string]string{
+		`{"id":"a","items":[{"sku":"x"}]}`:       "",
+		`{"id":"a","unknown":1}`:                 `unknown field "unknown"`,
+		`{"note":"b"}`:                           "field .id is required",
+		`{"id":null}`:                            "field .id is required",
+		`{"id":"a","items":[{"sku":"x"},{}]}`:    "field .items[1].sku is required",
+		`{"id":"a","items":[{"sku":"x","y":1}]}`: `unknown field "y"`,
+	} {
+		// WHEN
+		_, err := h.Invoke(context.Background(), []byte(input))
+
+		// THEN
+		switch {
+		case expected == "" && err != nil:
+			t.Errorf("unexpected error %v for %s", err, input)
+		case expected != "" && (err == nil || !strings.Contains(err.Error(), expected)):
+			t.Errorf("expected error %s for %s, got %v", expected, input, err)
+		}
+	}
+}
+
+func TestStrictProtobuf(t *testing.T) {
+	if _, ok := strictOf(codecOf(CodecProtobuf)).(strict); ok {
+		t.Errorf("strict decoding is applied to JSON only")
+	}
+}
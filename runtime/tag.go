@@ -0,0 +1,56 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Tag is the name of struct tag annotating fields for typestep. The tag is
+// comma-separated list of options, same as `json` tag, so that annotations
+// are combined (e.g. `typestep:"pii,required"`).
+const Tag = "typestep"
+
+// TagOptions returns options of the struct tag `typestep` of the field
+func TagOptions(f reflect.StructField) []string {
+	tag := f.Tag.Get(Tag)
+	if tag == "" {
+		return nil
+	}
+
+	seq := []string{}
+	for _, opt := range strings.Split(tag, ",") {
+		if opt = strings.TrimSpace(opt); opt != "" {
+			seq = append(seq, opt)
+		}
+	}
+	return seq
+}
+
+// HasTag checks if the field is annotated with the option (e.g. `pii`)
+func HasTag(f reflect.StructField, opt string) bool {
+	for _, x := range TagOptions(f) {
+		if x == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// TagValue returns the value of the option given as key with suffix `=`
+// (e.g. `alias=` returns `userId` for `typestep:"alias=userId"`)
+func TagValue(f reflect.StructField, key string) (string, bool) {
+	for _, x := range TagOptions(f) {
+		if strings.HasPrefix(x, key) {
+			return strings.TrimPrefix(x, key), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,42 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagOptions(t *testing.T) {
+	type T struct {
+		A string `typestep:"pii"`
+		B string `typestep:"pii,required"`
+		C string `typestep:"required, alias=userId"`
+		D string `json:"d"`
+	}
+
+	typeOf := reflect.TypeOf(T{})
+	a, _ := typeOf.FieldByName("A")
+	b, _ := typeOf.FieldByName("B")
+	c, _ := typeOf.FieldByName("C")
+	d, _ := typeOf.FieldByName("D")
+
+	if !HasTag(a, TagPII) || !HasTag(b, TagPII) || !HasTag(b, TagRequired) || !HasTag(c, TagRequired) {
+		t.Errorf("options of the tag are not parsed")
+	}
+	if HasTag(a, TagRequired) || HasTag(d, TagPII) {
+		t.Errorf("undefined options of the tag are parsed")
+	}
+	if was, ok := TagValue(c, "alias="); !ok || was != "userId" {
+		t.Errorf("unexpected value of the option %s", was)
+	}
+	if _, ok := TagValue(b, "alias="); ok {
+		t.Errorf("undefined value of the option is parsed")
+	}
+}
@@ -0,0 +1,124 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// SAM renders AWS SAM template (`template.yaml`) of functions deployed by
+// pipelines, so that `sam local invoke` exercises individual typed steps
+// with recorded payloads (e.g. fixtures). Functions keep handlers, runtime,
+// limits and the environment configured by pipelines (codecs, offloading,
+// etc.), variables referring other resources of the stack are empty,
+// supply them with `--env-vars`. The code of function is the asset staged
+// by synth, write the template into the cloud assembly directory.
+//
+//	app.Synth(nil)
+//	tmpl, err := typestep.SAM(ts)
+//	os.WriteFile("cdk.out/template.yaml", tmpl, 0644)
+//
+//	sam local invoke -t cdk.out/template.yaml -e testdata/event.json FC4345940
+//
+// Functions are identified by logical ids of the stack. The template is
+// JSON document, which is valid YAML.
+func SAM(ts TypeStep) ([]byte, error) {
+	b := ts.(*typeStep)
+	stack := awscdk.Stack_Of(b.Construct)
+
+	resources := map[string]any{}
+	for _, f := range b.functions {
+		cfn, ok := f.Node().DefaultChild().(awslambda.CfnFunction)
+		if !ok {
+			// Note: imported functions are not part of the template
+			continue
+		}
+
+		id := *stack.GetLogicalId(cfn)
+		if _, has := resources[id]; has {
+			continue
+		}
+
+		code, err := codeOf(stack, cfn)
+		if err != nil {
+			return nil, fmt.Errorf("function %s: %w", id, err)
+		}
+
+		props := map[string]any{
+			"CodeUri": code,
+			"Handler": cfn.Handler(),
+			"Runtime": cfn.Runtime(),
+		}
+		if cfn.Architectures() != nil {
+			props["Architectures"] = cfn.Architectures()
+		}
+		if cfn.Timeout() != nil {
+			props["Timeout"] = cfn.Timeout()
+		}
+		if cfn.MemorySize() != nil {
+			props["MemorySize"] = cfn.MemorySize()
+		}
+		if env := environmentOf(stack, cfn); len(env) != 0 {
+			props["Environment"] = map[string]any{"Variables": env}
+		}
+
+		resources[id] = map[string]any{
+			"Type":       "AWS::Serverless::Function",
+			"Properties": props,
+		}
+	}
+
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("pipelines do not deploy functions")
+	}
+
+	return json.MarshalIndent(
+		map[string]any{
+			"AWSTemplateFormatVersion": "2010-09-09",
+			"Transform":                "AWS::Serverless-2016-10-31",
+			"Resources":                resources,
+		},
+		"", "  ",
+	)
+}
+
+// codeOf the function is the directory of the asset within the cloud assembly
+func codeOf(stack awscdk.Stack, cfn awslambda.CfnFunction) (string, error) {
+	if path, ok := cfn.GetMetadata(jsii.String("aws:asset:path")).(string); ok && path != "" {
+		return path, nil
+	}
+
+	// Note: properties are resolved with jsii names
+	code, _ := stack.Resolve(cfn.Code()).(map[string]any)
+	if key, ok := code["s3Key"].(string); ok && strings.HasSuffix(key, ".zip") {
+		return "asset." + strings.TrimSuffix(key, ".zip"), nil
+	}
+
+	return "", fmt.Errorf("code is not the asset of the stack")
+}
+
+// environmentOf the function, references to resources are not resolvable
+// locally, they are empty.
+func environmentOf(stack awscdk.Stack, cfn awslambda.CfnFunction) map[string]string {
+	env, _ := stack.Resolve(cfn.Environment()).(map[string]any)
+	vars, _ := env["variables"].(map[string]any)
+
+	seq := map[string]string{}
+	for k, v := range vars {
+		s, _ := v.(string)
+		seq[k] = s
+	}
+	return seq
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/scud"
+	"github.com/fogfish/typestep"
+	"github.com/fogfish/typestep/internal/test"
+)
+
+func TestSAM(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	bucket := awss3.NewBucket(stack, jsii.String("Offload"), nil)
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(f, p1)
+	p3 := typestep.Join(f, p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Encoding:       "jsoniter",
+			PayloadOffload: bucket,
+		},
+	)
+	typestep.StateMachine(ts, p4)
+
+	// WHEN
+	raw, err := typestep.SAM(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	var tmpl struct {
+		Transform string `json:"Transform"`
+		Resources map[string]struct {
+			Type       string `json:"Type"`
+			Properties struct {
+				CodeUri     string `json:"CodeUri"`
+				Handler     string `json:"Handler"`
+				Runtime     string `json:"Runtime"`
+				Environment struct {
+					Variables map[string]string `json:"Variables"`
+				} `json:"Environment"`
+			} `json:"Properties"`
+		} `json:"Resources"`
+	}
+	if err := json.Unmarshal(raw, &tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	if tmpl.Transform != "AWS::Serverless-2016-10-31" {
+		t.Errorf("unexpected transform %s", tmpl.Transform)
+	}
+	if len(tmpl.Resources) != 1 {
+		t.Fatalf("unexpected functions %s", raw)
+	}
+
+	for id, fn := range tmpl.Resources {
+		if !strings.HasPrefix(id, "F") || fn.Type != "AWS::Serverless::Function" {
+			t.Errorf("unexpected function %s of %s", id, fn.Type)
+		}
+		if !strings.HasPrefix(fn.Properties.CodeUri, "asset.") || fn.Properties.Handler == "" || fn.Properties.Runtime == "" {
+			t.Errorf("unexpected properties %s", raw)
+		}
+		if fn.Properties.Environment.Variables["TYPESTEP_CODEC"] != "jsoniter" {
+			t.Errorf("environment is not defined %s", raw)
+		}
+		if v, has := fn.Properties.Environment.Variables["TYPESTEP_OFFLOAD_BUCKET"]; !has || v != "" {
+			t.Errorf("references are not empty %s", raw)
+		}
+	}
+}
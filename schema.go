@@ -0,0 +1,236 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsssm"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// JSON Schema of types, indexed by the name of contract
+type schemas map[string]map[string]any
+
+func (s schemas) register(n runtime.FieldNamer, name string, t reflect.Type) {
+	s[name] = schemaOf(n, t, map[reflect.Type]bool{})
+}
+
+// schemaOf derives JSON Schema of the type, following encoding/json conventions
+func schemaOf(n runtime.FieldNamer, t reflect.Type, visited map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(n, t.Elem(), visited)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(n, t.Elem(), visited)}
+	case reflect.Struct:
+		if visited[t] {
+			return map[string]any{"type": "object"}
+		}
+		visited[t] = true
+		defer delete(visited, t)
+
+		properties := map[string]any{}
+		required := []string{}
+		schemaOfStruct(n, t, visited, properties, &required)
+		sort.Strings(required)
+
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) != 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
+
+func schemaOfStruct(n runtime.FieldNamer, t reflect.Type, visited map[reflect.Type]bool, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = runtime.FieldName(n, f)
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			schemaOfStruct(n, f.Type, visited, properties, required)
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = schemaOf(n, f.Type, visited)
+		if was, ok := aliasOf(f); ok {
+			properties[name].(map[string]any)[schemaAlias] = was
+		}
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// schemaAlias is the extension of JSON Schema, which records the previous name
+// of the renamed field (see [TagAlias]).
+const schemaAlias = "x-typestep-alias"
+
+// incompatible lists breaking changes of the new schema against the deployed one.
+// Removed or renamed required fields, new required fields and changes of type
+// break executions running mid-flight.
+func incompatible(path string, was, now map[string]any) []string {
+	if was["type"] != now["type"] {
+		return []string{fmt.Sprintf("%s: type is changed from %v to %v", path, was["type"], now["type"])}
+	}
+
+	issues := []string{}
+	switch was["type"] {
+	case "array":
+		issues = append(issues, incompatible(path+"[]", objectOf(was["items"]), objectOf(now["items"]))...)
+	case "object":
+		wasProps, nowProps := objectOf(was["properties"]), objectOf(now["properties"])
+		wasRequired, nowRequired := setOf(was["required"]), setOf(now["required"])
+
+		renamed := map[string]string{}
+		for _, key := range sortedKeys(nowProps) {
+			if was, ok := objectOf(nowProps[key])[schemaAlias].(string); ok {
+				renamed[was] = key
+			}
+		}
+
+		for _, key := range sortedKeys(wasProps) {
+			if _, has := nowProps[key]; !has {
+				// Note: renamed fields are mapped by the shim
+				if now, has := renamed[key]; has {
+					issues = append(issues, incompatible(path+"."+now, objectOf(wasProps[key]), objectOf(nowProps[now]))...)
+					continue
+				}
+				if wasRequired[key] {
+					issues = append(issues, fmt.Sprintf("%s.%s: required field is removed", path, key))
+				}
+				continue
+			}
+			issues = append(issues, incompatible(path+"."+key, objectOf(wasProps[key]), objectOf(nowProps[key]))...)
+		}
+
+		for _, key := range sortedKeys(nowProps) {
+			was, _ := objectOf(nowProps[key])[schemaAlias].(string)
+			_, isRenamed := wasProps[was]
+			if _, has := wasProps[key]; !has && !isRenamed && nowRequired[key] {
+				issues = append(issues, fmt.Sprintf("%s.%s: required field is added", path, key))
+			}
+		}
+	}
+
+	return issues
+}
+
+func objectOf(x any) map[string]any {
+	if m, ok := x.(map[string]any); ok {
+		return m
+	}
+	return map[string]any{}
+}
+
+func setOf(x any) map[string]bool {
+	set := map[string]bool{}
+	switch seq := x.(type) {
+	case []any:
+		for _, v := range seq {
+			set[fmt.Sprint(v)] = true
+		}
+	case []string:
+		for _, v := range seq {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// gate records schemas of the pipeline into SSM parameter and checks them
+// against the schemas recorded by the previous deployment. Breaking changes
+// are reported as synth errors. The lookup of deployed schemas requires
+// the stack bound to account and region, the gate fails otherwise.
+func (ts *typeStep) gate() error {
+	raw, err := json.Marshal(ts.schemas)
+	if err != nil {
+		return err
+	}
+
+	stack := awscdk.Stack_Of(ts.scope)
+	name := "/typestep/" + *stack.StackName() + "/" + ts.name()
+
+	if *awscdk.Token_IsUnresolved(stack.Account()) || *awscdk.Token_IsUnresolved(stack.Region()) {
+		return fmt.Errorf("schema compatibility of %s requires stack %s bound to account and region", ts.name(), *stack.StackName())
+	}
+
+	deployed := awsssm.StringParameter_ValueFromLookup(ts.scope, jsii.String(name), jsii.String(""))
+	was := schemas{}
+	if deployed != nil && json.Unmarshal([]byte(*deployed), &was) == nil {
+		for _, key := range sortedKeys(ts.schemas) {
+			deployed, has := was[key]
+			if !has {
+				continue
+			}
+			for _, issue := range incompatible(key, deployed, ts.schemas[key]) {
+				awscdk.Annotations_Of(ts.scope).AddError(jsii.String("typestep incompatible schema " + issue))
+			}
+		}
+	}
+
+	awsssm.NewStringParameter(ts.scope, jsii.String("Schema"),
+		&awsssm.StringParameterProps{
+			ParameterName: jsii.String(name),
+			StringValue:   jsii.String(string(raw)),
+			Tier:          awsssm.ParameterTier_INTELLIGENT_TIERING,
+		},
+	)
+
+	return nil
+}
@@ -0,0 +1,94 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Bio  string `json:"bio,omitempty"`
+}
+
+func TestSchemaCompatibility(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(&awscdk.AppProps{
+		Context: &map[string]any{
+			"ssm:account=000000000000:parameterName=/typestep/Test/Pipe:region=eu-west-1": `{"typestep_test.User":{"type":"object","properties":{"id":{"type":"string"},"email":{"type":"string"}},"required":["email","id"]}}`,
+		},
+	})
+	stack := awscdk.NewStack(app, jsii.String("Test"),
+		&awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("000000000000"),
+				Region:  jsii.String("eu-west-1"),
+			},
+		},
+	)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[User, User](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			SchemaCompatibility: true,
+		},
+	)
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[User](event))))
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::SSM::Parameter"),
+		map[string]any{
+			"Name":  "/typestep/Test/Pipe",
+			"Value": `{"typestep_test.User":{"properties":{"bio":{"type":"string"},"id":{"type":"string"},"name":{"type":"string"}},"required":["id","name"],"type":"object"}}`,
+		},
+	)
+
+	annotations := assertions.Annotations_FromStack(stack)
+	annotations.HasError(jsii.String("*"), assertions.Match_StringLikeRegexp(jsii.String("typestep_test.User.email: required field is removed")))
+	annotations.HasError(jsii.String("*"), assertions.Match_StringLikeRegexp(jsii.String("typestep_test.User.name: required field is added")))
+}
+
+func TestSchemaCompatibilityUnbound(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[User, User](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("schema compatibility shall require stack bound to account and region")
+		}
+	}()
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			SchemaCompatibility: true,
+		},
+	)
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[User](event))))
+}
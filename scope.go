@@ -0,0 +1,53 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import "github.com/fogfish/golem/duct"
+
+// Scope is the sequence of elements B within the context P of the parent
+// payload (e.g. categories of the user).
+type Scope[P, B any] struct {
+	Scope P   `json:"scope"`
+	Items []B `json:"items"`
+}
+
+// Scoped is the element B of the sequence combined with the context P of
+// the parent payload, it is the input of each iteration (see [LiftS]).
+type Scoped[P, B any] struct {
+	Scope P `json:"scope"`
+	Item  B `json:"item"`
+}
+
+// LiftS is equivalent to Lift but each iteration receives the element of
+// the sequence together with the context of the parent payload. The input
+// of the iteration is composed by Map's ItemSelector, upstream functions do
+// not need to denormalize the context into every element.
+func LiftS[A, P, B, C any](
+	f F[Scoped[P, B], C],
+	m duct.Morphism[A, Scope[P, B]],
+) duct.Morphism[A, C] {
+	fn := newLambda(1, f)
+	fn.scoped = true
+
+	// Note: the type is phantom, the sequence is selected by the Map state
+	seq := duct.Morphism[A, []Scoped[P, B]](m)
+	return duct.LiftF(duct.L2[Scoped[P, B], C](fn), seq)
+}
+
+// items of the sequence at path and selector of the iteration's input
+func itemsOf(path string, scoped bool) (string, *map[string]any) {
+	if !scoped {
+		return path, nil
+	}
+
+	return path + ".items", &map[string]any{
+		"scope.$": path + ".scope",
+		"item.$":  "$$.Map.Item.Value",
+	}
+}
@@ -0,0 +1,47 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import "github.com/fogfish/golem/duct"
+
+// Seam is the phantom input of segments, it is substituted by the input of
+// the pipeline when the segment is included.
+type Seam struct{}
+
+// Segment is a reusable sub-composition 𝑠: A ⟼ B (e.g. validate → enrich →
+// normalize), which is spliced into multiple pipelines using [Include]. The
+// segment is defined with same combinators as pipelines:
+//
+//	normalize := func(m duct.Morphism[typestep.Seam, Order]) duct.Morphism[typestep.Seam, Order] {
+//		return typestep.Join(enrich, typestep.Join(validate, m))
+//	}
+type Segment[A, B any] func(duct.Morphism[Seam, A]) duct.Morphism[Seam, B]
+
+// Include splices the segment 𝑠: B ⟼ C into morphism 𝑚: A ⟼ B producing
+// a new morphism 𝑚: A ⟼ C.
+func Include[A, B, C any](s Segment[B, C], m duct.Morphism[A, B]) duct.Morphism[A, C] {
+	// Note: the input type is phantom, morphism is equivalent to its code
+	return duct.Morphism[A, C](s(duct.Morphism[Seam, B](m)))
+}
+
+// If splices the segment 𝑠: B ⟼ B into morphism 𝑚: A ⟼ B only if it is
+// enabled, the disabled segment is elided at synth, the state machine has no
+// states of it, neither runtime Choice. It is the compile-time switch of
+// experimental stages, shipped dark behind Go build tags.
+//
+//	//go:build experimental
+//	const experimental = true
+//
+//	typestep.If(experimental, scoring, m)
+func If[A, B any](enabled bool, s Segment[B, B], m duct.Morphism[A, B]) duct.Morphism[A, B] {
+	if !enabled {
+		return m
+	}
+	return Include(s, m)
+}
@@ -0,0 +1,102 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep"
+)
+
+func TestSegment(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, Contact](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[Contact, Contact](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	c := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("C"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	var seg typestep.Segment[string, Contact] = func(m duct.Morphism[typestep.Seam, string]) duct.Morphism[typestep.Seam, Contact] {
+		return typestep.Join(b, typestep.Join(a, m))
+	}
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Include(seg, typestep.From[string](event))))
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Include(seg, typestep.Join(c, typestep.From[string](event, "Other")))))
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(2))
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"StartAt":"MapA"`,
+		`"StartAt":"MapC"`,
+		`"MapC":{"Next":"MapA"`,
+		`"MapA":{"Next":"MapB"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestIf(t *testing.T) {
+	for enabled, expect := range map[bool]string{
+		true:  `"MapA":{"Next":"MapB"`,
+		false: `"MapA":{"Next":"Sink"`,
+	} {
+		// GIVEN
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+		event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+		a := typestep.Function_FromFunctionArn[string, Contact](stack, jsii.String("A"),
+			jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+		b := typestep.Function_FromFunctionArn[Contact, Contact](stack, jsii.String("B"),
+			jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+		var seg typestep.Segment[Contact, Contact] = func(m duct.Morphism[typestep.Seam, Contact]) duct.Morphism[typestep.Seam, Contact] {
+			return typestep.Join(b, m)
+		}
+
+		// THEN
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+		typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.If(enabled, seg, typestep.Join(a, typestep.From[string](event)))))
+
+		// WHEN
+		template := assertions.Template_FromStack(stack, nil)
+		asl := definitionOf(template)
+
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+		if !enabled && strings.Contains(asl, "MapB") {
+			t.Errorf("disabled segment is not elided")
+		}
+	}
+}
@@ -0,0 +1,385 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+const (
+	// detail-type of events queuing executions awaiting the slot of semaphore
+	semaphoreDetailType = "typestep.semaphore"
+
+	// the queued execution attempts to acquire the slot again after the wait,
+	// so that the lost wake up (e.g. failure of router) does not stall it.
+	semaphoreWait = 5 * time.Minute
+
+	// the limit of queued executions, task tokens are kept within the item
+	// of semaphore, which is limited to 400KB.
+	maxWaiters = 256
+)
+
+// Semaphore limits the number of concurrent executions passing the step of
+// the morphism 𝑚: A ⟼ B, so that unbounded executions do not overwhelm
+// the fragile downstream system. The execution acquires the slot of the
+// semaphore, other executions are queued with the callback pattern and
+// resumed one by one as slots are released. The queued execution attempts
+// to acquire the slot again every 5 minutes, up to 256 executions are queued.
+// The slot is held until the execution completes with any status, the release
+// is triggered by the status change event of the execution. Steps of
+// the pipeline own slots independently. The semaphore is the item of
+// DynamoDB table, which uses string partition key `key`. The payload is
+// passed as-is.
+//
+//	typestep.Join(Export, typestep.Semaphore(table, "erp", 10, m))
+//
+// Only standard workflows are supported, status of express workflows is
+// not observable. The semaphore is not supported by nested computations.
+func Semaphore[A, B any](table awsdynamodb.ITable, name string, limit int, m duct.Morphism[A, B]) duct.Morphism[A, B] {
+	f := semaphore{table: table, name: name, limit: limit}
+	return duct.Join(duct.L2[B, B](f), m)
+}
+
+type semaphore struct {
+	table awsdynamodb.ITable
+	name  string
+	limit int
+	id    string
+}
+
+// owner of the slot is the step of the execution, steps acquiring the same
+// semaphore within the execution own slots independently.
+func (f semaphore) owner(execution string) string {
+	return execution + " & '/" + f.id + "'"
+}
+
+// semaphore acquires the slot, the step of the execution is the owner of
+// the slot. The execution is queued if slots are exhausted.
+//
+//	UpdateItem ⟼ (exhausted) PutEvents.waitForTaskToken ⟼ UpdateItem
+//	                                                    ⟼ (timeout) UpdateItem
+//	           ⟼ ...
+func (ts *typeStep) semaphore(f semaphore) error {
+	if len(ts.stack) != 1 {
+		return fmt.Errorf("semaphore %s is not supported by nested computations", f.name)
+	}
+	if ts.express() {
+		return fmt.Errorf("semaphore %s is not supported by express workflows", f.name)
+	}
+
+	f.id = ts.idOf("Semaphore")
+	route := ts.name() + f.id
+
+	acquire := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(f.id),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table: f.table,
+			Key: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String(f.name)),
+			},
+			UpdateExpression:    jsii.String("ADD #slots :one SET #owner = :now, #waiters = if_not_exists(#waiters, :empty)"),
+			ConditionExpression: jsii.String("attribute_not_exists(#slots) OR #slots < :limit"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#slots":   jsii.String("slots"),
+				"#owner":   jsii.String("{% " + f.owner("$states.context.Execution.Id") + " %}"),
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":one":   awsstepfunctionstasks.DynamoAttributeValue_FromNumber(jsii.Number(1)),
+				":limit": awsstepfunctionstasks.DynamoAttributeValue_NumberFromString(jsii.String(strconv.Itoa(f.limit))),
+				":now":   awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $now() %}")),
+				":empty": awsstepfunctionstasks.DynamoAttributeValue_FromList(&[]awsstepfunctionstasks.DynamoAttributeValue{}),
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	queue := awsstepfunctionstasks.EventBridgePutEvents_Jsonata(ts.scope, jsii.String(f.id+"Queue"),
+		&awsstepfunctionstasks.EventBridgePutEventsJsonataProps{
+			IntegrationPattern: awsstepfunctions.IntegrationPattern_WAIT_FOR_TASK_TOKEN,
+			Entries: &[]*awsstepfunctionstasks.EventBridgePutEventsEntry{
+				{
+					Source:     jsii.String("typestep"),
+					DetailType: jsii.String(semaphoreDetailType),
+					Detail: awsstepfunctions.TaskInput_FromObject(&map[string]any{
+						"semaphore": route,
+						"token":     "{% $states.context.Task.Token %}",
+					}),
+				},
+			},
+			TaskTimeout: awsstepfunctions.Timeout_Duration(awscdk.Duration_Seconds(jsii.Number(semaphoreWait.Seconds()))),
+			Outputs:     "{% $states.input %}",
+		},
+	)
+	queue.Next(acquire)
+	ts.sizes[len(ts.sizes)-1] += 1
+
+	// Note: the queued execution does not own the slot, the token of timed out
+	// task remains queued, the release skips it.
+	queue.AddCatch(acquire,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("States.Timeout"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	acquire.AddCatch(queue,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	ts.semaphores = append(ts.semaphores, f)
+	ts.semaphoreRouter(route, f)
+	ts.append(acquire)
+	return nil
+}
+
+// semaphoreRouter is the express state machine that queues executions, the
+// execution is resumed immediately if the slot is released meanwhile. The
+// execution is not queued if the queue is full, it attempts to acquire the
+// slot after the wait.
+//
+//	UpdateItem ⟼ (failed) GetItem ⟼ (available) SendTaskSuccess
+//	                              ⟼ (full) Pass
+func (ts *typeStep) semaphoreRouter(route string, f semaphore) {
+	key := &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+		"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String(f.name)),
+	}
+
+	enqueue := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(f.id+"Enqueue"),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table:               f.table,
+			Key:                 key,
+			UpdateExpression:    jsii.String("SET #waiters = list_append(if_not_exists(#waiters, :empty), :token)"),
+			ConditionExpression: jsii.String("#slots >= :limit AND (attribute_not_exists(#waiters) OR size(#waiters) < :waiters)"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#slots":   jsii.String("slots"),
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":limit":   awsstepfunctionstasks.DynamoAttributeValue_NumberFromString(jsii.String(strconv.Itoa(f.limit))),
+				":waiters": awsstepfunctionstasks.DynamoAttributeValue_NumberFromString(jsii.String(strconv.Itoa(maxWaiters))),
+				":empty":   awsstepfunctionstasks.DynamoAttributeValue_FromList(&[]awsstepfunctionstasks.DynamoAttributeValue{}),
+				":token": awsstepfunctionstasks.DynamoAttributeValue_FromList(
+					&[]awsstepfunctionstasks.DynamoAttributeValue{
+						awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String("{% $states.input.detail.token %}")),
+					},
+				),
+			},
+		},
+	)
+	transient(enqueue, "DynamoDB.ConditionalCheckFailedException")
+
+	resume := ts.wake(f.id+"Resume", "{% $states.input.detail.token %}")
+	transient(resume, "Sfn.TaskTimedOutException", "Sfn.TaskDoesNotExistException", "Sfn.InvalidTokenException")
+
+	// Note: the execution waits for the timeout if the queue is full
+	full := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String(f.id+"Full"), &awsstepfunctions.PassJsonataProps{})
+
+	lookup := awsstepfunctionstasks.DynamoGetItem_Jsonata(ts.scope, jsii.String(f.id+"Slots"),
+		&awsstepfunctionstasks.DynamoGetItemJsonataProps{
+			Table:          f.table,
+			Key:            key,
+			ConsistentRead: jsii.Bool(true),
+			Outputs: map[string]any{
+				"detail": "{% $states.input.detail %}",
+				"slots":  "{% $exists($states.result.Item.slots) ? $number($states.result.Item.slots.N) : 0 %}",
+			},
+		},
+	)
+	transient(lookup)
+
+	available := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(f.id+"Available"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String(fmt.Sprintf("{%% $states.input.slots < %d %%}", f.limit))),
+		resume,
+		nil,
+	).Otherwise(full)
+	lookup.Next(available)
+
+	enqueue.AddCatch(lookup,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	router := awsstepfunctions.NewStateMachine(ts.scope, jsii.String(f.id+"Router"),
+		&awsstepfunctions.StateMachineProps{
+			StateMachineType: awsstepfunctions.StateMachineType_EXPRESS,
+			DefinitionBody:   awsstepfunctions.ChainDefinitionBody_FromChainable(enqueue),
+		},
+	)
+
+	awsevents.NewRule(ts.scope, jsii.String(f.id+"Registration"),
+		&awsevents.RuleProps{
+			EventPattern: &awsevents.EventPattern{
+				Source:     jsii.Strings("typestep"),
+				DetailType: jsii.Strings(semaphoreDetailType),
+				Detail:     &map[string]any{"semaphore": []string{route}},
+			},
+			Targets: &[]awsevents.IRuleTarget{
+				awseventstargets.NewSfnStateMachine(router, &awseventstargets.SfnStateMachineProps{}),
+			},
+		},
+	)
+}
+
+// release slots of semaphores and locks of mutexes owned by completed
+// executions of the state machine, the release is the express state machine
+// triggered by the status change event of the execution.
+//
+//	UpdateItem ⟼ UpdateItem ⟼ ...
+func (ts *typeStep) release(states awsstepfunctions.StateMachine) {
+	var start awsstepfunctions.IChainable
+	var last awsstepfunctions.Pass
+	then := func(head awsstepfunctions.IChainable, tail awsstepfunctions.Pass) {
+		if last == nil {
+			start = head
+		} else {
+			last.Next(head)
+		}
+		last = tail
+	}
+
+	for i, f := range ts.semaphores {
+		then(ts.releaseSlot(i, f))
+	}
+	for _, f := range ts.mutexes {
+		then(ts.unlock(f))
+	}
+
+	machine := awsstepfunctions.NewStateMachine(ts.scope, jsii.String("ReleaseStateMachine"),
+		&awsstepfunctions.StateMachineProps{
+			StateMachineType: awsstepfunctions.StateMachineType_EXPRESS,
+			DefinitionBody:   awsstepfunctions.ChainDefinitionBody_FromChainable(start),
+		},
+	)
+
+	rule := awsevents.NewRule(ts.scope, jsii.String("ReleaseRule"),
+		&awsevents.RuleProps{
+			EventPattern: &awsevents.EventPattern{
+				Source:     jsii.Strings("aws.states"),
+				DetailType: jsii.Strings("Step Functions Execution Status Change"),
+				Detail: &map[string]any{
+					"stateMachineArn": []any{states.StateMachineArn()},
+					"status":          []any{"SUCCEEDED", "FAILED", "TIMED_OUT", "ABORTED"},
+				},
+			},
+		},
+	)
+	rule.AddTarget(
+		awseventstargets.NewSfnStateMachine(machine, &awseventstargets.SfnStateMachineProps{}),
+	)
+}
+
+// releaseSlot of the semaphore owned by the execution and resumes the first
+// queued execution, queued executions which are not running are skipped.
+//
+//	UpdateItem ⟼ (queued) SendTaskSuccess ⟼ ...
+//	                     ⟼ (failed) UpdateItem ⟼ ...
+func (ts *typeStep) releaseSlot(i int, f semaphore) (awsstepfunctions.IChainable, awsstepfunctions.Pass) {
+	id := fmt.Sprintf("Release%d", i)
+	key := &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+		"key": awsstepfunctionstasks.DynamoAttributeValue_FromString(jsii.String(f.name)),
+	}
+	outputs := map[string]any{
+		"detail": "{% $states.input.detail %}",
+		"token":  "{% $states.result.Attributes.waiters.L[0].S %}",
+	}
+
+	// Note: the execution does not own the slot if it fails before
+	// the semaphore is acquired.
+	next := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String(id+"Skip"), &awsstepfunctions.PassJsonataProps{})
+
+	release := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(id),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table:               f.table,
+			Key:                 key,
+			UpdateExpression:    jsii.String("REMOVE #owner, #waiters[0] ADD #slots :minus"),
+			ConditionExpression: jsii.String("attribute_exists(#owner)"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#slots":   jsii.String("slots"),
+				"#owner":   jsii.String("{% " + f.owner("$states.input.detail.executionArn") + " %}"),
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":minus": awsstepfunctionstasks.DynamoAttributeValue_FromNumber(jsii.Number(-1)),
+			},
+			ReturnValues: awsstepfunctionstasks.DynamoReturnValues_ALL_OLD,
+			Outputs:      outputs,
+		},
+	)
+	transient(release, "DynamoDB.ConditionalCheckFailedException")
+	release.AddCatch(next,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	pop := awsstepfunctionstasks.DynamoUpdateItem_Jsonata(ts.scope, jsii.String(id+"Next"),
+		&awsstepfunctionstasks.DynamoUpdateItemJsonataProps{
+			Table:               f.table,
+			Key:                 key,
+			UpdateExpression:    jsii.String("REMOVE #waiters[0]"),
+			ConditionExpression: jsii.String("size(#waiters) > :zero"),
+			ExpressionAttributeNames: &map[string]*string{
+				"#waiters": jsii.String("waiters"),
+			},
+			ExpressionAttributeValues: &map[string]awsstepfunctionstasks.DynamoAttributeValue{
+				":zero": awsstepfunctionstasks.DynamoAttributeValue_FromNumber(jsii.Number(0)),
+			},
+			ReturnValues: awsstepfunctionstasks.DynamoReturnValues_ALL_OLD,
+			Outputs:      outputs,
+		},
+	)
+	transient(pop, "DynamoDB.ConditionalCheckFailedException")
+	pop.AddCatch(next,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("DynamoDB.ConditionalCheckFailedException"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+
+	wake := ts.wake(id+"Wake", "{% $states.input.token %}")
+	transient(wake, "Sfn.TaskTimedOutException", "Sfn.TaskDoesNotExistException", "Sfn.InvalidTokenException")
+	wake.AddCatch(pop,
+		&awsstepfunctions.CatchProps{
+			Errors:  jsii.Strings("States.ALL"),
+			Outputs: "{% $states.input %}",
+		},
+	)
+	wake.Next(next)
+
+	queued := awsstepfunctions.Choice_Jsonata(ts.scope, jsii.String(id+"Queued"),
+		&awsstepfunctions.ChoiceJsonataProps{},
+	).When(
+		awsstepfunctions.Condition_Jsonata(jsii.String("{% $exists($states.input.token) %}")),
+		wake,
+		nil,
+	).Otherwise(next)
+	release.Next(queued)
+	pop.Next(queued)
+
+	return release, next
+}
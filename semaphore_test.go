@@ -0,0 +1,159 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestSemaphore(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("semaphore"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Semaphore(table, "erp", 10, p1)
+	p3 := typestep.Join(a, p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p4)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(3))
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"source":      []any{"typestep"},
+				"detail-type": []any{"typestep.semaphore"},
+				"detail":      map[string]any{"semaphore": []any{"PipeSemaphore0"}},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{
+				"source":      []any{"aws.states"},
+				"detail-type": []any{"Step Functions Execution Status Change"},
+				"detail": map[string]any{
+					"stateMachineArn": []any{map[string]any{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PipeStateMachine"))}},
+					"status":          []any{"SUCCEEDED", "FAILED", "TIMED_OUT", "ABORTED"},
+				},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Semaphore0":{"QueryLanguage":"JSONata","Next":"MapA"`,
+		`"ConditionExpression":"attribute_not_exists(#slots) OR #slots < :limit"`,
+		`"#owner":"{% $states.context.Execution.Id & '/Semaphore0' %}"`,
+		`":limit":{"N":"10"}`,
+		`"Catch":[{"Output":"{% $states.input %}","ErrorEquals":["DynamoDB.ConditionalCheckFailedException"],"Next":"Semaphore0Queue"}]`,
+		`"Semaphore0Queue":{"QueryLanguage":"JSONata","Next":"Semaphore0","Catch":[{"Output":"{% $states.input %}","ErrorEquals":["States.Timeout"],"Next":"Semaphore0"}],"Type":"Task","TimeoutSeconds":300`,
+		`"Resource":"arn:`,
+		`:states:::events:putEvents.waitForTaskToken"`,
+		`"UpdateExpression":"SET #waiters = list_append(if_not_exists(#waiters, :empty), :token)"`,
+		`"ConditionExpression":"#slots >= :limit AND (attribute_not_exists(#waiters) OR size(#waiters) < :waiters)"`,
+		`":waiters":{"N":"256"}`,
+		`"Retry":[{"ErrorEquals":["DynamoDB.ConditionalCheckFailedException"],"MaxAttempts":0},{"ErrorEquals":["States.ALL"],"MaxAttempts":3,"BackoffRate":2}]`,
+		`"Catch":[{"Output":"{% $states.input %}","ErrorEquals":["DynamoDB.ConditionalCheckFailedException"],"Next":"Semaphore0Slots"}]`,
+		`{"Condition":"{% $states.input.slots < 10 %}","Next":"Semaphore0Resume"}],"Default":"Semaphore0Full"}`,
+		`"UpdateExpression":"REMOVE #owner, #waiters[0] ADD #slots :minus"`,
+		`"#owner":"{% $states.input.detail.executionArn & '/Semaphore0' %}"`,
+		`"token":"{% $states.result.Attributes.waiters.L[0].S %}"`,
+		`"Release0Queued":{"Type":"Choice"`,
+		`"Retry":[{"ErrorEquals":["Sfn.TaskTimedOutException","Sfn.TaskDoesNotExistException","Sfn.InvalidTokenException"],"MaxAttempts":0},{"ErrorEquals":["States.ALL"],"MaxAttempts":3,"BackoffRate":2}],"Catch":[{"Output":"{% $states.input %}","ErrorEquals":["States.ALL"],"Next":"Release0Next"}]`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestSemaphoreOwner(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("semaphore"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Semaphore(table, "erp", 10, p1)
+	p3 := typestep.Join(a, p2)
+	p4 := typestep.Semaphore(table, "erp", 10, p3)
+	p5 := typestep.ToQueue(queue, p4)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p5)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"#owner":"{% $states.context.Execution.Id & '/Semaphore0' %}"`,
+		`"#owner":"{% $states.context.Execution.Id & '/Semaphore1' %}"`,
+		`"#owner":"{% $states.input.detail.executionArn & '/Semaphore0' %}"`,
+		`"#owner":"{% $states.input.detail.executionArn & '/Semaphore1' %}"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestSemaphoreNested(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("semaphore"))
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.Semaphore(table, "erp", 10, typestep.Wrap(p2))
+	p4 := typestep.ToQueue(queue, p3)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("nested semaphore is accepted")
+		}
+	}()
+	typestep.StateMachine(ts, p4)
+}
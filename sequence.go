@@ -0,0 +1,124 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// SortOrder of the sequence
+type SortOrder bool
+
+const (
+	Ascending  SortOrder = false
+	Descending SortOrder = true
+)
+
+// Sort the sequence 𝑚: A ⟼ []B by the key field of elements, the sort is
+// stable. Consumers that care about ordering (e.g. top-N or pagination) do
+// not need a dedicated function.
+//
+//	typestep.Sort(func(p *Product) *float64 { return &p.Price }, typestep.Descending, m)
+func Sort[A, B, K any](key func(*B) *K, order SortOrder, m duct.Morphism[A, []B]) duct.Morphism[A, []B] {
+	return duct.Join(duct.L2[[]B, []B](sorting{path: pathOf(key), order: order}), m)
+}
+
+// sort of the sequence by the key
+type sorting struct {
+	path  fieldPath
+	order SortOrder
+}
+
+// expression of the sort as JSONata over the sequence at path
+func (f sorting) expression(n runtime.FieldNamer, path string) string {
+	key := ""
+	for _, x := range f.path.names(n) {
+		key += ".`" + x + "`"
+	}
+
+	op := ">"
+	if f.order == Descending {
+		op = "<"
+	}
+
+	return fmt.Sprintf("{%% $sort($states.input%s, function($l, $r) { $l%s %s $r%s }) %%}",
+		strings.TrimPrefix(path, "$"), key, op, key)
+}
+
+// Take first n elements of the sequence 𝑚: A ⟼ []B, it bounds the size of
+// fan-out declaratively and caps the cost when upstream function returns
+// unbounded result sets. The sequence is sliced with JSONPath, intrinsic
+// functions do not provide slices.
+func Take[A, B any](n int, m duct.Morphism[A, []B]) duct.Morphism[A, []B] {
+	if n <= 0 {
+		panic(fmt.Errorf("take requires positive number of elements"))
+	}
+	return duct.Join(duct.L2[[]B, []B](take{n: n}), m)
+}
+
+// first n elements of the sequence
+type take struct {
+	n int
+}
+
+// take elements of the sequence
+func (ts *typeStep) take(f take) {
+	pass := awsstepfunctions.NewPass(ts.scope, jsii.String(ts.idOf("Take")),
+		&awsstepfunctions.PassProps{
+			Parameters: &map[string]any{
+				"Payload.$": fmt.Sprintf("%s[0:%d]", ts.args, f.n),
+			},
+		},
+	)
+	ts.append(pass)
+}
+
+// Sample the fraction of elements of the sequence 𝑚: A ⟼ []B at random, use it
+// for shadow pipelines and gradual rollout of expensive downstream steps.
+func Sample[A, B any](fraction float64, m duct.Morphism[A, []B]) duct.Morphism[A, []B] {
+	return duct.Join(duct.L2[[]B, []B](sampling{fraction: fraction}), m)
+}
+
+// SampleBy is equivalent to [Sample] but the subset is deterministic, elements
+// are sampled by the hash of the key field. The same element is either always
+// sampled or always skipped across executions.
+func SampleBy[A, B, K any](fraction float64, key func(*B) *K, m duct.Morphism[A, []B]) duct.Morphism[A, []B] {
+	return duct.Join(duct.L2[[]B, []B](sampling{fraction: fraction, path: pathOf(key)}), m)
+}
+
+// sampling of the sequence, either random or by the key
+type sampling struct {
+	fraction float64
+	path     fieldPath
+}
+
+// hexadecimal digits of the hash
+const hexdigits = `{"0":0,"1":1,"2":2,"3":3,"4":4,"5":5,"6":6,"7":7,"8":8,"9":9,"a":10,"b":11,"c":12,"d":13,"e":14,"f":15,"A":10,"B":11,"C":12,"D":13,"E":14,"F":15}`
+
+// expression of the sampling as JSONata over the sequence at path
+func (f sampling) expression(n runtime.FieldNamer, path string) string {
+	dice := "$random()"
+	if f.path != nil {
+		key := "$x"
+		for _, x := range f.path.names(n) {
+			key += ".`" + x + "`"
+		}
+		// Note: the first 32 bits of the hash are scaled into [0, 1)
+		dice = fmt.Sprintf("$reduce($split($substring($hash($string(%s), 'MD5'), 0, 8), ''), function($a, $c) { $a * 16 + $lookup(%s, $c) }, 0) / 4294967296", key, hexdigits)
+	}
+
+	return fmt.Sprintf("{%% $reduce($states.input%s, function($acc, $x) { %s < %v ? $append($acc, [$x]) : $acc }, []) %%}",
+		strings.TrimPrefix(path, "$"), dice, f.fraction)
+}
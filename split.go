@@ -0,0 +1,124 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Split the pair produced by morphism 𝑚: A ⟼ Pair[B, C] into two
+// continuations 𝑙: B ⟼ D and 𝑟: C ⟼ E, which are composed with same
+// combinators as pipelines starting from [Branch]. Continuations run
+// independently in parallel, so that one computation feeds two differently
+// typed continuations, results are paired again as Pair[D, E]. Continuation
+// yielding results to the sink contributes nothing to the pair.
+//
+//	left := typestep.ToQueue(orders, typestep.Join(Bill, typestep.Branch[Order]()))
+//	right := typestep.ToEventBus("shipment", bus, typestep.Branch[Shipment]())
+//	typestep.Split(left, right, typestep.Join(Checkout, a))
+func Split[A, B, C, D, E any](
+	left duct.Morphism[B, D],
+	right duct.Morphism[C, E],
+	m duct.Morphism[A, Pair[B, C]],
+) duct.Morphism[A, Pair[D, E]] {
+	f := split{
+		left:      left,
+		right:     right,
+		voidLeft:  isVoid[D](),
+		voidRight: isVoid[E](),
+	}
+	return duct.Join(duct.L2[Pair[B, C], Pair[D, E]](f), m)
+}
+
+type split struct {
+	left, right         interface{ Apply(duct.Visitor) error }
+	voidLeft, voidRight bool
+}
+
+// continuations yielding to sinks are typed by duct.Void
+func isVoid[T any]() bool {
+	return reflect.TypeOf(new(T)).Elem() == reflect.TypeOf(new(duct.Void)).Elem()
+}
+
+// split runs continuations as branches of the parallel state, each branch
+// starts with the projection of the pair
+//
+//	Parallel ⟼ [ Pass(a) ⟼ left..., Pass(b) ⟼ right... ] ⟼ Pair
+func (ts *typeStep) split(f split) error {
+	id := ts.idOf("Split")
+	args := ts.args
+
+	left, lsize, largs, err := ts.continuation(id+"A", "$.a", f.left)
+	if err != nil {
+		return err
+	}
+
+	right, rsize, rargs, err := ts.continuation(id+"B", "$.b", f.right)
+	if err != nil {
+		return err
+	}
+
+	pair := map[string]any{}
+	if !f.voidLeft {
+		pair["a.$"] = "$[0]" + strings.TrimPrefix(largs, "$")
+	}
+	if !f.voidRight {
+		pair["b.$"] = "$[1]" + strings.TrimPrefix(rargs, "$")
+	}
+
+	parallel := awsstepfunctions.NewParallel(ts.scope, jsii.String(id),
+		&awsstepfunctions.ParallelProps{
+			InputPath: jsii.String(args),
+			ResultSelector: &map[string]any{
+				"Payload": pair,
+			},
+		},
+	)
+	parallel.Branch(left, right)
+	ts.appendChain(parallel, id, lsize+rsize+1)
+
+	return nil
+}
+
+// continuation compiles the branch of split into the stack of the builder,
+// it returns the chain, its size and the path to the result of the branch.
+func (ts *typeStep) continuation(id, path string, m interface{ Apply(duct.Visitor) error }) (awsstepfunctions.IChainable, int, string, error) {
+	ts.stack = append(ts.stack, nil)
+	ts.names = append(ts.names, "")
+	ts.sizes = append(ts.sizes, 0)
+	ts.paths = append(ts.paths, ts.args)
+
+	ts.append(
+		awsstepfunctions.NewPass(ts.scope, jsii.String(id),
+			&awsstepfunctions.PassProps{
+				InputPath: jsii.String(path),
+			},
+		),
+	)
+	ts.args = "$"
+
+	if err := m.Apply(branch{ts}); err != nil {
+		return nil, 0, "", err
+	}
+
+	last := len(ts.stack) - 1
+	chain, size, args := ts.stack[last], ts.sizes[last], ts.args
+	ts.stack = ts.stack[:last]
+	ts.names = ts.names[:last]
+	ts.sizes = ts.sizes[:last]
+	ts.args = ts.paths[last]
+	ts.paths = ts.paths[:last]
+
+	return chain, size, args, nil
+}
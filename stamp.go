@@ -0,0 +1,78 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+const (
+	// TagUUID is the value of struct tag `typestep:"uuid"`, which annotates
+	// fields populated with v4 unique identifier by [Stamp].
+	TagUUID = "uuid"
+
+	// TagTimestamp is the value of struct tag `typestep:"timestamp"`, which
+	// annotates fields populated with start time of the execution by [Stamp].
+	TagTimestamp = "timestamp"
+)
+
+// Stamp populates fields of the payload 𝑚: A ⟼ B annotated with
+// `typestep:"uuid"` and `typestep:"timestamp"`, so that downstream
+// functions receive complete records without generating metadata themselves.
+//
+//	type Order struct {
+//	  EventID     string    `json:"eventId" typestep:"uuid"`
+//	  ProcessedAt time.Time `json:"processedAt" typestep:"timestamp"`
+//	}
+func Stamp[A, B any](m duct.Morphism[A, B]) duct.Morphism[A, B] {
+	kind := reflect.TypeOf(new(B)).Elem()
+	f := stamp{kind: kind}
+	if len(fieldsOf(nil, "", kind, TagUUID)) == 0 && len(fieldsOf(nil, "", kind, TagTimestamp)) == 0 {
+		panic(fmt.Errorf("type %s has no fields annotated with uuid or timestamp", kind))
+	}
+
+	return duct.Join(duct.L2[B, B](f), m)
+}
+
+// type with fields populated by metadata
+type stamp struct {
+	kind reflect.Type
+}
+
+// expression of JSONata transform over the payload at path
+func (f stamp) expression(n runtime.FieldNamer, path string) string {
+	expr := "$states.input" + strings.TrimPrefix(path, "$")
+	for _, x := range fieldsOf(n, "", f.kind, TagUUID) {
+		expr += transform(x, "$uuid()")
+	}
+	for _, x := range fieldsOf(n, "", f.kind, TagTimestamp) {
+		expr += transform(x, "$states.context.Execution.StartTime")
+	}
+	return "{% " + expr + " %}"
+}
+
+// transform the field of the payload with the value
+func transform(f field, value string) string {
+	seq := strings.Split(strings.TrimPrefix(f.path, "."), ".")
+	for i := range seq {
+		seq[i] = "`" + seq[i] + "`"
+	}
+
+	location := "$"
+	if len(seq) > 1 {
+		location = strings.Join(seq[:len(seq)-1], ".")
+	}
+
+	return fmt.Sprintf(" ~> |%s|{'%s': %s}|", location, f.name, value)
+}
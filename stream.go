@@ -0,0 +1,183 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awspipes"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// Engine is the backend executing pipelines.
+type Engine string
+
+const (
+	// Pipelines are AWS Step Functions state machines, the execution is
+	// started per event (default).
+	EngineStepFunctions Engine = "stepfunctions"
+
+	// Pipelines are EventBridge Pipes, events are buffered by AWS SQS queue
+	// and processed in batches by the enrichment function. It is only
+	// applicable for chains `From` ⟼ `Join` ⟼ `ToQueue` or `ToEventBus`.
+	EnginePipes Engine = "pipes"
+)
+
+// stream is the pipeline compiled into EventBridge Pipe
+//
+//	Rule ⟼ SQS ⟼ Pipe (f) ⟼ SQS | EventBridge
+type stream struct {
+	duct.AstVisitor
+	source *source
+	f      *lambda
+	sink   any
+}
+
+func (s *stream) OnEnterSeq(depth int, node duct.AstSeq) error {
+	return fmt.Errorf("pipes engine does not support sequences")
+}
+
+func (s *stream) OnEnterFrom(depth int, node duct.AstFrom) error {
+	f, ok := node.Source.(source)
+	if !ok {
+		return fmt.Errorf("pipes engine does not support input type: %T", node.Source)
+	}
+	s.source = &f
+	return nil
+}
+
+func (s *stream) OnEnterMap(depth int, node duct.AstMap) error {
+	f, ok := node.F.(lambda)
+	if !ok || f.cache != nil || f.fallback != nil {
+		return fmt.Errorf("pipes engine does not support compute type: %T", node.F)
+	}
+	if s.f != nil {
+		return fmt.Errorf("pipes engine supports only single function")
+	}
+	s.f = &f
+	return nil
+}
+
+func (s *stream) OnEnterYield(depth int, node duct.AstYield) error {
+	switch node.Target.(type) {
+	case queue, eventbus:
+		s.sink = node.Target
+		return nil
+	default:
+		return fmt.Errorf("pipes engine does not support reply type: %T", node.Target)
+	}
+}
+
+// streaming compiles the morphism into EventBridge Pipe, the function is
+// the enrichment of the pipe, which is invoked with the batch of events.
+func (ts *typeStep) streaming(m interface{ Apply(duct.Visitor) error }) error {
+	s := &stream{}
+	if err := m.Apply(s); err != nil {
+		return err
+	}
+	if s.source == nil {
+		return fmt.Errorf("undefined event source for compute pipeline")
+	}
+	if s.f == nil || s.sink == nil {
+		return fmt.Errorf("pipes engine requires chain From ⟼ Join ⟼ sink")
+	}
+
+	ts.bus = s.source.bus
+	ts.eventPattern = &awsevents.EventPattern{
+		DetailType: jsii.Strings(ts.detailTypeOf(s.source.kind)),
+	}
+	if len(s.source.cat) != 0 {
+		ts.eventPattern.DetailType = jsii.Strings(s.source.cat...)
+	}
+	if len(s.source.where) != 0 {
+		detail := detailOf(ts.namer, s.source.where)
+		ts.eventPattern.Detail = &detail
+	}
+
+	spec := &awssqs.QueueProps{}
+	if ts.DeadLetterQueue != nil {
+		spec.DeadLetterQueue = &awssqs.DeadLetterQueue{
+			Queue:           ts.DeadLetterQueue,
+			MaxReceiveCount: jsii.Number(3),
+		}
+	}
+	buffer := awssqs.NewQueue(ts.scope, jsii.String("StreamQueue"), spec)
+
+	awsevents.NewRule(ts.scope, jsii.String("Rule"),
+		&awsevents.RuleProps{
+			EventBus:     ts.bus,
+			EventPattern: ts.eventPattern,
+		},
+	).AddTarget(
+		awseventstargets.NewSqsQueue(buffer,
+			&awseventstargets.SqsQueueProps{
+				Message: awsevents.RuleTargetInput_FromEventPath(jsii.String("$.detail")),
+			},
+		),
+	)
+
+	role := awsiam.NewRole(ts.scope, jsii.String("StreamRole"),
+		&awsiam.RoleProps{
+			AssumedBy: awsiam.NewServicePrincipal(jsii.String("pipes.amazonaws.com"), nil),
+		},
+	)
+	buffer.GrantConsumeMessages(role)
+	// Note: the policy of role is used, imported functions are not modified
+	role.AddToPolicy(awsiam.NewPolicyStatement(
+		&awsiam.PolicyStatementProps{
+			Actions:   jsii.Strings("lambda:InvokeFunction"),
+			Resources: &[]*string{s.f.f.FunctionArn()},
+		},
+	))
+	ts.setenv(s.f.f, runtime.EnvBatch, "true")
+	ts.provision(s.f.f)
+
+	props := &awspipes.CfnPipeProps{
+		RoleArn:    role.RoleArn(),
+		Source:     buffer.QueueArn(),
+		Enrichment: s.f.f.FunctionArn(),
+		EnrichmentParameters: &awspipes.CfnPipe_PipeEnrichmentParametersProperty{
+			InputTemplate: jsii.String("<$.body>"),
+		},
+	}
+
+	switch sink := s.sink.(type) {
+	case queue:
+		sink.q.GrantSendMessages(role)
+		props.Target = sink.q.QueueArn()
+	case eventbus:
+		role.AddToPolicy(awsiam.NewPolicyStatement(
+			&awsiam.PolicyStatementProps{
+				Actions:   jsii.Strings("events:PutEvents"),
+				Resources: &[]*string{sink.bus.EventBusArn()},
+			},
+		))
+		kind := ts.detailTypeOf(sink.kind)
+		if len(sink.cat) != 0 {
+			kind = sink.cat[0]
+		}
+		props.Target = sink.bus.EventBusArn()
+		props.TargetParameters = &awspipes.CfnPipe_PipeTargetParametersProperty{
+			EventBridgeEventBusParameters: &awspipes.CfnPipe_PipeTargetEventBridgeEventBusParametersProperty{
+				DetailType: jsii.String(kind),
+				Source:     jsii.String(sink.source),
+			},
+		}
+	}
+
+	awspipes.NewCfnPipe(ts.scope, jsii.String("Pipe"), props)
+
+	return nil
+}
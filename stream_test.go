@@ -0,0 +1,93 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestEnginePipes(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+
+	a := typestep.Function_FromFunctionArn[string, User](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToEventBus("test", event, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{Engine: typestep.EnginePipes},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(0))
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{"detail-type": []string{"string"}},
+			"Targets": []any{
+				assertions.Match_ObjectLike(&map[string]any{"InputPath": "$.detail"}),
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Pipes::Pipe"),
+		map[string]any{
+			"Enrichment":           "arn:aws:lambda:eu-west-1:000000000000:function:my-function",
+			"EnrichmentParameters": map[string]any{"InputTemplate": "<$.body>"},
+			"Target":               "arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus",
+			"TargetParameters": map[string]any{
+				"EventBridgeEventBusParameters": map[string]any{
+					"DetailType": "User",
+					"Source":     "test",
+				},
+			},
+		},
+	)
+}
+
+func TestEnginePipesUnsupported(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+	b := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.Lift(b, p2)
+	p4 := typestep.ToEventBus("test", event, p3)
+
+	// THEN
+	defer func() {
+		if recover() == nil {
+			t.Errorf("pipes engine shall not compile sequences")
+		}
+	}()
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{Engine: typestep.EnginePipes},
+	)
+	typestep.StateMachine(ts, p4)
+}
@@ -0,0 +1,41 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import "github.com/fogfish/golem/duct"
+
+// Template is the shape of pipeline declared once over type parameters,
+// the parameter P binds typed functions and resources of the instantiation.
+// Templates are ordinary generic functions, instantiated for each type:
+//
+//	type Ingest[T any] struct {
+//		Events   awsevents.IEventBus
+//		Validate typestep.F[T, T]
+//		Store    typestep.F[T, T]
+//		Queue    awssqs.IQueue
+//	}
+//
+//	func IngestPipeline[T Validatable](p Ingest[T]) duct.Morphism[T, duct.Void] {
+//		a := typestep.From[T](p.Events)
+//		b := typestep.Join(p.Validate, a)
+//		c := typestep.Join(p.Store, b)
+//		return typestep.ToQueue(p.Queue, c)
+//	}
+//
+//	typestep.Instantiate(ts, IngestPipeline[Order], Ingest[Order]{/* ... */})
+//	typestep.Instantiate(ts, IngestPipeline[Invoice], Ingest[Invoice]{/* ... */})
+type Template[P, A, B any] func(P) duct.Morphism[A, B]
+
+// Instantiate the template with each binding, defining a new state machine
+// per binding (see [StateMachine]).
+func Instantiate[P, A, B any](ts TypeStep, t Template[P, A, B], bindings ...P) {
+	for _, p := range bindings {
+		StateMachine(ts, t(p))
+	}
+}
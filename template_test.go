@@ -0,0 +1,75 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep"
+)
+
+type Ingest[T any] struct {
+	Events   awsevents.IEventBus
+	Category string
+	Validate typestep.F[T, T]
+	Queue    awssqs.IQueue
+}
+
+func IngestPipeline[T any](p Ingest[T]) duct.Morphism[T, duct.Void] {
+	a := typestep.From[T](p.Events, p.Category)
+	b := typestep.Join(p.Validate, a)
+	return typestep.ToQueue(p.Queue, b)
+}
+
+func TestTemplate(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[User, User](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function-a"))
+
+	b := typestep.Function_FromFunctionArn[Contact, Contact](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function-b"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.Instantiate(ts, IngestPipeline[User],
+		Ingest[User]{Events: event, Category: "User", Validate: a, Queue: queue},
+	)
+	typestep.Instantiate(ts, IngestPipeline[Contact],
+		Ingest[Contact]{Events: event, Category: "Contact", Validate: b, Queue: queue},
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.ResourceCountIs(jsii.String("AWS::StepFunctions::StateMachine"), jsii.Number(2))
+	template.ResourceCountIs(jsii.String("AWS::Events::Rule"), jsii.Number(2))
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"StartAt":"MapA"`,
+		`"StartAt":"MapB"`,
+		`function:my-function-a`,
+		`function:my-function-b`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
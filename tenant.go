@@ -0,0 +1,85 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// TagTenant is the value of struct tag `typestep:"tenant"`, which
+	// annotates the tenant id of the input `A` (see TypeStepProps.Tenancy).
+	TagTenant = "tenant"
+
+	// TenantAttribute is the attribute of SQS messages (sinks and DLQ),
+	// which contains tenant id of the execution.
+	TenantAttribute = "typestep-tenant"
+
+	// variables of the execution, which hold tenant id and its concurrency
+	varTenant            = "typestepTenant"
+	varTenantConcurrency = "typestepTenantConcurrency"
+)
+
+// Tenancy of the pipeline isolates concurrency of tenants. The tenant id of
+// the execution is the field of input `A` annotated with `typestep:"tenant"`.
+// The fan-out of sequences (morphism 𝑚: A ⟼ []B) is limited per tenant,
+// the limit overrides concurrency of [LiftP]. Messages of sinks and
+// the dead-letter queue carry tenant id as [TenantAttribute]. Tenant id is
+// the variable of execution, it is passed to nested state machines (see
+// TypeStepProps.InlineStates) within their input.
+type Tenancy struct {
+	// Concurrency of sequences per tenant id.
+	Concurrency map[string]int
+
+	// DefaultConcurrency of sequences for tenants not listed by Concurrency,
+	// default is 1.
+	DefaultConcurrency int
+}
+
+// tenant binds the tenant id and its concurrency with variables of execution
+func (ts *typeStep) tenant(kind reflect.Type) error {
+	seq := fieldsOf(ts.namer, "", kind, TagTenant)
+	if len(seq) == 0 {
+		return fmt.Errorf("tenancy requires field of %s annotated with `typestep:\"%s\"`", kind, TagTenant)
+	}
+
+	table, err := json.Marshal(ts.tenancy.Concurrency)
+	if err != nil {
+		return err
+	}
+
+	n := ts.tenancy.DefaultConcurrency
+	if n == 0 {
+		n = 1
+	}
+
+	id := "$states.input" + strings.TrimPrefix(ts.args, "$")
+	for _, x := range strings.Split(strings.TrimPrefix(seq[0].path, "."), ".") {
+		id += ".`" + x + "`"
+	}
+
+	pass := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("Tenant"),
+		&awsstepfunctions.PassJsonataProps{
+			Assign: &map[string]any{
+				varTenant:            "{% $string(" + id + ") %}",
+				varTenantConcurrency: fmt.Sprintf("{%% ($n := $lookup(%s, $string(%s)); $exists($n) ? $n : %d) %%}", table, id, n),
+			},
+			Outputs: "{% $states.input %}",
+		},
+	)
+	ts.append(pass)
+
+	return nil
+}
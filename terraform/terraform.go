@@ -0,0 +1,949 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+// Package terraform exports typestep pipelines as Terraform configuration,
+// for platforms which mandate Terraform while pipelines are still composed
+// with the typed Go API. The CloudFormation template synthesized by the stack
+// (e.g. `cdk synth`) is rendered as JSON configuration (`*.tf.json`) of
+// the AWS provider, references between resources become Terraform references.
+//
+//	tf, err := terraform.Export(template)
+//	os.WriteFile("pipeline.tf.json", tf, 0644)
+//
+// The export covers resources generated by pipelines: state machines, rules
+// and targets, queues, IAM roles and policies, log groups, alarms, functions
+// and their permissions. Code of functions is not managed by Terraform, each
+// function declares the variable `{LogicalId}_code` with the path to zip
+// archive of the code. Other resources (e.g. custom resources, assets, pipes)
+// are reported as errors.
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Export renders the CloudFormation template as Terraform JSON configuration
+func Export(template []byte) ([]byte, error) {
+	var cfn struct {
+		Resources map[string]resource `json:"Resources"`
+	}
+	if err := json.Unmarshal(template, &cfn); err != nil {
+		return nil, err
+	}
+
+	e := &export{
+		resources: cfn.Resources,
+		resource:  map[string]map[string]any{},
+		data:      map[string]map[string]any{},
+		variable:  map[string]any{},
+	}
+
+	ids := make([]string, 0, len(cfn.Resources))
+	for id := range cfn.Resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := e.export(id, cfn.Resources[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	tf := map[string]any{"resource": e.resource}
+	if len(e.data) != 0 {
+		tf["data"] = e.data
+	}
+	if len(e.variable) != 0 {
+		tf["variable"] = e.variable
+	}
+
+	return encode(tf, "  ")
+}
+
+type resource struct {
+	Type       string         `json:"Type"`
+	Properties map[string]any `json:"Properties"`
+	DependsOn  any            `json:"DependsOn"`
+}
+
+// kind of CloudFormation resource, references are templates of Terraform
+// expressions, the address of resource substitutes %s.
+type kind struct {
+	tf    string
+	ref   string
+	attrs map[string]string
+	props func(e *export, id string, p props) (map[string]any, error)
+}
+
+// blocks are extra resources produced by the kind (e.g. targets of rule)
+type blocks map[string]map[string]map[string]any
+
+type export struct {
+	resources map[string]resource
+	resource  map[string]map[string]any
+	data      map[string]map[string]any
+	variable  map[string]any
+	extra     blocks
+}
+
+// resources of CloudFormation which are generated by AWS CDK but are not
+// part of the deployment
+var skipped = map[string]bool{
+	"AWS::CDK::Metadata": true,
+}
+
+// logical id of the singleton function of AWS CDK which applies log retention,
+// Terraform manages retention of log groups natively.
+const logRetentionProvider = "LogRetentionaae0aa3c5b4d4f87b02d85b201efdd8a"
+
+func isSkipped(id string, res resource) bool {
+	return skipped[res.Type] || strings.HasPrefix(id, logRetentionProvider)
+}
+
+func (e *export) export(id string, res resource) error {
+	if isSkipped(id, res) {
+		return nil
+	}
+
+	k, has := kinds[res.Type]
+	if !has {
+		return fmt.Errorf("resource %s of type %s is not supported by terraform export", id, res.Type)
+	}
+
+	e.extra = blocks{}
+	body, err := k.props(e, id, props{e: e, id: id, p: res.Properties})
+	if err != nil {
+		return fmt.Errorf("resource %s: %w", id, err)
+	}
+
+	deps, err := e.dependsOn(res.DependsOn)
+	if err != nil {
+		return fmt.Errorf("resource %s: %w", id, err)
+	}
+
+	if body != nil {
+		if len(deps) != 0 {
+			body["depends_on"] = deps
+		}
+		e.block(k.tf, id, body)
+	}
+	for tf, seq := range e.extra {
+		for name, x := range seq {
+			if len(deps) != 0 {
+				x["depends_on"] = deps
+			}
+			e.block(tf, name, x)
+		}
+	}
+
+	return nil
+}
+
+func (e *export) block(tf, name string, body map[string]any) {
+	if _, has := e.resource[tf]; !has {
+		e.resource[tf] = map[string]any{}
+	}
+	e.resource[tf][name] = body
+}
+
+// emit extra resource of the kind
+func (e *export) emit(tf, name string, body map[string]any) {
+	if _, has := e.extra[tf]; !has {
+		e.extra[tf] = map[string]map[string]any{}
+	}
+	e.extra[tf][name] = body
+}
+
+func (e *export) dependsOn(v any) ([]string, error) {
+	var ids []string
+	switch deps := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		ids = []string{deps}
+	case []any:
+		for _, x := range deps {
+			if s, ok := x.(string); ok {
+				ids = append(ids, s)
+			}
+		}
+	}
+
+	seq := []string{}
+	for _, id := range ids {
+		res, has := e.resources[id]
+		if !has || isSkipped(id, res) {
+			continue
+		}
+		k, has := kinds[res.Type]
+		if !has {
+			return nil, fmt.Errorf("depends on unsupported resource %s", id)
+		}
+		seq = append(seq, k.tf+"."+id)
+	}
+	sort.Strings(seq)
+
+	return seq, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Intrinsic functions
+//
+//------------------------------------------------------------------------------
+
+// pseudo parameters of CloudFormation
+var pseudo = map[string]struct{ data, kind, expr string }{
+	"AWS::Partition": {"aws_partition", "current", "${data.aws_partition.current.partition}"},
+	"AWS::URLSuffix": {"aws_partition", "current", "${data.aws_partition.current.dns_suffix}"},
+	"AWS::Region":    {"aws_region", "current", "${data.aws_region.current.name}"},
+	"AWS::AccountId": {"aws_caller_identity", "current", "${data.aws_caller_identity.current.account_id}"},
+}
+
+// value of the property, literals are escaped, intrinsic functions are
+// converted to Terraform templates.
+func (e *export) value(v any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		return escape(x), nil
+	case []any:
+		seq := make([]any, len(x))
+		for i, el := range x {
+			val, err := e.value(el)
+			if err != nil {
+				return nil, err
+			}
+			seq[i] = val
+		}
+		return seq, nil
+	case map[string]any:
+		if len(x) == 1 {
+			for fn, arg := range x {
+				switch {
+				case fn == "Ref":
+					return e.ref(arg)
+				case fn == "Fn::GetAtt":
+					return e.getAtt(arg)
+				case fn == "Fn::Join":
+					return e.join(arg)
+				case strings.HasPrefix(fn, "Fn::"):
+					return nil, fmt.Errorf("intrinsic function %s is not supported", fn)
+				}
+			}
+		}
+		obj := make(map[string]any, len(x))
+		for key, el := range x {
+			val, err := e.value(el)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = val
+		}
+		return obj, nil
+	default:
+		return v, nil
+	}
+}
+
+func (e *export) ref(arg any) (any, error) {
+	id, _ := arg.(string)
+
+	if p, has := pseudo[id]; has {
+		if _, has := e.data[p.data]; !has {
+			e.data[p.data] = map[string]any{}
+		}
+		e.data[p.data][p.kind] = map[string]any{}
+		return p.expr, nil
+	}
+
+	if id == "AWS::StackName" {
+		e.variable["stack_name"] = map[string]any{"type": "string"}
+		return "${var.stack_name}", nil
+	}
+
+	k, err := e.kindOf(id)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf(k.ref, k.tf+"."+id), nil
+}
+
+func (e *export) getAtt(arg any) (any, error) {
+	var id, attr string
+	switch x := arg.(type) {
+	case string:
+		id, attr, _ = strings.Cut(x, ".")
+	case []any:
+		if len(x) == 2 {
+			id, _ = x[0].(string)
+			attr, _ = x[1].(string)
+		}
+	}
+
+	k, err := e.kindOf(id)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, has := k.attrs[attr]
+	if !has {
+		return nil, fmt.Errorf("attribute %s.%s is not supported", id, attr)
+	}
+	return fmt.Sprintf(expr, k.tf+"."+id), nil
+}
+
+func (e *export) kindOf(id string) (kind, error) {
+	res, has := e.resources[id]
+	if !has {
+		return kind{}, fmt.Errorf("reference to undefined resource %s", id)
+	}
+	k, has := kinds[res.Type]
+	if !has {
+		return kind{}, fmt.Errorf("reference to unsupported resource %s of type %s", id, res.Type)
+	}
+	return k, nil
+}
+
+func (e *export) join(arg any) (any, error) {
+	x, ok := arg.([]any)
+	if !ok || len(x) != 2 {
+		return nil, fmt.Errorf("invalid Fn::Join")
+	}
+	sep, _ := x[0].(string)
+	parts, _ := x[1].([]any)
+
+	seq := make([]string, len(parts))
+	for i, part := range parts {
+		val, err := e.value(part)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("Fn::Join of non-string value")
+		}
+		seq[i] = s
+	}
+	return strings.Join(seq, escape(sep)), nil
+}
+
+// escape literal of Terraform template
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+	return s
+}
+
+func encode(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Properties
+//
+//------------------------------------------------------------------------------
+
+// props of CloudFormation resource
+type props struct {
+	e  *export
+	id string
+	p  map[string]any
+}
+
+func (p props) has(key string) bool {
+	_, has := p.p[key]
+	return has
+}
+
+// sub properties of the nested object
+func (p props) sub(key string) props {
+	x, _ := p.p[key].(map[string]any)
+	return props{e: p.e, id: p.id, p: x}
+}
+
+// seq of nested objects
+func (p props) seq(key string) []props {
+	x, _ := p.p[key].([]any)
+	seq := []props{}
+	for _, el := range x {
+		if obj, ok := el.(map[string]any); ok {
+			seq = append(seq, props{e: p.e, id: p.id, p: obj})
+		}
+	}
+	return seq
+}
+
+// copy properties to Terraform arguments as-is, the key of the mapping is
+// CloudFormation property, the value is Terraform argument.
+func (p props) copy(body map[string]any, keys map[string]string) error {
+	for cfn, tf := range keys {
+		v, has := p.p[cfn]
+		if !has {
+			continue
+		}
+		val, err := p.e.value(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cfn, err)
+		}
+		body[tf] = val
+	}
+	return nil
+}
+
+// json encodes the property as string, it is either JSON object or the
+// string containing JSON document.
+func (p props) json(body map[string]any, cfn, tf string) error {
+	v, has := p.p[cfn]
+	if !has {
+		return nil
+	}
+
+	val, err := p.e.value(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cfn, err)
+	}
+
+	if s, ok := val.(string); ok {
+		body[tf] = s
+		return nil
+	}
+
+	b, err := encode(val, "")
+	if err != nil {
+		return err
+	}
+	body[tf] = strings.TrimSuffix(string(b), "\n")
+	return nil
+}
+
+// tags of the resource
+func (p props) tags(body map[string]any) error {
+	seq := p.seq("Tags")
+	if len(seq) == 0 {
+		return nil
+	}
+
+	tags := map[string]any{}
+	for _, tag := range seq {
+		k, _ := tag.p["Key"].(string)
+		v, err := p.e.value(tag.p["Value"])
+		if err != nil {
+			return err
+		}
+		tags[k] = v
+	}
+	body["tags"] = tags
+	return nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Resources
+//
+//------------------------------------------------------------------------------
+
+// Note: kinds are initialized by init to break the cycle with converters
+var kinds map[string]kind
+
+func init() {
+	kinds = map[string]kind{
+		"AWS::StepFunctions::StateMachine": {
+			tf:    "aws_sfn_state_machine",
+			ref:   "${%s.arn}",
+			attrs: map[string]string{"Arn": "${%s.arn}", "Name": "${%s.name}"},
+			props: stateMachine,
+		},
+		"AWS::IAM::Role": {
+			tf:    "aws_iam_role",
+			ref:   "${%s.name}",
+			attrs: map[string]string{"Arn": "${%s.arn}", "RoleId": "${%s.unique_id}"},
+			props: role,
+		},
+		"AWS::IAM::Policy": {
+			tf:    "aws_iam_role_policy",
+			ref:   "${%s.id}",
+			props: policy,
+		},
+		"AWS::Events::Rule": {
+			tf:    "aws_cloudwatch_event_rule",
+			ref:   "${%s.name}",
+			attrs: map[string]string{"Arn": "${%s.arn}"},
+			props: rule,
+		},
+		"AWS::SQS::Queue": {
+			tf:    "aws_sqs_queue",
+			ref:   "${%s.url}",
+			attrs: map[string]string{"Arn": "${%s.arn}", "QueueName": "${%s.name}", "QueueUrl": "${%s.url}"},
+			props: queue,
+		},
+		"AWS::SQS::QueuePolicy": {
+			tf:    "aws_sqs_queue_policy",
+			ref:   "${%s.id}",
+			props: queuePolicy,
+		},
+		"AWS::Lambda::Function": {
+			tf:    "aws_lambda_function",
+			ref:   "${%s.function_name}",
+			attrs: map[string]string{"Arn": "${%s.arn}"},
+			props: function,
+		},
+		"AWS::Lambda::Permission": {
+			tf:    "aws_lambda_permission",
+			ref:   "${%s.id}",
+			props: permission,
+		},
+		"AWS::Logs::LogGroup": {
+			tf:  "aws_cloudwatch_log_group",
+			ref: "${%s.name}",
+			// Note: CloudFormation defines the arn of log group with suffix
+			attrs: map[string]string{"Arn": "${%s.arn}:*"},
+			props: logGroup,
+		},
+		"Custom::LogRetention": {
+			tf:    "aws_cloudwatch_log_group",
+			ref:   "${%s.name}",
+			props: logRetention,
+		},
+		"AWS::CloudWatch::Alarm": {
+			tf:    "aws_cloudwatch_metric_alarm",
+			ref:   "${%s.alarm_name}",
+			attrs: map[string]string{"Arn": "${%s.arn}"},
+			props: alarm,
+		},
+	}
+}
+
+func stateMachine(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"StateMachineName": "name",
+		"StateMachineType": "type",
+		"RoleArn":          "role_arn",
+	}); err != nil {
+		return nil, err
+	}
+	if _, has := body["name"]; !has {
+		body["name"] = id
+	}
+
+	if err := p.json(body, "DefinitionString", "definition"); err != nil {
+		return nil, err
+	}
+	if err := p.json(body, "Definition", "definition"); err != nil {
+		return nil, err
+	}
+
+	if p.has("TracingConfiguration") {
+		tracing := map[string]any{}
+		if err := p.sub("TracingConfiguration").copy(tracing, map[string]string{"Enabled": "enabled"}); err != nil {
+			return nil, err
+		}
+		body["tracing_configuration"] = tracing
+	}
+
+	if p.has("LoggingConfiguration") {
+		cfg := p.sub("LoggingConfiguration")
+		logging := map[string]any{}
+		if err := cfg.copy(logging, map[string]string{
+			"Level":                "level",
+			"IncludeExecutionData": "include_execution_data",
+		}); err != nil {
+			return nil, err
+		}
+		for _, dst := range cfg.seq("Destinations") {
+			if err := dst.sub("CloudWatchLogsLogGroup").copy(logging, map[string]string{"LogGroupArn": "log_destination"}); err != nil {
+				return nil, err
+			}
+		}
+		body["logging_configuration"] = logging
+	}
+
+	if err := p.tags(body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func role(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"RoleName":           "name",
+		"Path":               "path",
+		"Description":        "description",
+		"MaxSessionDuration": "max_session_duration",
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := p.json(body, "AssumeRolePolicyDocument", "assume_role_policy"); err != nil {
+		return nil, err
+	}
+
+	for i, x := range p.seq("Policies") {
+		inline := map[string]any{}
+		if err := x.copy(inline, map[string]string{"PolicyName": "name"}); err != nil {
+			return nil, err
+		}
+		if err := x.json(inline, "PolicyDocument", "policy"); err != nil {
+			return nil, err
+		}
+		inline["role"] = "${aws_iam_role." + id + ".name}"
+		e.emit("aws_iam_role_policy", fmt.Sprintf("%s_%d", id, i), inline)
+	}
+
+	if p.has("ManagedPolicyArns") {
+		arns, err := e.value(p.p["ManagedPolicyArns"])
+		if err != nil {
+			return nil, err
+		}
+		for i, arn := range arns.([]any) {
+			e.emit("aws_iam_role_policy_attachment", fmt.Sprintf("%s_%d", id, i),
+				map[string]any{
+					"role":       "${aws_iam_role." + id + ".name}",
+					"policy_arn": arn,
+				},
+			)
+		}
+	}
+
+	if err := p.tags(body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// policy is attached to each role, the policy attached to the single role
+// keeps the logical id.
+func policy(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{"PolicyName": "name"}); err != nil {
+		return nil, err
+	}
+	if err := p.json(body, "PolicyDocument", "policy"); err != nil {
+		return nil, err
+	}
+
+	roles, err := e.value(p.p["Roles"])
+	if err != nil {
+		return nil, err
+	}
+	seq, _ := roles.([]any)
+	if len(seq) == 0 {
+		return nil, fmt.Errorf("policy is not attached to roles")
+	}
+
+	if len(seq) == 1 {
+		body["role"] = seq[0]
+		return body, nil
+	}
+
+	for i, r := range seq {
+		attached := map[string]any{"role": r}
+		for k, v := range body {
+			attached[k] = v
+		}
+		e.emit("aws_iam_role_policy", fmt.Sprintf("%s_%d", id, i), attached)
+	}
+	return nil, nil
+}
+
+func rule(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"Name":               "name",
+		"Description":        "description",
+		"EventBusName":       "event_bus_name",
+		"ScheduleExpression": "schedule_expression",
+		"State":              "state",
+		"RoleArn":            "role_arn",
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := p.json(body, "EventPattern", "event_pattern"); err != nil {
+		return nil, err
+	}
+
+	for i, x := range p.seq("Targets") {
+		target := map[string]any{
+			"rule": "${aws_cloudwatch_event_rule." + id + ".name}",
+		}
+		if bus, has := body["event_bus_name"]; has {
+			target["event_bus_name"] = bus
+		}
+		if err := x.copy(target, map[string]string{
+			"Id":        "target_id",
+			"Arn":       "arn",
+			"RoleArn":   "role_arn",
+			"Input":     "input",
+			"InputPath": "input_path",
+		}); err != nil {
+			return nil, err
+		}
+
+		if x.has("InputTransformer") {
+			transformer := map[string]any{}
+			if err := x.sub("InputTransformer").copy(transformer, map[string]string{
+				"InputPathsMap": "input_paths",
+				"InputTemplate": "input_template",
+			}); err != nil {
+				return nil, err
+			}
+			target["input_transformer"] = transformer
+		}
+
+		if x.has("DeadLetterConfig") {
+			dlq := map[string]any{}
+			if err := x.sub("DeadLetterConfig").copy(dlq, map[string]string{"Arn": "arn"}); err != nil {
+				return nil, err
+			}
+			target["dead_letter_config"] = dlq
+		}
+
+		if x.has("RetryPolicy") {
+			retry := map[string]any{}
+			if err := x.sub("RetryPolicy").copy(retry, map[string]string{
+				"MaximumEventAgeInSeconds": "maximum_event_age_in_seconds",
+				"MaximumRetryAttempts":     "maximum_retry_attempts",
+			}); err != nil {
+				return nil, err
+			}
+			target["retry_policy"] = retry
+		}
+
+		if x.has("SqsParameters") {
+			sqs := map[string]any{}
+			if err := x.sub("SqsParameters").copy(sqs, map[string]string{"MessageGroupId": "message_group_id"}); err != nil {
+				return nil, err
+			}
+			target["sqs_target"] = sqs
+		}
+
+		e.emit("aws_cloudwatch_event_target", fmt.Sprintf("%s_%d", id, i), target)
+	}
+
+	if err := p.tags(body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func queue(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"QueueName":                     "name",
+		"FifoQueue":                     "fifo_queue",
+		"ContentBasedDeduplication":     "content_based_deduplication",
+		"DeduplicationScope":            "deduplication_scope",
+		"FifoThroughputLimit":           "fifo_throughput_limit",
+		"DelaySeconds":                  "delay_seconds",
+		"MaximumMessageSize":            "max_message_size",
+		"MessageRetentionPeriod":        "message_retention_seconds",
+		"ReceiveMessageWaitTimeSeconds": "receive_wait_time_seconds",
+		"VisibilityTimeout":             "visibility_timeout_seconds",
+		"KmsMasterKeyId":                "kms_master_key_id",
+		"SqsManagedSseEnabled":          "sqs_managed_sse_enabled",
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := p.json(body, "RedrivePolicy", "redrive_policy"); err != nil {
+		return nil, err
+	}
+
+	if err := p.tags(body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func queuePolicy(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.json(body, "PolicyDocument", "policy"); err != nil {
+		return nil, err
+	}
+
+	queues, err := e.value(p.p["Queues"])
+	if err != nil {
+		return nil, err
+	}
+	for i, url := range queues.([]any) {
+		attached := map[string]any{"queue_url": url}
+		for k, v := range body {
+			attached[k] = v
+		}
+		e.emit("aws_sqs_queue_policy", fmt.Sprintf("%s_%d", id, i), attached)
+	}
+	return nil, nil
+}
+
+func function(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"FunctionName":  "function_name",
+		"Description":   "description",
+		"Role":          "role",
+		"Handler":       "handler",
+		"Runtime":       "runtime",
+		"Timeout":       "timeout",
+		"MemorySize":    "memory_size",
+		"Architectures": "architectures",
+		"Layers":        "layers",
+	}); err != nil {
+		return nil, err
+	}
+	if _, has := body["function_name"]; !has {
+		body["function_name"] = id
+	}
+
+	// Note: assets are not exported, the code is the input of configuration
+	code := id + "_code"
+	e.variable[code] = map[string]any{
+		"type":        "string",
+		"description": "path to zip archive of the code of function " + id,
+	}
+	body["filename"] = "${var." + code + "}"
+	body["source_code_hash"] = "${filebase64sha256(var." + code + ")}"
+
+	if p.has("Environment") {
+		env := map[string]any{}
+		if err := p.sub("Environment").copy(env, map[string]string{"Variables": "variables"}); err != nil {
+			return nil, err
+		}
+		body["environment"] = env
+	}
+
+	if p.has("TracingConfig") {
+		tracing := map[string]any{}
+		if err := p.sub("TracingConfig").copy(tracing, map[string]string{"Mode": "mode"}); err != nil {
+			return nil, err
+		}
+		body["tracing_config"] = tracing
+	}
+
+	if p.has("LoggingConfig") {
+		logging := map[string]any{}
+		if err := p.sub("LoggingConfig").copy(logging, map[string]string{
+			"LogFormat": "log_format",
+			"LogGroup":  "log_group",
+		}); err != nil {
+			return nil, err
+		}
+		body["logging_config"] = logging
+	}
+
+	if err := p.tags(body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func permission(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"Action":        "action",
+		"FunctionName":  "function_name",
+		"Principal":     "principal",
+		"SourceArn":     "source_arn",
+		"SourceAccount": "source_account",
+	}); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func logGroup(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"LogGroupName":    "name",
+		"RetentionInDays": "retention_in_days",
+		"KmsKeyId":        "kms_key_id",
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := p.tags(body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// log retention of AWS CDK is the log group managed by Terraform
+func logRetention(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"LogGroupName":    "name",
+		"RetentionInDays": "retention_in_days",
+	}); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func alarm(e *export, id string, p props) (map[string]any, error) {
+	body := map[string]any{}
+	if err := p.copy(body, map[string]string{
+		"AlarmName":          "alarm_name",
+		"AlarmDescription":   "alarm_description",
+		"ComparisonOperator": "comparison_operator",
+		"EvaluationPeriods":  "evaluation_periods",
+		"DatapointsToAlarm":  "datapoints_to_alarm",
+		"Threshold":          "threshold",
+		"MetricName":         "metric_name",
+		"Namespace":          "namespace",
+		"Period":             "period",
+		"Statistic":          "statistic",
+		"ExtendedStatistic":  "extended_statistic",
+		"TreatMissingData":   "treat_missing_data",
+		"AlarmActions":       "alarm_actions",
+		"OKActions":          "ok_actions",
+	}); err != nil {
+		return nil, err
+	}
+	if _, has := body["alarm_name"]; !has {
+		body["alarm_name"] = id
+	}
+
+	if p.has("Metrics") {
+		return nil, fmt.Errorf("alarm of metric math is not supported")
+	}
+
+	if dims := p.seq("Dimensions"); len(dims) != 0 {
+		dimensions := map[string]any{}
+		for _, d := range dims {
+			name, _ := d.p["Name"].(string)
+			v, err := e.value(d.p["Value"])
+			if err != nil {
+				return nil, err
+			}
+			dimensions[name] = v
+		}
+		body["dimensions"] = dimensions
+	}
+
+	return body, nil
+}
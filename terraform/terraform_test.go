@@ -0,0 +1,194 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package terraform
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const template = `{"Resources": {
+  "PipeStateMachineRole1234": {
+    "Type": "AWS::IAM::Role",
+    "Properties": {
+      "AssumeRolePolicyDocument": {"Statement": [{"Action": "sts:AssumeRole", "Effect": "Allow", "Principal": {"Service": "states.amazonaws.com"}}]}
+    }
+  },
+  "PipeStateMachineRoleDefaultPolicy1234": {
+    "Type": "AWS::IAM::Policy",
+    "Properties": {
+      "PolicyName": "PipeStateMachineRoleDefaultPolicy1234",
+      "PolicyDocument": {"Statement": [{"Action": "sqs:SendMessage", "Effect": "Allow", "Resource": {"Fn::GetAtt": ["Queue1234", "Arn"]}}]},
+      "Roles": [{"Ref": "PipeStateMachineRole1234"}]
+    }
+  },
+  "PipeStateMachine1234": {
+    "Type": "AWS::StepFunctions::StateMachine",
+    "Properties": {
+      "RoleArn": {"Fn::GetAtt": ["PipeStateMachineRole1234", "Arn"]},
+      "DefinitionString": {"Fn::Join": ["", [
+        "{\"StartAt\":\"Sink\",\"States\":{\"Sink\":{\"Type\":\"Task\",\"Resource\":\"arn:",
+        {"Ref": "AWS::Partition"},
+        ":states:::aws-sdk:sqs:sendMessage\",\"Arguments\":{\"QueueUrl\":\"",
+        {"Ref": "Queue1234"},
+        "\",\"MessageBody\":\"{% $states.input %}\"},\"End\":true}}}"
+      ]]}
+    },
+    "DependsOn": ["PipeStateMachineRoleDefaultPolicy1234", "PipeStateMachineRole1234"]
+  },
+  "PipeRule1234": {
+    "Type": "AWS::Events::Rule",
+    "Properties": {
+      "EventBusName": "my-event-bus",
+      "EventPattern": {"detail-type": ["User"]},
+      "State": "ENABLED",
+      "Targets": [{"Arn": {"Ref": "PipeStateMachine1234"}, "Id": "Target0", "RoleArn": {"Fn::GetAtt": ["PipeStateMachineEventsRole1234", "Arn"]}}]
+    }
+  },
+  "PipeStateMachineEventsRole1234": {
+    "Type": "AWS::IAM::Role",
+    "Properties": {
+      "AssumeRolePolicyDocument": {"Statement": [{"Action": "sts:AssumeRole", "Effect": "Allow", "Principal": {"Service": "events.amazonaws.com"}}]}
+    }
+  },
+  "Queue1234": {
+    "Type": "AWS::SQS::Queue",
+    "Properties": {"MessageRetentionPeriod": 1209600}
+  },
+  "CDKMetadata": {"Type": "AWS::CDK::Metadata", "Properties": {"Analytics": "v2"}}
+}}`
+
+func TestExport(t *testing.T) {
+	raw, err := Export([]byte(template))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tf struct {
+		Resource map[string]map[string]map[string]any `json:"resource"`
+		Data     map[string]any                       `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &tf); err != nil {
+		t.Fatal(err)
+	}
+
+	for kind, names := range map[string][]string{
+		"aws_iam_role":                {"PipeStateMachineRole1234", "PipeStateMachineEventsRole1234"},
+		"aws_iam_role_policy":         {"PipeStateMachineRoleDefaultPolicy1234"},
+		"aws_sfn_state_machine":       {"PipeStateMachine1234"},
+		"aws_cloudwatch_event_rule":   {"PipeRule1234"},
+		"aws_cloudwatch_event_target": {"PipeRule1234_0"},
+		"aws_sqs_queue":               {"Queue1234"},
+	} {
+		for _, name := range names {
+			if _, has := tf.Resource[kind][name]; !has {
+				t.Errorf("%s.%s is not exported", kind, name)
+			}
+		}
+	}
+
+	if _, has := tf.Data["aws_partition"]; !has {
+		t.Errorf("aws_partition is not declared")
+	}
+
+	sfn := tf.Resource["aws_sfn_state_machine"]["PipeStateMachine1234"]
+	for key, expect := range map[string]string{
+		"role_arn":   "${aws_iam_role.PipeStateMachineRole1234.arn}",
+		"definition": `"Resource":"arn:${data.aws_partition.current.partition}:states:::aws-sdk:sqs:sendMessage","Arguments":{"QueueUrl":"${aws_sqs_queue.Queue1234.url}"`,
+	} {
+		if v, _ := sfn[key].(string); !strings.Contains(v, expect) {
+			t.Errorf("unexpected %s: %s", key, v)
+		}
+	}
+
+	if deps, _ := json.Marshal(sfn["depends_on"]); string(deps) != `["aws_iam_role.PipeStateMachineRole1234","aws_iam_role_policy.PipeStateMachineRoleDefaultPolicy1234"]` {
+		t.Errorf("unexpected depends_on: %s", deps)
+	}
+
+	target := tf.Resource["aws_cloudwatch_event_target"]["PipeRule1234_0"]
+	for key, expect := range map[string]string{
+		"rule":           "${aws_cloudwatch_event_rule.PipeRule1234.name}",
+		"event_bus_name": "my-event-bus",
+		"arn":            "${aws_sfn_state_machine.PipeStateMachine1234.arn}",
+		"role_arn":       "${aws_iam_role.PipeStateMachineEventsRole1234.arn}",
+	} {
+		if v, _ := target[key].(string); v != expect {
+			t.Errorf("unexpected %s: %s", key, v)
+		}
+	}
+
+	policy := tf.Resource["aws_iam_role_policy"]["PipeStateMachineRoleDefaultPolicy1234"]
+	if v, _ := policy["policy"].(string); !strings.Contains(v, `"Resource":"${aws_sqs_queue.Queue1234.arn}"`) {
+		t.Errorf("unexpected policy: %s", v)
+	}
+	if v, _ := policy["role"].(string); v != "${aws_iam_role.PipeStateMachineRole1234.name}" {
+		t.Errorf("unexpected role: %s", v)
+	}
+}
+
+func TestExportFunction(t *testing.T) {
+	raw, err := Export([]byte(`{"Resources": {
+	  "F1234": {"Type": "AWS::Lambda::Function", "Properties": {
+	    "Code": {"S3Bucket": "cdk-assets", "S3Key": "abc.zip"},
+	    "Handler": "bootstrap",
+	    "Runtime": "provided.al2023",
+	    "Environment": {"Variables": {"TYPESTEP_CODEC": "json"}}
+	  }},
+	  "FLogRetention1234": {"Type": "Custom::LogRetention", "Properties": {
+	    "ServiceToken": {"Fn::GetAtt": ["LogRetentionaae0aa3c5b4d4f87b02d85b201efdd8aFD4BFC8A", "Arn"]},
+	    "LogGroupName": {"Fn::Join": ["", ["/aws/lambda/", {"Ref": "F1234"}]]},
+	    "RetentionInDays": 5
+	  }},
+	  "LogRetentionaae0aa3c5b4d4f87b02d85b201efdd8aFD4BFC8A": {"Type": "AWS::Lambda::Function", "Properties": {"Handler": "index.handler"}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expect := range []string{
+		`"filename": "${var.F1234_code}"`,
+		`"F1234_code": {`,
+		`"TYPESTEP_CODEC": "json"`,
+		`"name": "/aws/lambda/${aws_lambda_function.F1234.function_name}"`,
+	} {
+		if !strings.Contains(string(raw), expect) {
+			t.Errorf("export does not contain %s", expect)
+		}
+	}
+
+	if strings.Contains(string(raw), "LogRetentionaae0aa3c5b4d4f87b02d85b201efdd8a") {
+		t.Errorf("log retention provider is exported")
+	}
+}
+
+func TestExportEscape(t *testing.T) {
+	raw, err := Export([]byte(`{"Resources": {
+	  "Queue1234": {"Type": "AWS::SQS::Queue", "Properties": {"QueueName": "${name}"}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(raw), `"name": "$${name}"`) {
+		t.Errorf("literal is not escaped: %s", raw)
+	}
+}
+
+func TestExportUnsupported(t *testing.T) {
+	for _, template := range []string{
+		`{"Resources": {"Pipe": {"Type": "AWS::Pipes::Pipe"}}}`,
+		`{"Resources": {"Queue": {"Type": "AWS::SQS::Queue", "Properties": {"QueueName": {"Fn::Sub": "x"}}}}}`,
+		`{"Resources": {"Queue": {"Type": "AWS::SQS::Queue", "Properties": {"QueueName": {"Ref": "Undefined"}}}}}`,
+	} {
+		if _, err := Export([]byte(template)); err == nil {
+			t.Errorf("unsupported template is exported: %s", template)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// F2 is the contract of lambda function 𝑓: A ⟼ B failing with domain error
+// `E`, the error is the part of the type, which is handled by the pipeline.
+type F2[A, B any, E error] interface {
+	F[A, B]
+
+	// HKT2 is a phantom method that represents the type-level
+	// information of a function A → (B, E). It is not meant to be called.
+	HKT2(func(A) (B, E))
+}
+
+// ErrorHandler of domain error, see [RetryError] and [RouteError]. Domain
+// errors, which are not handled, follow the policy of the pipeline
+// (e.g. dead-letter queue).
+type ErrorHandler struct {
+	retry *awsstepfunctions.RetryProps
+	queue awssqs.IQueue
+}
+
+// RetryError retries the function on domain error, the error follows
+// the policy of the pipeline once attempts are exhausted.
+func RetryError(retry *awsstepfunctions.RetryProps) ErrorHandler {
+	return ErrorHandler{retry: retry}
+}
+
+// RouteError sends the input of the function and the error into the queue,
+// the execution succeeds. The domain error is never retried.
+func RouteError(queue awssqs.IQueue) ErrorHandler {
+	return ErrorHandler{queue: queue}
+}
+
+// Throws declares domain error `E` of the function 𝑓: A ⟼ B and its handler.
+// The runtime wrapper reports the error found within the chain of failure
+// (e.g. wrapped by fmt.Errorf) with the type observed by the state machine
+// (see [runtime.ErrorTypeOf]), catch rules of the step dispatch it to
+// the handler. Declarations are stacked to handle multiple errors.
+//
+//	f := typestep.Throws[*NotFound](fetch, typestep.RouteError(missing))
+//	f = typestep.Throws[RateLimited](f, typestep.RetryError(&awsstepfunctions.RetryProps{}))
+//
+// The error `E` must be the concrete type, the message of error is JSON if
+// the type has exported fields. Only functions deployed by the stack report
+// domain errors with the runtime wrapper.
+func Throws[E error, A, B any](f F[A, B], h ErrorHandler) F2[A, B, E] {
+	if reflect.TypeOf(new(E)).Elem().Kind() == reflect.Interface {
+		panic(fmt.Errorf("domain error %s is not concrete type", reflect.TypeOf(new(E)).Elem()))
+	}
+
+	return throws[A, B, E]{f: f, err: domainError{kind: runtime.ErrorTypeOf[E](), handler: h}}
+}
+
+type throws[A, B any, E error] struct {
+	f   F[A, B]
+	err domainError
+}
+
+func (c throws[A, B, E]) HKT1(func(A) B)         {}
+func (c throws[A, B, E]) HKT2(func(A) (B, E))    {}
+func (c throws[A, B, E]) F() awslambda.IFunction { return c.f.F() }
+
+func (c throws[A, B, E]) decorate(fn *lambda) {
+	fn.errors = append(fn.errors, c.err)
+	decorate(c.f, fn)
+}
+
+type domainError struct {
+	kind    string
+	handler ErrorHandler
+}
+
+// throws configures the runtime wrapper to report domain errors and
+// the retry policy of the step on domain errors. Errors routed into queues
+// are never retried, retries of domain errors precede the default policy.
+func (ts *typeStep) throws(f lambda, compute awsstepfunctionstasks.LambdaInvoke) {
+	if len(f.errors) == 0 {
+		return
+	}
+
+	kinds := make([]string, len(f.errors))
+	for i, e := range f.errors {
+		kinds[i] = e.kind
+
+		retry := &awsstepfunctions.RetryProps{MaxAttempts: jsii.Number(0)}
+		if e.handler.retry != nil {
+			policy := *e.handler.retry
+			retry = &policy
+		}
+		retry.Errors = jsii.Strings(e.kind)
+		compute.AddRetry(retry)
+	}
+
+	ts.setenv(f.f, runtime.EnvErrors, strings.Join(kinds, ","))
+}
+
+// catches routes domain errors into queues
+//
+//	LambdaInvoke ⟼ (catch E) SQS ⟼ Succeed
+func (ts *typeStep) catches(f lambda, compute awsstepfunctionstasks.LambdaInvoke, uuid string) {
+	for _, e := range f.errors {
+		if e.handler.queue == nil {
+			continue
+		}
+
+		id := "Catch" + uuid + e.kind
+		send, _ := ts.send(id, queue{q: e.handler.queue, dlq: true}, "$")
+		done := awsstepfunctions.NewSucceed(ts.scope, jsii.String(id+"Done"), &awsstepfunctions.SucceedProps{})
+		ts.sizes[len(ts.sizes)-1] += 2
+
+		compute.AddCatch(
+			send.Next(done),
+			&awsstepfunctions.CatchProps{
+				Errors:     jsii.Strings(e.kind),
+				ResultPath: jsii.String("$.error"),
+			},
+		)
+	}
+}
@@ -0,0 +1,94 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/scud"
+	"github.com/fogfish/typestep"
+	"github.com/fogfish/typestep/internal/test"
+	"github.com/fogfish/typestep/runtime"
+)
+
+type NotFound struct {
+	ID string `json:"id"`
+}
+
+func (e *NotFound) Error() string { return "not found " + e.ID }
+
+type RateLimited struct{}
+
+func (RateLimited) Error() string { return "rate limited" }
+
+func TestThrows(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	missing := awssqs.Queue_FromQueueArn(stack, jsii.String("Missing"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-missing"))
+	dlq := awssqs.Queue_FromQueueArn(stack, jsii.String("DLQ"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-dlq"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// THEN
+	var g typestep.F2[string, string, RateLimited] = typestep.Throws[RateLimited](
+		typestep.Throws[*NotFound](f, typestep.RouteError(missing)),
+		typestep.RetryError(&awsstepfunctions.RetryProps{MaxAttempts: jsii.Number(5)}),
+	)
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(g, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: dlq,
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"Environment": map[string]any{
+				"Variables": map[string]any{
+					runtime.EnvErrors: "RateLimited,NotFound",
+				},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`{"ErrorEquals":["RateLimited"],"MaxAttempts":5},{"ErrorEquals":["NotFound"],"MaxAttempts":0}`,
+		`"Catch":[{"ErrorEquals":["NotFound"],"ResultPath":"$.error","Next":"CatchFNotFound"},{"ErrorEquals":["States.ALL"],"ResultPath":"$.error","Next":"TryF"}]`,
+		`"CatchFNotFound":{"Next":"CatchFNotFoundDone"`,
+		`"CatchFNotFoundDone":{"Type":"Succeed"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awstimestream"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+// Yield results of 𝑚: A ⟼ B binding it with Amazon Timestream. Each result
+// is written as the record of the table, the selected fields are dimensions
+// and the measure of the record. The name of dimension or measure is the
+// JSON name of the field. The time of record is the time of the step.
+//
+//	typestep.ToTimestream(table,
+//	  []typestep.Selector[Metric]{typestep.Field(func(m *Metric) *string { return &m.Region })},
+//	  typestep.Field(func(m *Metric) *float64 { return &m.Latency }),
+//	  m,
+//	)
+func ToTimestream[A, B any](
+	table awstimestream.CfnTable,
+	dimensions []Selector[B],
+	measure Selector[B],
+	m duct.Morphism[A, B],
+) duct.Morphism[A, duct.Void] {
+	sink := timestream{
+		table:      table,
+		kind:       reflect.TypeOf(new(B)).Elem(),
+		dimensions: make([]fieldPath, len(dimensions)),
+		measure:    measure.path,
+		measureOf:  measure.kind,
+	}
+	for i, d := range dimensions {
+		sink.dimensions[i] = d.path
+	}
+	return duct.Yield(duct.L1[B](sink), m)
+}
+
+// sink of category B into Amazon Timestream
+type timestream struct {
+	table      awstimestream.CfnTable
+	kind       reflect.Type
+	dimensions []fieldPath
+	measure    fieldPath
+	measureOf  reflect.Type
+}
+
+// record builds JSONata expression of the Timestream record for the value
+func (f timestream) record(n runtime.FieldNamer, value string) (string, error) {
+	if len(f.measure) == 0 {
+		return "", fmt.Errorf("undefined measure of timestream record %s", f.kind)
+	}
+
+	measureType, err := measureTypeOf(f.measureOf)
+	if err != nil {
+		return "", err
+	}
+
+	dimensions := make([]string, len(f.dimensions))
+	for i, x := range f.dimensions {
+		d := x.names(n)
+		dimensions[i] = `{"Name": ` + quote(strings.Join(d, ".")) + `, "Value": $string(` + selectorOf(value, d) + `)}`
+	}
+
+	record := []string{
+		`"Dimensions": [` + strings.Join(dimensions, ", ") + `]`,
+		`"MeasureName": ` + quote(strings.Join(f.measure.names(n), ".")),
+		`"MeasureValue": $string(` + selectorOf(value, f.measure.names(n)) + `)`,
+		`"MeasureValueType": ` + quote(measureType),
+		`"Time": $string($toMillis($states.context.State.EnteredTime))`,
+		`"TimeUnit": "MILLISECONDS"`,
+	}
+
+	return "{" + strings.Join(record, ", ") + "}", nil
+}
+
+// selectorOf the field of JSONata value
+func selectorOf(value string, path []string) string {
+	for _, x := range path {
+		value += ".`" + x + "`"
+	}
+	return value
+}
+
+// measureTypeOf maps Go type into Timestream type of measure
+func measureTypeOf(t reflect.Type) (string, error) {
+	if t == nil {
+		return "DOUBLE", nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.String:
+		return "VARCHAR", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "BIGINT", nil
+	case reflect.Float32, reflect.Float64:
+		return "DOUBLE", nil
+	default:
+		return "", fmt.Errorf("type %s is not supported by timestream measure", t)
+	}
+}
+
+// timestream creates the task for writing the value into the table
+func (ts *typeStep) timestream(f timestream) error {
+	if f.kind.Kind() == reflect.Slice {
+		return fmt.Errorf("timestream sink does not support sequence %s, use Lift", f.kind)
+	}
+
+	record, err := f.record(ts.namer, "$states.input"+strings.TrimPrefix(ts.args, "$"))
+	if err != nil {
+		return err
+	}
+
+	sink := awsstepfunctionstasks.CallAwsService_Jsonata(ts.scope, jsii.String(ts.unique("Sink")),
+		&awsstepfunctionstasks.CallAwsServiceJsonataProps{
+			Comment: ts.comment(),
+			Service: jsii.String("timestreamwrite"),
+			Action:  jsii.String("writeRecords"),
+			Parameters: &map[string]any{
+				"DatabaseName": f.table.DatabaseName(),
+				"TableName":    f.table.AttrName(),
+				"Records":      "{% [" + record + "] %}",
+			},
+			IamResources: jsii.Strings(*f.table.AttrArn()),
+			IamAction:    jsii.String("timestream:WriteRecords"),
+			// Note: Timestream requires endpoint discovery
+			AdditionalIamStatements: &[]awsiam.PolicyStatement{
+				awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+					Actions:   jsii.Strings("timestream:DescribeEndpoints"),
+					Resources: jsii.Strings("*"),
+				}),
+			},
+		},
+	)
+	ts.append(sink)
+
+	return nil
+}
@@ -0,0 +1,83 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awstimestream"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+type Metric struct {
+	Region  string  `json:"region"`
+	Latency float64 `json:"latency"`
+}
+
+func TestToTimestream(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	table := awstimestream.NewCfnTable(stack, jsii.String("Table"),
+		&awstimestream.CfnTableProps{
+			DatabaseName: jsii.String("metrics"),
+			TableName:    jsii.String("latency"),
+		},
+	)
+
+	a := typestep.Function_FromFunctionArn[string, Metric](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToTimestream(table,
+		[]typestep.Selector[Metric]{typestep.Field(func(m *Metric) *string { return &m.Region })},
+		typestep.Field(func(m *Metric) *float64 { return &m.Latency }),
+		p2,
+	)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::IAM::Policy"),
+		map[string]any{
+			"PolicyDocument": map[string]any{
+				"Statement": assertions.Match_ArrayWith(&[]any{
+					map[string]any{
+						"Action":   "timestream:DescribeEndpoints",
+						"Effect":   "Allow",
+						"Resource": "*",
+					},
+				}),
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Resource":"arn::states:::aws-sdk:timestreamwrite:writeRecords"`,
+		`"DatabaseName":"metrics"`,
+		`{\"Name\": \"region\", \"Value\": $string($states.input.Payload.` + "`region`" + `)}`,
+		`\"MeasureName\": \"latency\"`,
+		`\"MeasureValueType\": \"DOUBLE\"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
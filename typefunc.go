@@ -18,9 +18,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscodedeploy"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsssm"
 	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
 	"github.com/fogfish/scud"
+	typestepruntime "github.com/fogfish/typestep/runtime"
 )
 
 // Signature for type-safe entry point to lambda function
@@ -46,20 +52,78 @@ type Lambda[A, B any] = func() func(context.Context, A) (B, error)
 // information throughout the deployment process.
 type Function[A, B any] struct {
 	Function awslambda.Function
+
+	// Alias of the function invoked by pipelines, it is defined when
+	// the deployment shifts the traffic (see FunctionTypedProps.Deployment).
+	Alias awslambda.Alias
 }
 
 func (f *Function[A, B]) HKT1(func(A) B)         {}
 func (f *Function[A, B]) F() awslambda.IFunction { return f.Function }
 
+func (f *Function[A, B]) decorate(fn *lambda) {
+	if f.Alias != nil {
+		fn.alias = f.Alias
+	}
+}
+
 // Instantiates deployment for "type-safe" AWS Lambda.
 func NewFunctionTyped[A, B any](scope constructs.Construct, id *string, spec *FunctionTypedProps[A, B]) *Function[A, B] {
 	path := autogen(spec.Handler, spec.SourceCodeModule, spec.AutoGen)
 	spec.SourceCodeLambda = filepath.Join(path, agdir)
 	flambda := scud.NewFunctionGo(scope, id, spec.FunctionGoProps)
 
-	return &Function[A, B]{Function: flambda}
+	f := &Function[A, B]{Function: flambda}
+	if len(spec.Secrets) != 0 || len(spec.Parameters) != 0 {
+		inject(scope, flambda, spec.Secrets, spec.Parameters)
+	}
+
+	if spec.Deployment != nil {
+		f.Alias = awslambda.NewAlias(scope, jsii.String(*id+"Alias"),
+			&awslambda.AliasProps{
+				AliasName: jsii.String(aliasName),
+				Version:   flambda.CurrentVersion(),
+			},
+		)
+
+		awscodedeploy.NewLambdaDeploymentGroup(scope, jsii.String(*id+"Deployment"),
+			&awscodedeploy.LambdaDeploymentGroupProps{
+				Alias:            f.Alias,
+				DeploymentConfig: spec.Deployment,
+			},
+		)
+	}
+
+	return f
 }
 
+// inject references of secrets into the function and grants access to them
+func inject(
+	scope constructs.Construct,
+	f awslambda.Function,
+	secrets map[string]awssecretsmanager.ISecret,
+	parameters map[string]awsssm.IParameter,
+) {
+	refs := map[string]*string{}
+	for name, secret := range secrets {
+		secret.GrantRead(f, nil)
+		refs[name] = secret.SecretArn()
+	}
+	for name, parameter := range parameters {
+		if _, has := refs[name]; has {
+			panic(fmt.Errorf("secret %s is declared twice", name))
+		}
+		parameter.GrantRead(f)
+		refs[name] = parameter.ParameterName()
+	}
+
+	spec := awscdk.Stack_Of(scope).ToJsonString(refs, nil)
+	f.AddEnvironment(jsii.String(typestepruntime.EnvSecrets), spec, nil)
+}
+
+// name of the alias invoked by pipelines
+const aliasName = "live"
+
 // Imports an existing AWS Lambda function with type-safe annotations.
 type IFunction[A, B any] struct {
 	Handler awslambda.IFunction
@@ -98,6 +162,21 @@ type FunctionTypedProps[A, B any] struct {
 	*scud.FunctionGoProps
 	Handler Lambda[A, B]
 	AutoGen bool
+
+	// Deployment shifts the traffic to the new code of function gradually
+	// (e.g. awscodedeploy.LambdaDeploymentConfig_CANARY_10PERCENT_5MINUTES()).
+	// Pipelines invoke the alias `live` of the function, which is managed by
+	// CodeDeploy. Steps are rolled out without redeploying the state machine.
+	Deployment awscodedeploy.ILambdaDeploymentConfig
+
+	// Secrets of the function, indexed by name. The function is granted
+	// to read secrets, the runtime wrapper fetches them at cold start and
+	// surfaces to handler through [runtime.Secret].
+	Secrets map[string]awssecretsmanager.ISecret
+
+	// Parameters of the function, indexed by name. They are SSM parameters
+	// injected as [FunctionTypedProps.Secrets], SecureString is decrypted.
+	Parameters map[string]awsssm.IParameter
 }
 
 func (f *FunctionTypedProps[A, B]) ForceAutoGen() *FunctionTypedProps[A, B] {
@@ -105,6 +184,27 @@ func (f *FunctionTypedProps[A, B]) ForceAutoGen() *FunctionTypedProps[A, B] {
 	return f
 }
 
+func (f *FunctionTypedProps[A, B]) WithDeployment(config awscodedeploy.ILambdaDeploymentConfig) *FunctionTypedProps[A, B] {
+	f.Deployment = config
+	return f
+}
+
+func (f *FunctionTypedProps[A, B]) WithSecret(name string, secret awssecretsmanager.ISecret) *FunctionTypedProps[A, B] {
+	if f.Secrets == nil {
+		f.Secrets = map[string]awssecretsmanager.ISecret{}
+	}
+	f.Secrets[name] = secret
+	return f
+}
+
+func (f *FunctionTypedProps[A, B]) WithParameter(name string, parameter awsssm.IParameter) *FunctionTypedProps[A, B] {
+	if f.Parameters == nil {
+		f.Parameters = map[string]awsssm.IParameter{}
+	}
+	f.Parameters[name] = parameter
+	return f
+}
+
 // Constructor for NewFunctionTypedProps to support automatic inference of types from function
 func NewFunctionTypedProps[A, B any](f Lambda[A, B], props *scud.FunctionGoProps) *FunctionTypedProps[A, B] {
 	return &FunctionTypedProps[A, B]{
@@ -119,6 +219,7 @@ const agdir = "autogen"
 
 // autogen generates a `main.go` file for the provided Lambda function.
 // The file is created in the `autogen` directory relative to the source code module.
+// The existing file is regenerated if it does not match the template.
 func autogen[A, B any](f Lambda[A, B], scModule string, force bool) string {
 	fptr := reflect.ValueOf(f).Pointer()
 	fobj := runtime.FuncForPC(fptr)
@@ -130,25 +231,29 @@ func autogen[A, B any](f Lambda[A, B], scModule string, force bool) string {
 	path := strings.TrimSuffix(name, filepath.Ext(name))
 	base := filepath.Base(name)
 
-	code := fmt.Sprintf(`// DO NOT EDIT !!!
-// THE FILE IS AUTO GENERATED BY github.com/fogfish/typestep
-// %s
-package main
+	body := fmt.Sprintf(`package main
 
 import (
-  "github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/fogfish/typestep/runtime"
 	"%s"
 )
 
-func main() { lambda.Start(%s()) }
-`, time.Now(), path, base)
+func main() { lambda.Start(runtime.Handler(%s())) }
+`, path, base)
+
+	code := fmt.Sprintf(`// DO NOT EDIT !!!
+// THE FILE IS AUTO GENERATED BY github.com/fogfish/typestep
+// %s
+%s`, time.Now(), body)
 
 	gofile, _ := fobj.FileLine(fptr)
 	codepath := filepath.Join(filepath.Dir(gofile), agdir, "main.go")
 
 	if !force {
-		if _, err := os.Stat(codepath); err == nil {
-			// If the file already exists, we assume it has been generated before
+		// The file generated before is kept unless it differs from the template
+		// (e.g. it is generated by earlier versions without the runtime wrapper).
+		if old, err := os.ReadFile(codepath); err == nil && strings.HasSuffix(string(old), body) {
 			return strings.TrimPrefix(path, scModule)
 		}
 	}
@@ -9,10 +9,19 @@
 package typestep_test
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscodedeploy"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsssm"
 	"github.com/aws/jsii-runtime-go"
 	"github.com/fogfish/scud"
 	"github.com/fogfish/typestep"
@@ -44,3 +53,137 @@ func TestFunctionTyped(t *testing.T) {
 	}
 
 }
+
+func TestFunctionTypedDeployment(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		).WithDeployment(awscodedeploy.LambdaDeploymentConfig_CANARY_10PERCENT_5MINUTES()),
+	)
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(f, typestep.From[string](event))))
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Alias"),
+		map[string]any{
+			"Name": "live",
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::CodeDeploy::DeploymentGroup"),
+		map[string]any{
+			"DeploymentConfigName": "CodeDeployDefault.LambdaCanary10Percent5Minutes",
+		},
+	)
+	template.HasResource(jsii.String("AWS::Lambda::Alias"),
+		map[string]any{
+			"UpdatePolicy": map[string]any{
+				"CodeDeployLambdaAliasUpdate": assertions.Match_AnyValue(),
+			},
+		},
+	)
+
+	sfn := template.FindResources(jsii.String("AWS::StepFunctions::StateMachine"), nil)
+	raw, _ := json.Marshal(sfn)
+	if !strings.Contains(string(raw), `{"Ref":"FAlias`) {
+		t.Errorf("state machine shall invoke alias of function")
+	}
+}
+
+func TestFunctionTypedSecrets(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	secret := awssecretsmanager.Secret_FromSecretCompleteArn(stack, jsii.String("Secret"),
+		jsii.String("arn:aws:secretsmanager:eu-west-1:000000000000:secret:token-AbCdEf"))
+	param := awsssm.StringParameter_FromStringParameterName(stack, jsii.String("Param"), jsii.String("endpoint"))
+
+	// THEN
+	typestep.NewFunctionTyped(stack, jsii.String("T"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		).WithSecret("token", secret).WithParameter("endpoint", param),
+	)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"Environment": map[string]any{
+				"Variables": assertions.Match_ObjectLike(&map[string]any{
+					"TYPESTEP_SECRETS": `{"endpoint":"endpoint","token":"arn:aws:secretsmanager:eu-west-1:000000000000:secret:token-AbCdEf"}`,
+				}),
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::IAM::Policy"),
+		map[string]any{
+			"PolicyDocument": map[string]any{
+				"Statement": assertions.Match_ArrayWith(&[]any{
+					assertions.Match_ObjectLike(&map[string]any{
+						"Action":   []any{"secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"},
+						"Resource": "arn:aws:secretsmanager:eu-west-1:000000000000:secret:token-AbCdEf",
+					}),
+				}),
+			},
+		},
+	)
+}
+
+func TestFunctionTypedAutoGenMigration(t *testing.T) {
+	// GIVEN
+	codepath := filepath.Join("internal", "test", "autogen", "main.go")
+	if code, err := os.ReadFile(codepath); err == nil {
+		t.Cleanup(func() { os.WriteFile(codepath, code, 0766) })
+	}
+
+	legacy := `// DO NOT EDIT !!!
+// THE FILE IS AUTO GENERATED BY github.com/fogfish/typestep
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/fogfish/typestep/internal/test"
+)
+
+func main() { lambda.Start(test.Main()) }
+`
+	if err := os.MkdirAll(filepath.Dir(codepath), 0766); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(codepath, []byte(legacy), 0766); err != nil {
+		t.Fatal(err)
+	}
+
+	// THEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	typestep.NewFunctionTyped(stack, jsii.String("T"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// WHEN
+	code, err := os.ReadFile(codepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(code), "lambda.Start(runtime.Handler(test.Main()))") {
+		t.Errorf("autogen/main.go is not regenerated: %s", code)
+	}
+}
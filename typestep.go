@@ -12,16 +12,21 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"reflect"
 
+	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctionstasks"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
 	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
 )
 
 // F is a generic interface that represents a function from A to B
@@ -43,12 +48,31 @@ type F[A, B any] interface {
 
 // Creates new morphism 𝑚, binding it with EventBridge for reading category `A` events.
 func From[A any](in awsevents.IEventBus, cat ...string) duct.Morphism[A, A] {
-	return duct.From(duct.L1[A](source{cat: cat, bus: in}))
+	return duct.From(duct.L1[A](source{cat: cat, bus: in, kind: reflect.TypeOf(new(A)).Elem()}))
 }
 
 type source struct {
-	cat []string
-	bus awsevents.IEventBus
+	cat     []string
+	bus     awsevents.IEventBus
+	kind    reflect.Type
+	where   []clause
+	batched bool
+}
+
+// Batch of events, it is the detail of events consumed by [FromBatch].
+type Batch[A any] struct {
+	Items []A `json:"items"`
+}
+
+// Creates new morphism 𝑚, binding it with EventBridge for reading batches of
+// category `A` events, which allows producers amortize PutEvents calls. The
+// detail of event is [Batch], the detail-type is `[]A` unless category is
+// defined. The rest of the pipeline is wrapped into the Map, it is evaluated
+// for each element of the batch.
+//
+//	typestep.FromBatch[Order](bus)
+func FromBatch[A any](in awsevents.IEventBus, cat ...string) duct.Morphism[[]A, A] {
+	return duct.WrapF(duct.From(duct.L1[[]A](source{cat: cat, bus: in, kind: reflect.TypeOf(new([]A)).Elem(), batched: true})))
 }
 
 // Compose lambda function transformer 𝑓: B ⟼ C with morphism 𝑚: A ⟼ B producing a new morphism 𝑚: A ⟼ C.
@@ -56,13 +80,45 @@ func Join[A, B, C any](
 	f F[B, C],
 	m duct.Morphism[A, B],
 ) duct.Morphism[A, C] {
-	fn := lambda{concurency: 1, f: f.F()}
+	fn := newLambda(1, f)
 	return duct.Join(duct.L2[B, C](fn), m)
 }
 
 type lambda struct {
 	concurency int
 	f          awslambda.IFunction
+	input      reflect.Type
+	reply      reflect.Type
+	cache      *cache
+	metrics    []runtime.Metric
+	chaos      *runtime.Chaos
+	fallback   *fallback
+	scoped     bool
+	retry      *awsstepfunctions.RetryProps
+	alias      awslambda.IFunction
+	errors     []domainError
+}
+
+func newLambda[A, B any](concurency int, f F[A, B]) lambda {
+	fn := lambda{
+		concurency: concurency,
+		f:          f.F(),
+		input:      reflect.TypeOf(new(A)).Elem(),
+		reply:      reflect.TypeOf(new(B)).Elem(),
+	}
+	decorate(f, &fn)
+	return fn
+}
+
+// decorator of the typed function configures its step within state machine
+type decorator interface {
+	decorate(*lambda)
+}
+
+func decorate(f any, fn *lambda) {
+	if d, ok := f.(decorator); ok {
+		d.decorate(fn)
+	}
 }
 
 // Compose lambda function transformer 𝑓: B ⟼ C with morphism 𝑚: A ⟼ []B.
@@ -76,7 +132,7 @@ func Lift[A, B, C any](
 	f F[B, C],
 	m duct.Morphism[A, []B],
 ) duct.Morphism[A, C] {
-	fn := lambda{concurency: 1, f: f.F()}
+	fn := newLambda(1, f)
 	return duct.LiftF(duct.L2[B, C](fn), m)
 }
 
@@ -87,10 +143,30 @@ func LiftP[A, B, C any](
 	f F[B, C],
 	m duct.Morphism[A, []B],
 ) duct.Morphism[A, C] {
-	fn := lambda{concurency: n, f: f.F()}
+	fn := newLambda(n, f)
 	return duct.LiftF(duct.L2[B, C](fn), m)
 }
 
+// LiftB is equivalent to LiftP but packs up to k elements of the sequence
+// into a single invocation of the lambda function 𝑓: []B ⟼ []C. The sequence
+// is partitioned using intrinsic function, each nested computation receives
+// the batch []C. Use it to amortize the cost of large fan-outs.
+func LiftB[A, B, C any](
+	n, k int,
+	f F[[]B, []C],
+	m duct.Morphism[A, []B],
+) duct.Morphism[A, []C] {
+	fn := newLambda(n, f)
+	chunks := duct.Join(duct.L2[[]B, [][]B](batch{size: k, id: *fn.f.Node().Id()}), m)
+	return duct.LiftF(duct.L2[[]B, []C](fn), chunks)
+}
+
+// partitions sequence into chunks of the given size
+type batch struct {
+	size int
+	id   string
+}
+
 // Wrap is equivalent to Lift but operates directly on the inner structure of
 // the morphism 𝑚: A ⟼ []B, extracting individual elements of B while
 // preserving the transformation context, enabling further composition.
@@ -109,19 +185,23 @@ func Unit[A, B any](m duct.Morphism[A, B]) duct.Morphism[A, []B] {
 }
 
 // Yield results of 𝑚: A ⟼ B binding it with AWS SQS.
-func ToQueue[A, B any](q awssqs.IQueue, m duct.Morphism[A, B]) duct.Morphism[A, duct.Void] {
-	return duct.Yield(duct.L1[B](q), m)
+func ToQueue[A, B any](q awssqs.IQueue, m duct.Morphism[A, B], opts ...*QueueProps) duct.Morphism[A, duct.Void] {
+	sink := queue{q: q, kind: reflect.TypeOf(new(B)).Elem()}
+	if len(opts) != 0 && opts[0] != nil {
+		sink.delay = opts[0].Delay
+		if opts[0].Acknowledge {
+			sink.ack = opts[0].AckTimeout
+			if sink.ack == 0 {
+				sink.ack = defaultAckTimeout
+			}
+		}
+	}
+	return duct.Yield(duct.L1[B](sink), m)
 }
 
 // Yield results of 𝑚: A ⟼ B binding it with AWS EventBridge.
 func ToEventBus[A, B any](source string, bus awsevents.IEventBus, m duct.Morphism[A, B], cat ...string) duct.Morphism[A, duct.Void] {
-	return duct.Yield(duct.L1[B](eventbus{bus: bus, source: source, cat: cat}), m)
-}
-
-type eventbus struct {
-	bus    awsevents.IEventBus
-	source string
-	cat    []string
+	return duct.Yield(duct.L1[B](eventbus{bus: bus, source: source, cat: cat, kind: reflect.TypeOf(new(B)).Elem()}), m)
 }
 
 //------------------------------------------------------------------------------
@@ -140,17 +220,226 @@ type TypeStepProps struct {
 	// SeqConcurrency is the maximum number of lambda's invocations allowed for
 	// itterators while processing the sequence of computations (morphism 𝑚: A ⟼ []B).
 	SeqConcurrency *float64
+
+	// Encoding is the wire format of payloads passed between typed functions,
+	// JSON is used by default. See [Encoding] for details.
+	Encoding Encoding
+
+	// PayloadOffload enables claim-check pattern. The runtime wrapper of typed
+	// functions stores payloads above the threshold in the bucket and passes
+	// the reference ([runtime.Ref]) through state machine instead. The next
+	// function rehydrates the payload transparently. Sinks receive the
+	// reference, consumers are responsible for rehydration.
+	//
+	// It is recommended to configure lifecycle expiration rule on the bucket.
+	PayloadOffload awss3.IBucket
+
+	// PayloadCompression enables gzip compression of payloads passed between
+	// typed functions. It is lighter alternative to offload. The function
+	// which output is read by other states (e.g. sinks, assertions, sorting,
+	// keys of mutex) emits uncompressed payload at any position.
+	PayloadCompression bool
+
+	// IdempotencyKey is JSONPath within the input `A` (e.g. `$.id`, see [Path]),
+	// its hash is used as the name of execution. Duplicate events with the same key do
+	// not start duplicate executions. Use `$` to hash the entire input.
+	IdempotencyKey string
+
+	// ExecutionName is the template of execution name, placeholders refer
+	// fields of the input `A` (e.g. `order-{{.ID}}`), so that executions are
+	// searchable by business keys. Executions with the same name are not
+	// started twice, the name overrides IdempotencyKey. Values of fields must
+	// be valid names of executions, up to 80 characters.
+	ExecutionName string
+
+	// Correlation enables propagation of correlation id through every step of
+	// the pipeline. The id is available to typed functions through the runtime
+	// wrapper (see [runtime.CorrelationID]). The id of source event is used
+	// unless CorrelationKey is defined.
+	Correlation bool
+
+	// CorrelationKey is JSONPath within the input `A` (e.g. `$.id`, see [Path])
+	// of the correlation id.
+	CorrelationKey string
+
+	// Metadata enables injection of the execution metadata (execution id,
+	// state name, attempt and version of the pipeline) into every typed
+	// function, the runtime wrapper restores it into the context of
+	// the handler (see [runtime.MetaOf]).
+	Metadata bool
+
+	// StrictDecoding enables strict decoding of the input by typed functions,
+	// unknown fields and missing fields annotated with `typestep:"required"`
+	// fail the function with descriptive error instead of silently zeroing
+	// them. It is applied to JSON encoding only.
+	StrictDecoding bool
+
+	// Tracing enables W3C trace context propagation. The trace context is
+	// injected at the source and carried through every step, the runtime
+	// wrapper restores it into the context of typed functions, which enables
+	// a single distributed trace per execution (see [runtime.Traceparent]).
+	Tracing bool
+
+	// MetricsNamespace is CloudWatch namespace of business metrics declared
+	// with [WithMetric], default is `typestep`.
+	MetricsNamespace string
+
+	// InlineStates is the maximum number of states within the iteration of
+	// inline Map (morphism 𝑚: A ⟼ []B), default is 40. The larger iteration
+	// is decomposed into nested state machine, which is started synchronously
+	// for each element of the sequence. The iteration is also decomposed when
+	// the pipeline exceeds the definition budget of Step Functions.
+	InlineStates int
+
+	// GlobalEndpoint binds the source of the pipeline with EventBridge global
+	// endpoint for failover to the secondary region. See [GlobalEndpoint].
+	GlobalEndpoint *GlobalEndpoint
+
+	// Profile of the environment, it scales resources of the pipeline.
+	// See [ProfileDev], [ProfileStage] and [ProfileProd].
+	Profile *Profile
+
+	// Owner of the pipeline (e.g. team), it is listed by the architecture
+	// metadata of the cloud assembly (see [MetadataPipeline]).
+	Owner string
+
+	// Lineage enables emission of OpenLineage run events by typed functions,
+	// it is the namespace of jobs and datasets. The dataset is the type of
+	// payload, the job is the state and the run is the execution. Lineage
+	// enables injection of the execution metadata (see Metadata).
+	Lineage string
+
+	// Registry of types, the synth warns if the pipeline consumes types
+	// deprecated by the registry. See [NewTypeRegistry].
+	Registry *TypeRegistry
+
+	// SchemaCompatibility enables the gate against breaking changes of types.
+	// JSON Schemas of the types used by the pipeline are recorded into SSM
+	// parameter `/typestep/{stack}/{pipeline}`, the synth fails if the schema
+	// is incompatible with the one recorded by the previous deployment (e.g.
+	// required field is removed or renamed), which protects executions running
+	// mid-flight. The gate requires the stack bound to account and region.
+	SchemaCompatibility bool
+
+	// RemovalPolicy of every resource generated by the construct, including
+	// state machines, rules and log groups. Use [awscdk.RemovalPolicy_DESTROY]
+	// for ephemeral environments and [awscdk.RemovalPolicy_RETAIN] to keep
+	// the audit history.
+	RemovalPolicy awscdk.RemovalPolicy
+
+	// LogRetention enables execution logs of state machines, it overrides
+	// the retention declared by the profile.
+	LogRetention awslogs.RetentionDays
+
+	// SizeGuard checks the size of payload, in UTF-8 bytes, after the source
+	// and before each nested computation. The execution fails with [ErrPayloadTooLarge] and
+	// typed diagnostic, which is routed to the dead-letter queue, instead of
+	// opaque `States.DataLimitExceeded` mid-pipeline.
+	SizeGuard bool
+
+	// Audit tees the value of every step into the bucket, giving full data
+	// lineage of executions without instrumenting functions. Objects are
+	// partitioned by pipeline, execution and step.
+	Audit awss3.IBucket
+
+	// Naming is the policy of detail-types, it decouples wire contracts of
+	// pipelines from names of Go types. See [Naming] for details.
+	Naming *Naming
+
+	// Retry is the default retry policy of functions, the policy retries
+	// `Lambda.ServiceException`, `Lambda.TooManyRequestsException` and
+	// `States.TaskFailed` unless errors are explicitly defined. Use
+	// [WithRetry] to override the policy of the step.
+	Retry *awsstepfunctions.RetryProps
+
+	// Deployment of pipelines, the definition is updated in-place by default.
+	// Use [BlueGreen] to deploy the new definition side-by-side with the live
+	// one, which does not risk in-flight executions.
+	Deployment *BlueGreen
+
+	// Tenancy isolates concurrency of tenants within the pipeline, the tenant
+	// id is the field of input `A` annotated with `typestep:"tenant"`.
+	// See [Tenancy] for details.
+	Tenancy *Tenancy
+
+	// Engine of pipelines, AWS Step Functions is used by default. Use
+	// [EnginePipes] for high-volume sources, the chain `From` ⟼ `Join` ⟼ sink
+	// is compiled into EventBridge Pipe instead of the execution per event.
+	Engine Engine
+
+	// Backpressure disables the rule consuming events of the source when
+	// the dead-letter queue backs up or executions fail, and enables it after
+	// recovery. See [Backpressure] for details.
+	Backpressure *Backpressure
+
+	// Budget switches the pipeline into degraded mode when the alarm on
+	// the spending is raised. See [Budget] for details.
+	Budget *Budget
 }
 
 // private type - duct ast builder
 type typeStep struct {
 	constructs.Construct
-	DeadLetterQueue awssqs.IQueue
-	bus             awsevents.IEventBus
-	eventPattern    *awsevents.EventPattern
-	args            string
-	stack           []awsstepfunctions.Chain
-	names           []string
+	DeadLetterQueue   awssqs.IQueue
+	pipelines         []awsstepfunctions.StateMachine
+	machines          []awsstepfunctions.StateMachine
+	scope             constructs.Construct
+	bus               awsevents.IEventBus
+	eventPattern      *awsevents.EventPattern
+	args              string
+	stack             []awsstepfunctions.Chain
+	names             []string
+	sizes             []int
+	paths             []string
+	inlineStates      int
+	global            *GlobalEndpoint
+	profile           *Profile
+	compatibility     bool
+	schemas           schemas
+	logRetention      awslogs.RetentionDays
+	sizeGuard         bool
+	windowed          *windowed
+	queued            *queued
+	naming            *Naming
+	retry             *awsstepfunctions.RetryProps
+	blueGreenMode     *BlueGreen
+	engine            Engine
+	tenancy           *Tenancy
+	backpressure      *Backpressure
+	budgeted          *Budget
+	owner             string
+	registry          *TypeRegistry
+	lineage           string
+	steps             map[string]int
+	latencies         []latency
+	auditing          awss3.IBucket
+	audits            []audited
+	semaphores        []semaphore
+	mutexes           []mutex
+	queues            []awssqs.IQueue
+	version           string
+	encoding          Encoding
+	namer             runtime.FieldNamer
+	offload           awss3.IBucket
+	compression       bool
+	idempotencyKey    string
+	executionTemplate string
+	executionName     string
+	correlation       bool
+	correlationKey    string
+	tracing           bool
+	metadata          bool
+	api               *route
+	restarts          bool
+	ids               map[string]bool
+	uuid              string
+	strict            bool
+	describe          string
+	metricsNamespace  string
+	protos            protoFiles
+	lastf             awslambda.IFunction
+	plain             map[awslambda.IFunction]bool
+	functions         []awslambda.IFunction
 }
 
 type node interface {
@@ -164,24 +453,142 @@ var _ duct.Visitor = (*typeStep)(nil)
 // Create a new instance of TypeStep construct
 func NewTypeStep(scope constructs.Construct, id *string, props *TypeStepProps) TypeStep {
 	builder := &typeStep{
-		Construct:       constructs.NewConstruct(scope, id),
-		DeadLetterQueue: props.DeadLetterQueue,
-		stack:           []awsstepfunctions.Chain{nil},
-		names:           []string{""},
+		Construct:         constructs.NewConstruct(scope, id),
+		DeadLetterQueue:   props.DeadLetterQueue,
+		encoding:          props.Encoding,
+		namer:             runtime.FieldNamerOf(string(props.Encoding)),
+		offload:           props.PayloadOffload,
+		compression:       props.PayloadCompression,
+		plain:             map[awslambda.IFunction]bool{},
+		idempotencyKey:    props.IdempotencyKey,
+		executionTemplate: props.ExecutionName,
+		correlation:       props.Correlation || props.CorrelationKey != "",
+		correlationKey:    props.CorrelationKey,
+		tracing:           props.Tracing,
+		metadata:          props.Metadata || props.Lineage != "",
+		strict:            props.StrictDecoding,
+		metricsNamespace:  props.MetricsNamespace,
+		inlineStates:      props.InlineStates,
+		global:            props.GlobalEndpoint,
+		profile:           props.Profile,
+		compatibility:     props.SchemaCompatibility,
+		logRetention:      props.LogRetention,
+		sizeGuard:         props.SizeGuard,
+		auditing:          props.Audit,
+		naming:            props.Naming,
+		retry:             props.Retry,
+		blueGreenMode:     props.Deployment,
+		engine:            props.Engine,
+		tenancy:           props.Tenancy,
+		backpressure:      props.Backpressure,
+		budgeted:          props.Budget,
+		owner:             props.Owner,
+		registry:          props.Registry,
+		lineage:           props.Lineage,
+	}
+	if builder.inlineStates == 0 {
+		builder.inlineStates = defaultInlineStates
+	}
+	for _, key := range []*string{&builder.idempotencyKey, &builder.correlationKey} {
+		path, err := builder.path(*key)
+		if err != nil {
+			panic(err)
+		}
+		*key = path
+	}
+	if props.RemovalPolicy != "" {
+		awscdk.RemovalPolicies_Of(builder.Construct).Apply(props.RemovalPolicy, nil)
 	}
 	return builder
 }
 
 // StateMachine injects the morphism into the AWS Step Function,
 // it constructs the state machine from the defined computation.
+//
+// The construct hosts a family of pipelines sharing the configuration,
+// each call of StateMachine defines a new state machine. Resources of
+// the first pipeline are defined within the construct, each subsequent
+// one is defined within the scope `Pipeline{n}`.
 func StateMachine[A, B any](ts TypeStep, m duct.Morphism[A, B]) {
 	b := ts.(*typeStep)
+	b.enter()
+
+	version, err := versionOf(b.namer, m)
+	if err != nil {
+		panic(err)
+	}
+	b.version = version
+
+	catalog, err := b.annotate(m)
+	if err != nil {
+		panic(err)
+	}
+	b.deprecations(catalog)
+
+	if b.executionTemplate != "" {
+		name, err := executionNameOf(b.namer, b.executionTemplate, reflect.TypeOf(new(A)).Elem())
+		if err != nil {
+			panic(err)
+		}
+		b.executionName = name
+	}
+
+	if b.engine == EnginePipes {
+		if err := b.streaming(m); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	if err := m.Apply(b); err != nil {
 		panic(err)
 	}
 }
 
+// enter resets the builder state for the new pipeline
+func (ts *typeStep) enter() {
+	ts.scope = ts.Construct
+	if n := len(ts.pipelines); n > 0 {
+		ts.scope = constructs.NewConstruct(ts.Construct, jsii.String(fmt.Sprintf("Pipeline%d", n+1)))
+	}
+
+	ts.bus = nil
+	ts.eventPattern = nil
+	ts.windowed = nil
+	ts.queued = nil
+	ts.audits = nil
+	ts.semaphores = nil
+	ts.mutexes = nil
+	ts.queues = nil
+	ts.args = ""
+	ts.stack = []awsstepfunctions.Chain{nil}
+	ts.names = []string{""}
+	ts.sizes = []int{0}
+	ts.paths = []string{""}
+	ts.protos = protoFiles{}
+	ts.schemas = schemas{}
+	ts.lastf = nil
+	ts.describe = ""
+	ts.steps = map[string]int{}
+	ts.latencies = nil
+	ts.ids = map[string]bool{}
+	ts.uuid = ""
+}
+
+// name of the pipeline, unique within the stack
+func (ts *typeStep) name() string {
+	if ts.scope == ts.Construct {
+		return *ts.Node().Id()
+	}
+	return *ts.Node().Id() + *ts.scope.Node().Id()
+}
+
 func (ts *typeStep) append(f node) {
+	ts.appendChain(f, *f.Node().Id(), 1)
+}
+
+// appendChain appends the sequence of n states, the name identifies it.
+func (ts *typeStep) appendChain(f awsstepfunctions.IChainable, name string, n int) {
 	tsal := len(ts.stack) - 1
 	last := ts.stack[tsal]
 	if last == nil {
@@ -189,7 +596,58 @@ func (ts *typeStep) append(f node) {
 	} else {
 		ts.stack[tsal] = last.Next(f)
 	}
-	ts.names[tsal] = ts.names[tsal] + *f.Node().Id()
+	ts.names[tsal] = ts.names[tsal] + name
+	ts.sizes[tsal] = ts.sizes[tsal] + n
+}
+
+// idOf returns unique id of the built-in step of the kind (e.g. Assert0)
+func (ts *typeStep) idOf(kind string) string {
+	n := ts.steps[kind]
+	ts.steps[kind] = n + 1
+	return fmt.Sprintf("%s%d", kind, n)
+}
+
+// unique returns the id of the step, which is unique within the pipeline.
+// Steps composed more than once (e.g. the function reused by the pipeline or
+// multiple sinks) are suffixed with the number of occurrence (see [Validate]).
+func (ts *typeStep) unique(id string) string {
+	uid := id
+	for n := 2; ts.ids[uid]; n++ {
+		uid = fmt.Sprintf("%s%d", id, n)
+	}
+	ts.ids[uid] = true
+
+	if uid != id {
+		awscdk.Annotations_Of(ts.scope).AddWarning(jsii.String(fmt.Sprintf("typestep step %s is composed more than once, it is renamed to %s", id, uid)))
+	}
+	return uid
+}
+
+// readable makes the output of the last function readable by other states
+// of the state machine, the function emits uncompressed payload and canonical
+// JSON form of protobuf messages. The runtime wrapper is configured once per
+// function, the function is readable at any position once any state reads
+// its output.
+func (ts *typeStep) readable() {
+	if ts.lastf == nil {
+		return
+	}
+
+	ts.plain[ts.lastf] = true
+	if ts.compression {
+		ts.setenv(ts.lastf, runtime.EnvCompression, runtime.CompressionNone)
+	}
+	if ts.encoding == EncodingProtobuf {
+		ts.setenv(ts.lastf, runtime.EnvCodec, runtime.CodecProtoJSON)
+	}
+}
+
+// setenv configures the runtime wrapper of the function, it is only possible
+// for functions deployed by the stack, imported functions are not modified.
+func (ts *typeStep) setenv(f awslambda.IFunction, key, val string) {
+	if fn, ok := f.(awslambda.Function); ok {
+		fn.AddEnvironment(jsii.String(key), jsii.String(val), nil)
+	}
 }
 
 func (ts *typeStep) OnEnterMorphism(depth int, node duct.AstSeq) error {
@@ -201,34 +659,140 @@ func (ts *typeStep) OnLeaveMorphism(depth int, node duct.AstSeq) error {
 		return fmt.Errorf("bad definition of compute pipeline")
 	}
 
-	if ts.bus == nil {
+	if ts.bus == nil && ts.queued == nil {
 		return fmt.Errorf("undefined event source for compute pipeline")
 	}
 
-	states := awsstepfunctions.NewStateMachine(ts.Construct, jsii.String("StateMachine"),
-		&awsstepfunctions.StateMachineProps{
-			DefinitionBody: awsstepfunctions.ChainDefinitionBody_FromChainable(ts.stack[0]),
-		},
-	)
+	var chain awsstepfunctions.IChainable = ts.stack[0]
+	if len(ts.audits) != 0 {
+		chain = ts.replay(chain)
+	}
 
-	awsevents.NewRule(ts.Construct, jsii.String("Rule"),
-		&awsevents.RuleProps{
-			EventBus:     ts.bus,
-			EventPattern: ts.eventPattern,
-		},
-	).AddTarget(
-		awseventstargets.NewSfnStateMachine(
-			states,
-			&awseventstargets.SfnStateMachineProps{},
-		),
-	)
+	props := &awsstepfunctions.StateMachineProps{Logs: ts.logs()}
+	if ts.api != nil {
+		chain = ts.request(chain)
+		props.StateMachineType = awsstepfunctions.StateMachineType_EXPRESS
+	}
+	if ts.queued != nil && ts.queued.fifo() {
+		props.StateMachineType = awsstepfunctions.StateMachineType_EXPRESS
+	}
+	props.DefinitionBody = awsstepfunctions.ChainDefinitionBody_FromChainable(chain)
+
+	states := awsstepfunctions.NewStateMachine(ts.scope, jsii.String("StateMachine"), props)
+	ts.alarms(states)
+	ts.grant(states)
+	if len(ts.semaphores) != 0 || len(ts.mutexes) != 0 {
+		ts.release(states)
+	}
+	if ts.restarts {
+		ts.restart(states)
+	}
+
+	if ts.compatibility {
+		if err := ts.gate(); err != nil {
+			return err
+		}
+	}
+
+	if len(ts.protos) != 0 {
+		schema, err := ts.protos.encode()
+		if err != nil {
+			return err
+		}
+		states.Node().DefaultChild().(awscdk.CfnResource).AddMetadata(jsii.String("typestep:protobuf"), schema)
+	}
+	ts.pipelines = append(ts.pipelines, states)
+	ts.machines = append(ts.machines, states)
+
+	if (ts.backpressure != nil || ts.budgeted != nil) && (ts.api != nil || ts.windowed != nil || ts.queued != nil) {
+		return fmt.Errorf("backpressure requires the pipeline started by the rule")
+	}
+
+	if ts.api != nil {
+		ts.mount(states)
+		return nil
+	}
+
+	if ts.global != nil {
+		if err := ts.endpoint(states); err != nil {
+			return err
+		}
+	}
+
+	var live, mirror awsstepfunctions.IStateMachine = states, nil
+	if ts.blueGreenMode != nil {
+		live, mirror = ts.blueGreen(states)
+	}
+
+	// Note: windowed pipeline is started by batches of events
+	if ts.windowed != nil {
+		return ts.buffer(live)
+	}
+
+	// Note: queued pipeline is started by messages of the queue
+	if ts.queued != nil {
+		return ts.consume(live)
+	}
+
+	target := live
+	if ts.idempotencyKey != "" || ts.executionName != "" {
+		target = ts.intake(live)
+	}
+
+	subscribe := func(id string, pattern *awsevents.EventPattern, enabled bool) awsevents.Rule {
+		rule := awsevents.NewRule(ts.scope, jsii.String(id),
+			&awsevents.RuleProps{
+				EventBus:     ts.bus,
+				EventPattern: pattern,
+				Enabled:      jsii.Bool(enabled),
+			},
+		)
+		rule.AddTarget(
+			awseventstargets.NewSfnStateMachine(
+				target,
+				&awseventstargets.SfnStateMachineProps{},
+			),
+		)
+
+		if mirror != nil {
+			rule.AddTarget(
+				awseventstargets.NewSfnStateMachine(
+					mirror,
+					&awseventstargets.SfnStateMachineProps{},
+				),
+			)
+		}
+		return rule
+	}
+
+	rule := subscribe("Rule", ts.eventPattern, true)
+
+	if ts.budgeted != nil {
+		if err := ts.budget(rule, subscribe); err != nil {
+			return err
+		}
+	}
+
+	if ts.backpressure != nil {
+		return ts.throttle(states, rule)
+	}
 
 	return nil
 }
 
 func (ts *typeStep) OnEnterSeq(depth int, node duct.AstSeq) error {
+	if ts.sizeGuard {
+		if f, ok := node.Seq[0].(*duct.AstMap); ok {
+			if f, ok := f.F.(lambda); ok {
+				ts.guard(*f.f.Node().Id()+"Seq", "[]"+f.input.String())
+			}
+		}
+	}
+
 	ts.stack = append(ts.stack, nil)
 	ts.names = append(ts.names, "")
+	ts.sizes = append(ts.sizes, 0)
+	ts.paths = append(ts.paths, ts.args)
 	ts.args = "$"
 
 	return nil
@@ -242,88 +806,439 @@ func (ts *typeStep) OnLeaveSeq(depth int, node duct.AstSeq) error {
 	ihex := hex.EncodeToString(hash[:])[:8]
 
 	concurency := 1
-	if f, ok := node.Seq[0].(duct.AstMap); ok {
+	scoped := false
+	if f, ok := node.Seq[0].(*duct.AstMap); ok {
 		if f, ok := f.F.(lambda); ok {
-			concurency = f.concurency
+			concurency = ts.concurrencyOf(f.concurency)
+			scoped = f.scoped
 		}
 	}
 
-	foreach := awsstepfunctions.NewMap(ts.Construct, jsii.String("Seq"+ihex),
-		&awsstepfunctions.MapProps{
-			ItemsPath:      jsii.String("$.Payload"), // assuming the first element is function, which is true by defsign
-			MaxConcurrency: jsii.Number(concurency),
-		},
-	)
+	// Note: the iteration yields the element, not the response of the step,
+	//       so that nested computations compose at any depth.
+	if ts.args != "$" {
+		item := awsstepfunctions.NewPass(ts.scope, jsii.String("Item"+ihex),
+			&awsstepfunctions.PassProps{
+				InputPath: jsii.String(ts.args),
+			},
+		)
+		ts.appendChain(item, "", 1)
+	}
 
-	foreach.ItemProcessor(ts.stack[last],
+	// assuming the first element is function, which is true by defsign
+	items, selector := itemsOf(ts.paths[last], scoped)
+	props := &awsstepfunctions.MapProps{
+		ItemsPath:      jsii.String(items),
+		ItemSelector:   selector,
+		MaxConcurrency: jsii.Number(concurency),
+	}
+	if ts.tenancy != nil {
+		props.MaxConcurrency = nil
+		props.MaxConcurrencyPath = jsii.String("$" + varTenantConcurrency)
+	}
+	foreach := awsstepfunctions.NewMap(ts.scope, jsii.String("Seq"+ihex), props)
+
+	var iterator awsstepfunctions.IChainable = ts.stack[last]
+	if ts.oversized(last) {
+		iterator = ts.nest(ihex, ts.stack[last])
+		ts.sizes[last] = 1
+	}
+	foreach.ItemProcessor(iterator,
 		&awsstepfunctions.ProcessorConfig{},
 	)
 
+	size := ts.sizes[last]
 	ts.stack = ts.stack[:last]
 	ts.names = ts.names[:last]
-	ts.append(foreach)
+	ts.sizes = ts.sizes[:last]
+	ts.paths = ts.paths[:last]
+
+	if ts.sizeGuard {
+		foreach.AddCatch(ts.oversize("Seq"+ihex, "sequence"),
+			&awsstepfunctions.CatchProps{
+				Errors: jsii.Strings("States.DataLimitExceeded"),
+			},
+		)
+	}
+
+	// Note: the pipeline completes without output on empty sequence, the guard
+	// is only required for top level computation, which is followed by sinks.
+	if last == 1 {
+		guard := awsstepfunctions.NewChoice(ts.scope, jsii.String("Some"+ihex),
+			&awsstepfunctions.ChoiceProps{},
+		).When(
+			awsstepfunctions.Condition_IsPresent(jsii.String(items+"[0]")),
+			foreach,
+			nil,
+		).Otherwise(
+			awsstepfunctions.NewSucceed(ts.scope, jsii.String("None"+ihex), &awsstepfunctions.SucceedProps{}),
+		)
+		ts.appendChain(
+			awsstepfunctions.Chain_Custom(guard, &[]awsstepfunctions.INextable{foreach}, foreach),
+			*foreach.Node().Id(),
+			size+3,
+		)
+	} else {
+		ts.appendChain(foreach, *foreach.Node().Id(), size+1)
+	}
 	ts.args = "$"
 
 	return nil
 }
 
 func (ts *typeStep) OnEnterMap(depth int, node duct.AstMap) error {
+	if f, ok := lambdaOf(node.F); ok {
+		ts.uuid = ts.unique(*f.f.Node().Id())
+	}
+
+	// Note: states other than typed functions read the payload
+	switch f := node.F.(type) {
+	case lambda:
+		if f.cache != nil {
+			ts.readable()
+		}
+	case describe:
+	default:
+		ts.readable()
+	}
+
 	switch f := node.F.(type) {
 	case lambda:
-		uuid := *f.f.Node().Id()
-		compute := awsstepfunctionstasks.NewLambdaInvoke(
-			ts.Construct,
-			jsii.String("Map"+uuid),
+		ts.shim(f.input)
+		if f.cache != nil {
+			return ts.cached(f)
+		}
+		if f.fallback != nil {
+			return ts.recovered(f)
+		}
+
+		compute := ts.invoke(f,
 			&awsstepfunctionstasks.LambdaInvokeProps{
-				InputPath:      jsii.String(ts.args),
-				LambdaFunction: f.f,
+				InputPath: jsii.String(ts.args),
 			},
 		)
+		ts.append(compute)
+		return nil
 
-		if ts.DeadLetterQueue != nil {
-			dlq := awsstepfunctionstasks.NewSqsSendMessage(ts.Construct, jsii.String("Try"+uuid),
-				&awsstepfunctionstasks.SqsSendMessageProps{
-					Queue:       ts.DeadLetterQueue,
-					MessageBody: awsstepfunctions.TaskInput_FromJsonPathAt(jsii.String("$")),
+	case batch:
+		chunks := awsstepfunctions.NewPass(ts.scope, jsii.String("Batch"+f.id),
+			&awsstepfunctions.PassProps{
+				Parameters: &map[string]any{
+					"Payload.$": fmt.Sprintf("States.ArrayPartition(%s, %d)", ts.args, ts.batchOf(f.size)),
 				},
-			)
-			err := awsstepfunctions.NewFail(ts.Construct, jsii.String("Err"+uuid),
-				&awsstepfunctions.FailProps{},
-			)
+			},
+		)
+		ts.append(chunks)
+		return nil
 
-			compute.AddCatch(
-				dlq.Next(err),
-				&awsstepfunctions.CatchProps{
-					ResultPath: jsii.String("$.error"),
-				},
-			)
-		}
+	case assertion:
+		ts.assert(f)
+		return nil
 
-		ts.append(compute)
+	case intrinsic:
+		ts.intrinsic(f)
+		return nil
+
+	case stamp:
+		ts.shape("Stamp", f.expression(ts.namer, ts.args))
+		return nil
+
+	case joinWith:
+		return ts.joinWith(f)
+
+	case split:
+		return ts.split(f)
+
+	case lookup:
+		ts.lookup(f)
+		return nil
+
+	case inout:
+		ts.inout(f)
+		return nil
+
+	case collapse:
+		ts.shape(f.kind, f.expression(ts.args))
+		return nil
+
+	case sorting:
+		ts.shape("Sort", f.expression(ts.namer, ts.args))
 		return nil
+
+	case take:
+		ts.take(f)
+		return nil
+
+	case sampling:
+		ts.shape("Sample", f.expression(ts.namer, ts.args))
+		return nil
+
+	case pages:
+		ts.pages(f)
+		return nil
+
+	case continueAsNew:
+		return ts.continueAsNew(f)
+
+	case job:
+		return ts.job(f)
+
+	case flagged:
+		ts.flagged(f)
+		return nil
+
+	case quota:
+		ts.quota(f)
+		return nil
+
+	case semaphore:
+		return ts.semaphore(f)
+
+	case mutex:
+		return ts.mutex(f)
+
+	case awaitEvent:
+		ts.await(f)
+		return nil
+
+	case describe:
+		ts.describe = f.comment
+		return nil
+
+	case execute:
+		return ts.execute(f)
+
+	case glueJob:
+		ts.glue(f)
+		return nil
+
 	default:
 		return fmt.Errorf("unkown compute type: %T", f)
 	}
 }
 
+// invoke creates the task for lambda function, it configures the runtime
+// wrapper of the function and error handling of the task.
+func (ts *typeStep) invoke(f lambda, props *awsstepfunctionstasks.LambdaInvokeProps) awsstepfunctionstasks.LambdaInvoke {
+	if ts.encoding != "" && ts.encoding != EncodingJSON && !(ts.encoding == EncodingProtobuf && ts.plain[f.f]) {
+		ts.setenv(f.f, runtime.EnvCodec, string(ts.encoding))
+	}
+	if ts.encoding == EncodingProtobuf {
+		ts.protos.register(f.input)
+		ts.protos.register(f.reply)
+	}
+	if ts.offload != nil {
+		ts.offload.GrantReadWrite(f.f, nil)
+		ts.setenv(f.f, runtime.EnvOffloadBucket, *ts.offload.BucketName())
+	}
+	if ts.compression && !ts.plain[f.f] {
+		ts.setenv(f.f, runtime.EnvCompression, runtime.CompressionGzip)
+	}
+	if ts.strict {
+		ts.setenv(f.f, runtime.EnvStrict, "true")
+	}
+	if ts.lineage != "" {
+		ts.setenv(f.f, runtime.EnvLineage, ts.lineage)
+	}
+	if ts.compatibility {
+		ts.schemas.register(ts.namer, ts.contractOf(f.input), f.input)
+		ts.schemas.register(ts.namer, ts.contractOf(f.reply), f.reply)
+	}
+	ts.metrics(f)
+	ts.chaos(f)
+	ts.provision(f.f)
+	ts.functions = append(ts.functions, f.f)
+
+	uuid := ts.uuid
+	props.LambdaFunction = f.f
+	if f.alias != nil {
+		props.LambdaFunction = f.alias
+	}
+	if uuid != "" {
+		ts.latencies = append(ts.latencies, latency{name: uuid, f: props.LambdaFunction})
+	}
+	if props.Payload == nil {
+		props.Payload = ts.envelope()
+	}
+	if props.Comment == nil {
+		props.Comment = ts.comment()
+	}
+	retry := ts.retryOf(f)
+	if retry != nil {
+		// Note: the policy replaces default retry on service exceptions
+		props.RetryOnServiceExceptions = jsii.Bool(false)
+	}
+	compute := awsstepfunctionstasks.NewLambdaInvoke(ts.scope, jsii.String("Map"+uuid), props)
+	ts.throws(f, compute)
+	if retry != nil {
+		compute.AddRetry(retry)
+	}
+	ts.catches(f, compute, uuid)
+
+	// Note: the failure of recoverable function is not dead-lettered
+	if ts.DeadLetterQueue != nil && f.fallback == nil {
+		dlq := ts.deadLetter("Try" + uuid)
+		err := awsstepfunctions.NewFail(ts.scope, jsii.String("Err"+uuid),
+			&awsstepfunctions.FailProps{},
+		)
+
+		var catch awsstepfunctions.IChainable = dlq.Next(err)
+		ts.sizes[len(ts.sizes)-1] += 2
+		if redact := ts.redact(uuid, f.input); redact != nil {
+			ts.readable()
+			catch = redact.Next(catch)
+			ts.sizes[len(ts.sizes)-1] += 1
+		}
+
+		compute.AddCatch(
+			catch,
+			&awsstepfunctions.CatchProps{
+				ResultPath: jsii.String("$.error"),
+			},
+		)
+	}
+
+	ts.lastf = f.f
+	return compute
+}
+
 func (ts *typeStep) OnLeaveMap(depth int, node duct.AstMap) error {
+	// Note: assertion, quota, semaphore, mutex, glue job and description pass the payload as-is
+	switch node.F.(type) {
+	case assertion, quota, semaphore, mutex, glueJob, describe:
+		return nil
+	}
+
 	// Note: Lambda's response of step function is always packed
 	ts.args = "$.Payload"
+
+	// Note: replies of composed functions are read by their states
+	switch node.F.(type) {
+	case joinWith, inout, pages, flagged, continueAsNew, job:
+		ts.readable()
+	}
+
+	if _, ok := node.F.(lambda); ok && ts.auditing != nil {
+		ts.readable()
+		ts.audit(ts.uuid)
+	}
 	return nil
 }
 
 func (ts *typeStep) OnEnterFrom(depth int, node duct.AstFrom) error {
+	if _, ok := node.Source.(source); !ok && ts.tenancy != nil {
+		return fmt.Errorf("tenancy is not supported by input type: %T", node.Source)
+	}
+
 	switch f := node.Source.(type) {
 	case source:
 		ts.bus = f.bus
 		ts.eventPattern = &awsevents.EventPattern{
-			DetailType: jsii.Strings(node.Type),
+			DetailType: jsii.Strings(ts.detailTypeOf(f.kind)),
 		}
 		if len(f.cat) != 0 {
 			ts.eventPattern.DetailType = jsii.Strings(f.cat...)
 		}
+		if len(f.where) != 0 {
+			detail := detailOf(ts.namer, f.where)
+			ts.eventPattern.Detail = &detail
+		}
 		ts.args = "$.detail"
+		if f.batched {
+			ts.args = "$.detail.items"
+		}
+
+		if ts.tenancy != nil {
+			if err := ts.tenant(f.kind); err != nil {
+				return err
+			}
+		}
+
+		if ts.correlation {
+			ts.correlate()
+		}
+		if ts.tracing {
+			ts.trace()
+		}
+		if ts.sizeGuard {
+			ts.guard("Source", node.Type)
+		}
+		if ts.auditing != nil {
+			ts.audit("Source")
+		}
 		return nil
+	case union:
+		ts.bus = f.bus
+		ts.eventPattern = &awsevents.EventPattern{
+			DetailType: &[]*string{},
+		}
+		for _, kind := range f.kinds {
+			*ts.eventPattern.DetailType = append(*ts.eventPattern.DetailType, jsii.String(ts.detailTypeOf(kind)))
+		}
+		ts.args = "$.detail"
+
+		if ts.correlation {
+			ts.correlate()
+		}
+		if ts.tracing {
+			ts.trace()
+		}
+		ts.dispatch(f)
+		if ts.sizeGuard {
+			ts.guard("Source", node.Type)
+		}
+		if ts.auditing != nil {
+			ts.audit("Source")
+		}
+		return nil
+	case windowed:
+		ts.bus = f.bus
+		ts.eventPattern = &awsevents.EventPattern{
+			DetailType: jsii.Strings(ts.detailTypeOf(f.kind)),
+		}
+		if len(f.cat) != 0 {
+			ts.eventPattern.DetailType = jsii.Strings(f.cat...)
+		}
+		ts.windowed = &f
+		ts.args = "$.detail"
+
+		ts.unpack()
+		if ts.correlation {
+			ts.correlate()
+		}
+		if ts.tracing {
+			ts.trace()
+		}
+		if ts.sizeGuard {
+			ts.guard("Source", node.Type)
+		}
+		if ts.auditing != nil {
+			ts.audit("Source")
+		}
+		return nil
+
+	case queued:
+		if ts.idempotencyKey != "" || ts.executionName != "" {
+			return fmt.Errorf("execution name is not supported by input type: %T", f)
+		}
+		ts.queued = &f
+		ts.args = "$.detail"
+
+		ts.dequeue()
+		if ts.correlation {
+			ts.correlate()
+		}
+		if ts.tracing {
+			ts.trace()
+		}
+		if ts.sizeGuard {
+			ts.guard("Source", node.Type)
+		}
+		if ts.auditing != nil {
+			ts.audit("Source")
+		}
+		return nil
+
 	default:
 		return fmt.Errorf("unkown input type: %T", f)
 	}
@@ -334,43 +1249,53 @@ func (ts *typeStep) OnLeaveFrom(depth int, node duct.AstFrom) error {
 }
 
 func (ts *typeStep) OnEnterYield(depth int, node duct.AstYield) error {
+	// sinks consume the uncompressed reply of the last function, EventBridge
+	// requires JSON object as detail of the event
+	ts.readable()
+
 	switch f := node.Target.(type) {
-	case awssqs.IQueue:
-		sink := awsstepfunctionstasks.NewSqsSendMessage(ts.Construct, jsii.String("Sink"),
-			&awsstepfunctionstasks.SqsSendMessageProps{
-				Queue:       f,
-				MessageBody: awsstepfunctions.TaskInput_FromJsonPathAt(jsii.String(ts.args)),
-			},
-		)
+	case queue:
+		sink, err := ts.send(ts.unique("Sink"), f, ts.args)
+		if err != nil {
+			return err
+		}
 		ts.append(sink)
 		return nil
 
+	case function:
+		ts.uuid = ts.unique(*f.f.Node().Id())
+		ts.append(ts.async(f))
+		return nil
+
 	case eventbus:
-		kind := node.Type
+		kind := ts.detailTypeOf(f.kind)
 		if len(f.cat) != 0 {
 			kind = f.cat[0]
 		}
 
-		sink := awsstepfunctionstasks.NewEventBridgePutEvents(ts.Construct, jsii.String("Sink"),
-			&awsstepfunctionstasks.EventBridgePutEventsProps{
-				Entries: &[]*awsstepfunctionstasks.EventBridgePutEventsEntry{
-					{
-						Detail:     awsstepfunctions.TaskInput_FromJsonPathAt(jsii.String(ts.args)),
-						DetailType: jsii.String(kind),
-						Source:     jsii.String(f.source),
-						EventBus:   f.bus,
-					},
-				},
-			},
-		)
-		ts.append(sink)
+		if f.dedup != nil {
+			return ts.publishOnce(f, kind)
+		}
+
+		ts.publish(f, kind)
 		return nil
 
+	case timestream:
+		return ts.timestream(f)
+
+	case redshift:
+		return ts.insert(f)
+
+	case outbox:
+		return ts.outbox(f)
+
 	default:
 		return fmt.Errorf("unkown reply type: %T", f)
 	}
 }
 
 func (ts *typeStep) OnLeaveYield(depth int, node duct.AstYield) error {
+	// Note: sinks yield no result
+	ts.args = "$"
 	return nil
 }
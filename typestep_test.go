@@ -9,14 +9,21 @@
 package typestep_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
 	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/scud"
 	"github.com/fogfish/typestep"
+	"github.com/fogfish/typestep/internal/test"
+	"github.com/fogfish/typestep/runtime"
 )
 
 func TestTypeStep(t *testing.T) {
@@ -65,3 +72,608 @@ func TestTypeStep(t *testing.T) {
 		template.ResourceCountIs(key, val)
 	}
 }
+
+func TestTypeStepPayloadOffload(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+	bucket := awss3.Bucket_FromBucketName(stack, jsii.String("Bucket"), jsii.String("my-bucket"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(f, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			PayloadOffload: bucket,
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"Environment": map[string]any{
+				"Variables": map[string]any{
+					runtime.EnvOffloadBucket: "my-bucket",
+				},
+			},
+		},
+	)
+}
+
+func TestTypeStepPayloadCompression(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+				FunctionProps: &awslambda.FunctionProps{
+					FunctionName: jsii.String("f"),
+				},
+			},
+		),
+	)
+
+	g := typestep.NewFunctionTyped(stack, jsii.String("G"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+				FunctionProps: &awslambda.FunctionProps{
+					FunctionName: jsii.String("g"),
+				},
+			},
+		),
+	)
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(f, p1)
+	p3 := typestep.Join(g, p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			PayloadCompression: true,
+		},
+	)
+	typestep.StateMachine(ts, p4)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"FunctionName": "f",
+			"Environment": map[string]any{
+				"Variables": map[string]any{
+					runtime.EnvCompression: runtime.CompressionGzip,
+				},
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"FunctionName": "g",
+			"Environment": map[string]any{
+				"Variables": map[string]any{
+					runtime.EnvCompression: runtime.CompressionNone,
+				},
+			},
+		},
+	)
+}
+
+func TestTypeStepPayloadCompressionReadable(t *testing.T) {
+	inline := func(stack awscdk.Stack, id string) awslambda.Function {
+		return awslambda.NewFunction(stack, jsii.String(id),
+			&awslambda.FunctionProps{
+				FunctionName: jsii.String(strings.ToLower(id)),
+				Runtime:      awslambda.Runtime_NODEJS_LATEST(),
+				Handler:      jsii.String("index.handler"),
+				Code:         awslambda.Code_FromInline(jsii.String("none")),
+			},
+		)
+	}
+
+	compression := func(t *testing.T, template assertions.Template, name, codec string) {
+		t.Helper()
+		template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+			map[string]any{
+				"FunctionName": name,
+				"Environment": map[string]any{
+					"Variables": map[string]any{
+						runtime.EnvCompression: codec,
+					},
+				},
+			},
+		)
+	}
+
+	t.Run("Assert", func(t *testing.T) {
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+		event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+		f := &typestep.Function[string, Order]{Function: inline(stack, "F")}
+		g := &typestep.Function[Order, string]{Function: inline(stack, "G")}
+
+		p1 := typestep.From[string](event)
+		p2 := typestep.Join(f, p1)
+		p3 := typestep.Assert(typestep.Prefix(func(o *Order) *string { return &o.Customer }, "c-"), "unknown customer", p2)
+		p4 := typestep.Join(g, p3)
+		p5 := typestep.ToQueue(queue, p4)
+
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+			&typestep.TypeStepProps{
+				PayloadCompression: true,
+			},
+		)
+		typestep.StateMachine(ts, p5)
+
+		template := assertions.Template_FromStack(stack, nil)
+		compression(t, template, "f", runtime.CompressionNone)
+		compression(t, template, "g", runtime.CompressionNone)
+	})
+
+	t.Run("Sort", func(t *testing.T) {
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+		event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+		f := &typestep.Function[string, []Order]{Function: inline(stack, "F")}
+		g := &typestep.Function[[]Order, string]{Function: inline(stack, "G")}
+
+		p1 := typestep.From[string](event)
+		p2 := typestep.Join(f, p1)
+		p3 := typestep.Sort(func(o *Order) *int { return &o.Seq }, typestep.Descending, p2)
+		p4 := typestep.Join(g, p3)
+		p5 := typestep.ToQueue(queue, p4)
+
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+			&typestep.TypeStepProps{
+				PayloadCompression: true,
+			},
+		)
+		typestep.StateMachine(ts, p5)
+
+		template := assertions.Template_FromStack(stack, nil)
+		compression(t, template, "f", runtime.CompressionNone)
+		compression(t, template, "g", runtime.CompressionNone)
+	})
+
+	t.Run("Reused", func(t *testing.T) {
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+		event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+		f := &typestep.Function[Order, Order]{Function: inline(stack, "F")}
+		g := &typestep.Function[Order, Order]{Function: inline(stack, "G")}
+
+		p1 := typestep.From[Order](event)
+		p2 := typestep.Join(f, p1)
+		p3 := typestep.Join(g, p2)
+		p4 := typestep.Join(f, p3)
+		p5 := typestep.Assert(typestep.Prefix(func(o *Order) *string { return &o.Customer }, "c-"), "unknown customer", p4)
+		p6 := typestep.Join(g, p5)
+		p7 := typestep.ToQueue(queue, p6)
+
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+			&typestep.TypeStepProps{
+				PayloadCompression: true,
+			},
+		)
+		typestep.StateMachine(ts, p7)
+
+		template := assertions.Template_FromStack(stack, nil)
+		compression(t, template, "f", runtime.CompressionNone)
+		compression(t, template, "g", runtime.CompressionNone)
+	})
+}
+
+func TestTypeStepStrictDecoding(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(f, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			StrictDecoding: true,
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"Environment": map[string]any{
+				"Variables": map[string]any{
+					runtime.EnvStrict: "true",
+				},
+			},
+		},
+	)
+}
+
+func TestTypeStepLineage(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(f, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			Lineage: "orders",
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"Environment": map[string]any{
+				"Variables": map[string]any{
+					runtime.EnvLineage: "orders",
+				},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"execution.$":"$$.Execution.Id"`,
+		`"state.$":"$$.State.Name"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestTypeStepLiftP(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.LiftP(7, b, p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p4)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	if !strings.Contains(asl, `"MaxConcurrency":7`) {
+		t.Errorf("state machine definition does not contain concurrency of LiftP")
+	}
+}
+
+func TestTypeStepLiftB(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[[]string, []string](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.LiftB(5, 10, b, p2)
+	p4 := typestep.ToQueue(queue, p3)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p4)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"Payload.$":"States.ArrayPartition($.Payload, 10)"`,
+		`"MaxConcurrency":5`,
+		`"Choices":[{"Variable":"$.Payload[0]","IsPresent":true,"Next":"Seq3008196f"}],"Default":"None3008196f"`,
+		`"None3008196f":{"Type":"Succeed"}`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestTypeStepLiftNested(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, []string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	b := typestep.Function_FromFunctionArn[string, []int](stack, jsii.String("B"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	c := typestep.Function_FromFunctionArn[int, string](stack, jsii.String("C"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	d := typestep.Function_FromFunctionArn[[]string, string](stack, jsii.String("D"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.Lift(b, p2)
+	p4 := typestep.Lift(c, p3)
+	p5 := typestep.Join(d, typestep.Unit(p4))
+	p6 := typestep.ToQueue(queue, typestep.Unit(p5))
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p6)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"MapB":{"Next":"Seqeb068c09"`,
+		`"Seqeb068c09":{"Type":"Map","Next":"MapD","ItemsPath":"$.Payload"`,
+		`"MapC":{"Next":"Itemeb068c09"`,
+		`"Itemeb068c09":{"Type":"Pass","InputPath":"$.Payload","End":true}`,
+		`"MapD":{"Next":"Item`,
+		`"FunctionName":"arn:aws:lambda:eu-west-1:000000000000:function:my-function","Payload.$":"$"}},"Item`,
+		`"MessageBody.$":"States.JsonToString($)"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
+
+func TestTypeStepWithMetric(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	f := typestep.NewFunctionTyped(stack, jsii.String("F"),
+		typestep.NewFunctionTypedProps(test.Main,
+			&scud.FunctionGoProps{
+				SourceCodeModule: "github.com/fogfish/typestep",
+			},
+		),
+	)
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(typestep.WithMetric(f, "Picked", "$"), p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			MetricsNamespace: "test",
+		},
+	)
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Lambda::Function"),
+		map[string]any{
+			"Environment": map[string]any{
+				"Variables": map[string]any{
+					runtime.EnvMetrics:          `[{"name":"Picked","path":"$"}]`,
+					runtime.EnvMetricsNamespace: "test",
+					runtime.EnvPipeline:         "Pipe",
+				},
+			},
+		},
+	)
+}
+
+func TestTypeStepMultiplePipelines(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: queue,
+		},
+	)
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[string](event))))
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[string](event, "Other"))))
+
+	// WHEN
+	require := map[*string]*float64{
+		jsii.String("AWS::Events::Rule"):                jsii.Number(2),
+		jsii.String("AWS::StepFunctions::StateMachine"): jsii.Number(2),
+	}
+
+	template := assertions.Template_FromStack(stack, nil)
+	for key, val := range require {
+		template.ResourceCountIs(key, val)
+	}
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{"detail-type": []string{"Other"}},
+		},
+	)
+}
+
+// definitionOf returns definitions (Amazon States Language) of state machines
+// defined by the template, tokens of definition are omitted.
+func TestTypeStepRemovalPolicy(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			RemovalPolicy: awscdk.RemovalPolicy_RETAIN,
+			LogRetention:  awslogs.RetentionDays_ONE_YEAR,
+		},
+	)
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[string](event))))
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	for _, kind := range []string{"AWS::StepFunctions::StateMachine", "AWS::Logs::LogGroup", "AWS::Events::Rule"} {
+		template.HasResource(jsii.String(kind),
+			map[string]any{
+				"DeletionPolicy":      "Retain",
+				"UpdateReplacePolicy": "Retain",
+			},
+		)
+	}
+	template.HasResourceProperties(jsii.String("AWS::Logs::LogGroup"),
+		map[string]any{
+			"RetentionInDays": 365,
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::StepFunctions::StateMachine"),
+		map[string]any{
+			"LoggingConfiguration": map[string]any{"Level": "ERROR"},
+		},
+	)
+}
+
+func definitionOf(template assertions.Template) string {
+	asl := ""
+	sfn := template.FindResources(jsii.String("AWS::StepFunctions::StateMachine"), nil)
+	for _, res := range *sfn {
+		props := (*res)["Properties"].(map[string]any)
+		switch def := props["DefinitionString"].(type) {
+		case string:
+			asl += def + "\n"
+		case map[string]any:
+			for _, x := range def["Fn::Join"].([]any)[1].([]any) {
+				if s, ok := x.(string); ok {
+					asl += s
+				}
+			}
+			asl += "\n"
+		}
+	}
+	return asl
+}
+
+func TestFromBatch(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.FromBatch[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{"detail-type": []string{"[]string"}},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Variable":"$.detail.items[0]","IsPresent":true`,
+		`"ItemsPath":"$.detail.items"`,
+		`"MapA":{"Next":"Sink","Retry":`,
+		`"InputPath":"$"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}
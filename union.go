@@ -0,0 +1,81 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// Or is the tagged union of events A and B consumed by the pipeline (see
+// [FromOr]), exactly one of variants is defined.
+type Or[A, B any] struct {
+	A *A `json:"a,omitempty"`
+	B *B `json:"b,omitempty"`
+}
+
+// Creates new morphism 𝑚, binding it with EventBridge for reading events of
+// categories `A` and `B`, which payloads differ. The input of pipeline is
+// the tagged union of events, the variant is chosen by detail-type.
+func FromOr[A, B any](in awsevents.IEventBus) duct.Morphism[Or[A, B], Or[A, B]] {
+	return duct.From(duct.L1[Or[A, B]](union{
+		bus:   in,
+		kinds: []reflect.Type{reflect.TypeOf(new(A)).Elem(), reflect.TypeOf(new(B)).Elem()},
+	}))
+}
+
+type union struct {
+	bus   awsevents.IEventBus
+	kinds []reflect.Type
+}
+
+// variants of the union
+var unionTags = []string{"a", "b"}
+
+// dispatch packs the detail of event into variant of the union, it chooses
+// the variant by detail-type.
+func (ts *typeStep) dispatch(f union) {
+	choice := awsstepfunctions.NewChoice(ts.scope, jsii.String("Or"),
+		&awsstepfunctions.ChoiceProps{},
+	)
+
+	variants := []awsstepfunctions.INextable{}
+	for i, kind := range f.kinds {
+		tag := unionTags[i]
+		variant := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("Or"+kind.Name()),
+			&awsstepfunctions.PassJsonataProps{
+				Outputs: "{% $merge([$states.input, {'detail': {'" + tag + "': $states.input.detail}}]) %}",
+			},
+		)
+		choice.When(
+			awsstepfunctions.Condition_StringEquals(jsii.String("$.detail-type"), jsii.String(ts.detailTypeOf(kind))),
+			variant,
+			nil,
+		)
+		variants = append(variants, variant)
+	}
+
+	choice.Otherwise(
+		awsstepfunctions.NewFail(ts.scope, jsii.String("OrErr"),
+			&awsstepfunctions.FailProps{
+				Error: jsii.String("typestep.UnknownVariant"),
+			},
+		),
+	)
+
+	ts.appendChain(
+		awsstepfunctions.Chain_Custom(choice, &variants, choice),
+		*choice.Node().Id(),
+		len(variants)+2,
+	)
+}
@@ -0,0 +1,47 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fogfish/golem/duct"
+)
+
+// UnitConcat is equivalent to [Unit] but concatenates results of the nested
+// context, where each element produced the sequence []B. It collapses
+// the nested morphism into 𝑚: A ⟼ []B instead of 𝑚: A ⟼ [][]B.
+func UnitConcat[A, B any](m duct.Morphism[A, []B]) duct.Morphism[A, []B] {
+	return duct.Join(duct.L2[[][]B, []B](collapse{kind: "Concat"}), duct.Unit(m))
+}
+
+// UnitFirst is equivalent to [Unit] but retains first n results of the nested
+// context, the order of results follows the order of the sequence.
+func UnitFirst[A, B any](n int, m duct.Morphism[A, B]) duct.Morphism[A, []B] {
+	return duct.Join(duct.L2[[]B, []B](collapse{kind: "First", n: n}), duct.Unit(m))
+}
+
+// collapse strategy of nested context
+type collapse struct {
+	kind string
+	n    int
+}
+
+// expression of the strategy as JSONata over the sequence at path
+func (f collapse) expression(path string) string {
+	seq := "$states.input" + strings.TrimPrefix(path, "$")
+
+	switch f.kind {
+	case "First":
+		return fmt.Sprintf("{%% $reduce(%s, function($acc, $x) { $count($acc) < %d ? $append($acc, [$x]) : $acc }, []) %%}", seq, f.n)
+	default:
+		return fmt.Sprintf("{%% $reduce(%s, $append, []) %%}", seq)
+	}
+}
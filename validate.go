@@ -0,0 +1,91 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fogfish/golem/duct"
+)
+
+// Validate checks the pipeline 𝑚: A ⟼ B for steps, which are composed more
+// than once (e.g. the function reused by the pipeline or multiple sinks).
+// The builder makes ids of such steps unique, Validate reports conflicting
+// compositions so that the change review catches them before the synth.
+//
+//	if err := typestep.Validate(m); err != nil { /* ... */ }
+func Validate[A, B any](m duct.Morphism[A, B]) error {
+	v := &validator{ids: map[string][]string{}}
+	if err := m.Apply(v); err != nil {
+		return err
+	}
+	return v.err()
+}
+
+type validator struct {
+	duct.AstVisitor
+	ids   map[string][]string
+	order []string
+	n     int
+}
+
+// step records the composition of the step with id
+func (v *validator) step(id, composition string) {
+	v.n++
+	if _, has := v.ids[id]; !has {
+		v.order = append(v.order, id)
+	}
+	v.ids[id] = append(v.ids[id], fmt.Sprintf("#%d %s", v.n, composition))
+}
+
+func (v *validator) OnEnterMap(depth int, node duct.AstMap) error {
+	if f, ok := node.F.(joinWith); ok {
+		if err := f.side.Apply(v); err != nil {
+			return err
+		}
+	}
+
+	if f, ok := node.F.(split); ok {
+		for _, m := range []interface{ Apply(duct.Visitor) error }{f.left, f.right} {
+			if err := m.Apply(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	if f, ok := lambdaOf(node.F); ok {
+		v.step(*f.f.Node().Id(), fmt.Sprintf("%s(%s ⟼ %s)", nameOf(node.F), node.TypeA, node.TypeB))
+		return nil
+	}
+
+	v.n++
+	return nil
+}
+
+func (v *validator) OnEnterYield(depth int, node duct.AstYield) error {
+	if f, ok := node.Target.(function); ok {
+		v.step(*f.f.Node().Id(), fmt.Sprintf("function(%s)", node.Type))
+		return nil
+	}
+
+	v.step("Sink", fmt.Sprintf("%s(%s)", nameOf(node.Target), node.Type))
+	return nil
+}
+
+func (v *validator) err() error {
+	errs := []error{}
+	for _, id := range v.order {
+		if seq := v.ids[id]; len(seq) > 1 {
+			errs = append(errs, fmt.Errorf("step %s is composed %d times by %s", id, len(seq), strings.Join(seq, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
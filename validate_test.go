@@ -0,0 +1,96 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestValidate(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.Join(a, p2)
+	p4 := typestep.ToQueue(queue, p3)
+	p5 := typestep.ToQueue(queue, p4)
+
+	// WHEN
+	err := typestep.Validate(p5)
+
+	// THEN
+	if err == nil {
+		t.Fatal("duplicate steps are not detected")
+	}
+	for _, expect := range []string{
+		"step A is composed 2 times by #1 lambda(string ⟼ string), #2 lambda(string ⟼ string)",
+		"step Sink is composed 2 times by #3 queue(string), #4 queue(Void)",
+	} {
+		if !strings.Contains(err.Error(), expect) {
+			t.Errorf("error %v does not contain %s", err, expect)
+		}
+	}
+
+	if err := typestep.Validate(typestep.ToQueue(queue, typestep.Join(a, typestep.From[string](event)))); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDuplicateSteps(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.From[string](event)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.Join(a, p2)
+	p4 := typestep.ToQueue(queue, p3)
+	p5 := typestep.ToQueue(queue, p4)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p5)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []string{
+		`"MapA":{"Next":"MapA2"`,
+		`"MapA2":{"Next":"Sink"`,
+		`"Sink":{"Next":"Sink2"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine does not contain %s", expect)
+		}
+	}
+
+	annotations := assertions.Annotations_FromStack(stack)
+	annotations.HasWarning(jsii.String("*"), assertions.Match_StringLikeRegexp(jsii.String("typestep step A is composed more than once, it is renamed to A2")))
+}
@@ -0,0 +1,288 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"reflect"
+	goruntime "runtime"
+	"sort"
+	"unsafe"
+
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/fogfish/golem/duct"
+	"github.com/fogfish/typestep/runtime"
+)
+
+const (
+	// VersionAttribute is the attribute of SQS messages (sinks and DLQ),
+	// which contains version of the pipeline that produced the message.
+	VersionAttribute = "typestep-version"
+
+	// VersionField is the field of EventBridge event's detail, which contains
+	// version of the pipeline that produced the event.
+	VersionField = "typestep:version"
+)
+
+// versionOf the pipeline is the content hash of its definition, including
+// the structure of states, configuration of steps and schemas of types. Only
+// the content stable across builds is hashed: paths of constructs, names of
+// types and functions. The version is derived before states are rendered,
+// they are stamped with it.
+func versionOf(n runtime.FieldNamer, m interface{ Apply(duct.Visitor) error }) (string, error) {
+	v := &versioner{hash: sha256.New(), namer: n}
+	if err := m.Apply(v); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(v.hash.Sum(nil))[:12], nil
+}
+
+type versioner struct {
+	duct.AstVisitor
+	hash  hash.Hash
+	namer runtime.FieldNamer
+}
+
+func (v *versioner) OnEnterSeq(depth int, node duct.AstSeq) error {
+	v.hash.Write([]byte("["))
+	return nil
+}
+
+func (v *versioner) OnLeaveSeq(depth int, node duct.AstSeq) error {
+	v.hash.Write([]byte("]"))
+	return nil
+}
+
+func (v *versioner) OnEnterFrom(depth int, node duct.AstFrom) error {
+	fmt.Fprintf(v.hash, "from:%s:", node.Type)
+	if err := v.fingerprint(reflect.ValueOf(node.Source), map[uintptr]bool{}); err != nil {
+		return err
+	}
+	v.hash.Write([]byte(";"))
+	return nil
+}
+
+func (v *versioner) OnEnterMap(depth int, node duct.AstMap) error {
+	switch f := node.F.(type) {
+	case lambda:
+		return v.lambda(f)
+	case joinWith:
+		v.hash.Write([]byte("with("))
+		if err := f.side.Apply(v); err != nil {
+			return err
+		}
+		v.hash.Write([]byte(")"))
+		return v.lambda(f.lambda)
+	case split:
+		v.hash.Write([]byte("split("))
+		if err := f.left.Apply(v); err != nil {
+			return err
+		}
+		v.hash.Write([]byte(","))
+		if err := f.right.Apply(v); err != nil {
+			return err
+		}
+		v.hash.Write([]byte(")"))
+	case inout:
+		fmt.Fprint(v.hash, "inout:")
+		return v.lambda(f.lambda)
+	case pages:
+		fmt.Fprint(v.hash, "pages:")
+		return v.lambda(f.lambda)
+	case flagged:
+		fmt.Fprint(v.hash, "flag:")
+		return v.lambda(f.lambda)
+	case continueAsNew:
+		fmt.Fprint(v.hash, "continue:")
+		return v.lambda(f.lambda)
+	case job:
+		fmt.Fprintf(v.hash, "job:%s:", f.interval)
+		if err := v.lambda(f.start); err != nil {
+			return err
+		}
+		return v.lambda(f.check)
+	case quota:
+		fmt.Fprintf(v.hash, "quota:%s:%d:%t;", f.name, f.limit, f.pause)
+	case semaphore:
+		fmt.Fprintf(v.hash, "semaphore:%s:%d;", f.name, f.limit)
+	case mutex:
+		fmt.Fprintf(v.hash, "mutex:%v;", f.path.names(v.namer))
+	case execute:
+		fmt.Fprintf(v.hash, "execute:%s:%s:%s:%d;", *f.machine.Node().Path(), f.input, f.reply, f.invocation)
+	case describe:
+		// Note: descriptions do not change the version
+	case awaitEvent:
+		fmt.Fprintf(v.hash, "await:%s:%v:%v:%s;", f.kind, f.b.names(v.namer), f.c.names(v.namer), f.timeout)
+	default:
+		fmt.Fprint(v.hash, "map:")
+		if err := v.fingerprint(reflect.ValueOf(f), map[uintptr]bool{}); err != nil {
+			return err
+		}
+		v.hash.Write([]byte(";"))
+	}
+	return nil
+}
+
+func (v *versioner) lambda(f lambda) error {
+	fmt.Fprint(v.hash, "map:")
+	if err := v.fingerprint(reflect.ValueOf(f), map[uintptr]bool{}); err != nil {
+		return err
+	}
+	v.hash.Write([]byte(";"))
+	return nil
+}
+
+func (v *versioner) OnEnterYield(depth int, node duct.AstYield) error {
+	fmt.Fprintf(v.hash, "yield:%s:", node.Type)
+	if err := v.fingerprint(reflect.ValueOf(node.Target), map[uintptr]bool{}); err != nil {
+		return err
+	}
+	v.hash.Write([]byte(";"))
+	return nil
+}
+
+var (
+	typeConstruct = reflect.TypeOf((*constructs.IConstruct)(nil)).Elem()
+	typeType      = reflect.TypeOf((*reflect.Type)(nil)).Elem()
+	typeStringer  = reflect.TypeOf((*interface{ ToString() *string })(nil)).Elem()
+)
+
+// fingerprint writes the content of the value stable across builds. Constructs
+// are written as their paths, types as their names and schemas, functions as
+// their names. Addresses of values are never written.
+func (v *versioner) fingerprint(x reflect.Value, visited map[uintptr]bool) error {
+	if !x.IsValid() {
+		v.hash.Write([]byte("nil"))
+		return nil
+	}
+
+	if x.Kind() == reflect.Interface || x.Kind() == reflect.Ptr {
+		if x.IsNil() {
+			v.hash.Write([]byte("nil"))
+			return nil
+		}
+	}
+
+	if x.CanInterface() {
+		switch {
+		case x.Type().Implements(typeConstruct):
+			fmt.Fprintf(v.hash, "%s", *x.Interface().(constructs.IConstruct).Node().Path())
+			return nil
+		case x.Type().Implements(typeType):
+			t := x.Interface().(reflect.Type)
+			schema, err := json.Marshal(schemaOf(v.namer, t, map[reflect.Type]bool{}))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(v.hash, "%s:%s", t, schema)
+			return nil
+		case x.Kind() != reflect.Struct && x.Type().Implements(typeStringer):
+			if s := x.Interface().(interface{ ToString() *string }).ToString(); s != nil {
+				fmt.Fprintf(v.hash, "%s", *s)
+			}
+			return nil
+		}
+	}
+
+	switch x.Kind() {
+	case reflect.Interface:
+		return v.fingerprint(x.Elem(), visited)
+
+	case reflect.Ptr:
+		if visited[x.Pointer()] {
+			v.hash.Write([]byte("cycle"))
+			return nil
+		}
+		visited[x.Pointer()] = true
+		defer delete(visited, x.Pointer())
+		return v.fingerprint(x.Elem(), visited)
+
+	case reflect.Struct:
+		// Note: unexported fields are accessed through the addressable copy
+		y := reflect.New(x.Type()).Elem()
+		y.Set(x)
+
+		fmt.Fprintf(v.hash, "%s{", x.Type())
+		for i := 0; i < y.NumField(); i++ {
+			fv := y.Field(i)
+			fv = reflect.NewAt(fv.Type(), unsafe.Pointer(fv.UnsafeAddr())).Elem()
+			fmt.Fprintf(v.hash, "%s:", x.Type().Field(i).Name)
+			if err := v.fingerprint(fv, visited); err != nil {
+				return err
+			}
+			v.hash.Write([]byte(","))
+		}
+		v.hash.Write([]byte("}"))
+		return nil
+
+	case reflect.Map:
+		keys := make([]string, 0, x.Len())
+		index := map[string]reflect.Value{}
+		for _, key := range x.MapKeys() {
+			k := &versioner{hash: sha256.New(), namer: v.namer}
+			if err := k.fingerprint(key, visited); err != nil {
+				return err
+			}
+			digest := hex.EncodeToString(k.hash.Sum(nil))
+			keys = append(keys, digest)
+			index[digest] = key
+		}
+		sort.Strings(keys)
+
+		v.hash.Write([]byte("{"))
+		for _, key := range keys {
+			fmt.Fprintf(v.hash, "%s:", key)
+			if err := v.fingerprint(x.MapIndex(index[key]), visited); err != nil {
+				return err
+			}
+			v.hash.Write([]byte(","))
+		}
+		v.hash.Write([]byte("}"))
+		return nil
+
+	case reflect.Slice, reflect.Array:
+		v.hash.Write([]byte("["))
+		for i := 0; i < x.Len(); i++ {
+			if err := v.fingerprint(x.Index(i), visited); err != nil {
+				return err
+			}
+			v.hash.Write([]byte(","))
+		}
+		v.hash.Write([]byte("]"))
+		return nil
+
+	case reflect.Func:
+		if x.IsNil() {
+			v.hash.Write([]byte("nil"))
+			return nil
+		}
+		if fn := goruntime.FuncForPC(x.Pointer()); fn != nil {
+			fmt.Fprintf(v.hash, "%s", fn.Name())
+		}
+		return nil
+
+	case reflect.Chan, reflect.UnsafePointer:
+		fmt.Fprintf(v.hash, "%s", x.Type())
+		return nil
+
+	default:
+		fmt.Fprintf(v.hash, "%v", x)
+		return nil
+	}
+}
+
+// stamped payload of EventBridge event, the version is merged into detail
+// using JSONata expression.
+func (ts *typeStep) stamped(value string) string {
+	return fmt.Sprintf(`$merge([%s, {"%s": "%s"}])`, value, VersionField, ts.version)
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestVersion(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[string, Contact](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"),
+		&typestep.TypeStepProps{
+			DeadLetterQueue: queue,
+		},
+	)
+	typestep.StateMachine(ts, typestep.ToQueue(queue, typestep.Join(a, typestep.From[string](event))))
+	typestep.StateMachine(ts, typestep.ToEventBus("test", event, typestep.Join(a, typestep.From[string](event, "Other"))))
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	asl := definitionOf(template)
+
+	for _, expect := range []*regexp.Regexp{
+		regexp.MustCompile(`:states:::aws-sdk:sqs:sendMessage"`),
+		regexp.MustCompile(`"MessageAttributes":\{"typestep-version":\{"DataType":"String","StringValue":"[0-9a-f]{12}"\}\}`),
+		regexp.MustCompile(`"MessageBody.\$":"States.JsonToString\(\$.Payload\)"`),
+		regexp.MustCompile(`"MessageBody.\$":"States.JsonToString\(\$\)"`),
+		regexp.MustCompile(`\\"Detail\\": \$string\(\$merge\(\[\$states.input.Payload, \{\\"typestep:version\\": \\"[0-9a-f]{12}\\"\}\]\)\)`),
+	} {
+		if !expect.MatchString(asl) {
+			t.Errorf("state machine definition does not match %s", expect)
+		}
+	}
+}
+
+func TestVersionStable(t *testing.T) {
+	versionOf := func(message string, attempts float64) string {
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+		event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+		queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+		table := awsdynamodb.Table_FromTableName(stack, jsii.String("Table"), jsii.String("quota"))
+
+		a := typestep.Function_FromFunctionArn[string, Contact](stack, jsii.String("A"),
+			jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+		p1 := typestep.From[Order](event)
+		p2 := typestep.Assert(typestep.Prefix(func(o *Order) *string { return &o.Customer }, "c-"), message, p1)
+		p3 := typestep.Quota(table, "erp", 10, p2)
+		p4 := typestep.Lookup(map[int]string{1: "basic", 2: "premium"}, func(o *Order) *int { return &o.Seq }, p3)
+		p5 := typestep.Join(typestep.WithRetry(a, &awsstepfunctions.RetryProps{MaxAttempts: jsii.Number(attempts)}), p4)
+		p6 := typestep.ToQueue(queue, p5)
+
+		ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+		typestep.StateMachine(ts, p6)
+
+		template := assertions.Template_FromStack(stack, nil)
+		version := regexp.MustCompile(`"typestep-version":\{"DataType":"String","StringValue":"([0-9a-f]{12})"\}`).FindStringSubmatch(definitionOf(template))
+		if len(version) != 2 {
+			t.Fatalf("state machine definition is not versioned")
+		}
+		return version[1]
+	}
+
+	if a, b := versionOf("unknown customer", 3), versionOf("unknown customer", 3); a != b {
+		t.Errorf("builds of the same pipeline have different versions %s and %s", a, b)
+	}
+
+	if a, b := versionOf("unknown customer", 3), versionOf("invalid customer", 3); a == b {
+		t.Errorf("builds of different pipelines have the same version %s", a)
+	}
+
+	if a, b := versionOf("unknown customer", 3), versionOf("unknown customer", 5); a == b {
+		t.Errorf("builds of different pipelines have the same version %s", a)
+	}
+}
@@ -0,0 +1,113 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awspipes"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/golem/duct"
+)
+
+// the longest batching window of EventBridge Pipes
+const maxWindow = 5 * time.Minute
+
+// Creates new morphism 𝑚, binding it with EventBridge for reading category `A`
+// events in windows. Events are buffered by AWS SQS queue, the pipeline starts
+// with the sequence []A once per window or when the batch `size` is reached.
+// The window is up to 5 minutes.
+//
+//	typestep.FromWindow[Order](bus, 5*time.Minute, 500)
+//
+// The correlation id of windowed pipeline is the name of execution.
+func FromWindow[A any](in awsevents.IEventBus, window time.Duration, size int, cat ...string) duct.Morphism[[]A, []A] {
+	return duct.From(duct.L1[[]A](windowed{cat: cat, bus: in, kind: reflect.TypeOf(new(A)).Elem(), window: window, size: size}))
+}
+
+type windowed struct {
+	cat    []string
+	kind   reflect.Type
+	bus    awsevents.IEventBus
+	window time.Duration
+	size   int
+}
+
+// unpack the batch of SQS messages, the body of message is the event's detail
+func (ts *typeStep) unpack() {
+	batch := awsstepfunctions.Pass_Jsonata(ts.scope, jsii.String("Window"),
+		&awsstepfunctions.PassJsonataProps{
+			Outputs: map[string]any{
+				"detail": "{% $map($states.input, function($m) { $parse($m.body) })[] %}",
+			},
+		},
+	)
+	ts.append(batch)
+}
+
+// buffer events of the windowed source into queue, the pipe starts
+// the pipeline with batch of messages.
+//
+//	Rule ⟼ SQS ⟼ Pipe ⟼ StateMachine
+func (ts *typeStep) buffer(states awsstepfunctions.IStateMachine) error {
+	if ts.windowed.window > maxWindow {
+		return fmt.Errorf("window %s exceeds %s", ts.windowed.window, maxWindow)
+	}
+
+	queue := awssqs.NewQueue(ts.scope, jsii.String("WindowQueue"), &awssqs.QueueProps{})
+
+	awsevents.NewRule(ts.scope, jsii.String("Rule"),
+		&awsevents.RuleProps{
+			EventBus:     ts.bus,
+			EventPattern: ts.eventPattern,
+		},
+	).AddTarget(
+		awseventstargets.NewSqsQueue(queue,
+			&awseventstargets.SqsQueueProps{
+				Message: awsevents.RuleTargetInput_FromEventPath(jsii.String("$.detail")),
+			},
+		),
+	)
+
+	role := awsiam.NewRole(ts.scope, jsii.String("WindowRole"),
+		&awsiam.RoleProps{
+			AssumedBy: awsiam.NewServicePrincipal(jsii.String("pipes.amazonaws.com"), nil),
+		},
+	)
+	queue.GrantConsumeMessages(role)
+	states.GrantStartExecution(role)
+
+	awspipes.NewCfnPipe(ts.scope, jsii.String("Pipe"),
+		&awspipes.CfnPipeProps{
+			RoleArn: role.RoleArn(),
+			Source:  queue.QueueArn(),
+			Target:  states.StateMachineArn(),
+			SourceParameters: &awspipes.CfnPipe_PipeSourceParametersProperty{
+				SqsQueueParameters: &awspipes.CfnPipe_PipeSourceSqsQueueParametersProperty{
+					BatchSize:                      jsii.Number(ts.windowed.size),
+					MaximumBatchingWindowInSeconds: jsii.Number(ts.windowed.window.Seconds()),
+				},
+			},
+			TargetParameters: &awspipes.CfnPipe_PipeTargetParametersProperty{
+				StepFunctionStateMachineParameters: &awspipes.CfnPipe_PipeTargetStateMachineParametersProperty{
+					InvocationType: jsii.String("FIRE_AND_FORGET"),
+				},
+			},
+		},
+	)
+
+	return nil
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (C) 2025 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/typestep
+//
+
+package typestep_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/fogfish/typestep"
+)
+
+func TestFromWindow(t *testing.T) {
+	// GIVEN
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("Test"), nil)
+	event := awsevents.EventBus_FromEventBusArn(stack, jsii.String("Events"), jsii.String("arn:aws:events:eu-west-1:000000000000:event-bus:my-event-bus"))
+	queue := awssqs.Queue_FromQueueArn(stack, jsii.String("Queue"), jsii.String("arn:aws:sqs:eu-west-1:000000000000:my-queue"))
+
+	a := typestep.Function_FromFunctionArn[[]string, string](stack, jsii.String("A"),
+		jsii.String("arn:aws:lambda:eu-west-1:000000000000:function:my-function"))
+
+	// THEN
+	p1 := typestep.FromWindow[string](event, 5*time.Minute, 500)
+	p2 := typestep.Join(a, p1)
+	p3 := typestep.ToQueue(queue, p2)
+
+	ts := typestep.NewTypeStep(stack, jsii.String("Pipe"), &typestep.TypeStepProps{})
+	typestep.StateMachine(ts, p3)
+
+	// WHEN
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::Events::Rule"),
+		map[string]any{
+			"EventPattern": map[string]any{"detail-type": []string{"string"}},
+			"Targets": []any{
+				assertions.Match_ObjectLike(&map[string]any{"InputPath": "$.detail"}),
+			},
+		},
+	)
+	template.HasResourceProperties(jsii.String("AWS::Pipes::Pipe"),
+		map[string]any{
+			"SourceParameters": map[string]any{
+				"SqsQueueParameters": map[string]any{
+					"BatchSize":                      500,
+					"MaximumBatchingWindowInSeconds": 300,
+				},
+			},
+			"TargetParameters": map[string]any{
+				"StepFunctionStateMachineParameters": map[string]any{"InvocationType": "FIRE_AND_FORGET"},
+			},
+		},
+	)
+
+	asl := definitionOf(template)
+	for _, expect := range []string{
+		`"Window":{"Type":"Pass","QueryLanguage":"JSONata","Output":{"detail":"{% $map($states.input, function($m) { $parse($m.body) })[] %}"},"Next":"MapA"}`,
+		`"MapA":{"Next":"Sink","Retry":`,
+		`"InputPath":"$.detail"`,
+	} {
+		if !strings.Contains(asl, expect) {
+			t.Errorf("state machine definition does not contain %s", expect)
+		}
+	}
+}